@@ -0,0 +1,626 @@
+package orderedmap
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+)
+
+// The MessagePack and CBOR codecs share the same data model, so the reflection
+// that maps Go values onto that model lives here and each format only has to
+// read and write its own framing.
+
+// binaryWriter writes the primitives of a binary format.
+type binaryWriter interface {
+	writeNil()
+	writeBool(b bool)
+	writeInt(i int64)
+	writeUint(u uint64)
+	writeFloat32(f float32)
+	writeFloat64(f float64)
+	writeString(s string)
+	writeBytes(b []byte)
+	writeTime(t time.Time)
+	writeArrayHeader(n int)
+	writeMapHeader(n int)
+}
+
+// binaryReader reads the primitives of a binary format one token at a time.
+type binaryReader interface {
+	next() (binaryToken, error)
+
+	// enter and leave are called around the elements of each array or map,
+	// see binaryDepth.
+	enter() error
+	leave()
+}
+
+// maxBinaryDepth is the maximum nesting of arrays and maps, the same as
+// encoding/json. Without a limit, deeply nested data would overflow the stack
+// (which cannot be recovered from) rather than return an error.
+const maxBinaryDepth = 10000
+
+// binaryDepth counts the arrays and maps that are being decoded. It is
+// embedded in each binaryReader.
+type binaryDepth struct {
+	depth int
+}
+
+func (d *binaryDepth) enter() error {
+	if d.depth >= maxBinaryDepth {
+		return errBinaryDepth
+	}
+	d.depth++
+
+	return nil
+}
+
+func (d *binaryDepth) leave() {
+	d.depth--
+}
+
+type binaryKind int
+
+const (
+	binaryNil binaryKind = iota
+	binaryBool
+	binaryInt
+	binaryUint
+	binaryFloat
+	binaryString
+	binaryBytes
+	binaryTime
+	binaryArray
+	binaryMap
+
+	// binaryBreak ends an array or map of unknown length.
+	binaryBreak
+)
+
+func (k binaryKind) String() string {
+	return [...]string{"nil", "bool", "int", "uint", "float", "string", "bytes",
+		"time", "array", "map", "break"}[k]
+}
+
+type binaryToken struct {
+	kind binaryKind
+	b    bool
+	i    int64
+	u    uint64
+	f    float64
+	s    []byte
+	t    time.Time
+
+	// n is the number of elements (or pairs) for an array or map, or -1 if the
+	// length is not known until a break is read.
+	n int
+}
+
+// binaryCoder is implemented by all *OrderedMap types so that nested ordered
+// maps are written and read element by element, in order.
+type binaryCoder interface {
+	encodeBinary(w binaryWriter) error
+	decodeBinary(r binaryReader, tok binaryToken) error
+}
+
+var (
+	binaryCoderType     = reflect.TypeFor[binaryCoder]()
+	timeType            = reflect.TypeFor[time.Time]()
+	textMarshalerType   = reflect.TypeFor[encoding.TextMarshaler]()
+	errBinaryUnexpected = errors.New("orderedmap: unexpected end of data")
+	errBinaryDepth      = fmt.Errorf("orderedmap: exceeded max depth of %d", maxBinaryDepth)
+)
+
+func (m *OrderedMap[K, V]) encodeBinary(w binaryWriter) error {
+	if m == nil {
+		w.writeNil()
+		return nil
+	}
+
+	w.writeMapHeader(m.Len())
+	for el := m.Front(); el != nil; el = el.Next() {
+		if err := encodeBinaryValue(w, reflect.ValueOf(&el.Key).Elem()); err != nil {
+			return err
+		}
+		if err := encodeBinaryValue(w, reflect.ValueOf(&el.Value).Elem()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *OrderedMap[K, V]) decodeBinary(r binaryReader, tok binaryToken) error {
+	if tok.kind == binaryNil {
+		return nil
+	}
+	if tok.kind != binaryMap {
+		return fmt.Errorf("orderedmap: cannot decode %s into %T", tok.kind, m)
+	}
+
+	m.lazyInit()
+
+	return decodeBinaryPairs(r, tok, func(keyTok binaryToken) error {
+		var key K
+		if err := decodeBinaryValue(r, keyTok, reflect.ValueOf(&key).Elem()); err != nil {
+			return err
+		}
+		if err := checkBinaryKey(key, keyTok); err != nil {
+			return err
+		}
+
+		valueTok, err := r.next()
+		if err != nil {
+			return err
+		}
+
+		var value V
+		if err := decodeBinaryValue(r, valueTok, reflect.ValueOf(&value).Elem()); err != nil {
+			return err
+		}
+
+		m.Set(key, value)
+
+		return nil
+	})
+}
+
+// checkBinaryKey returns an error if key cannot be used as a map key, which is
+// only possible when the key is an interface holding a slice or map. A nil key
+// is allowed.
+func checkBinaryKey(key any, tok binaryToken) error {
+	if key != nil && !reflect.TypeOf(key).Comparable() {
+		return fmt.Errorf("orderedmap: cannot use %s as a map key", tok.kind)
+	}
+
+	return nil
+}
+
+func encodeBinaryValue(w binaryWriter, v reflect.Value) error {
+	if !v.IsValid() {
+		w.writeNil()
+		return nil
+	}
+
+	if v.Type().Implements(binaryCoderType) {
+		return v.Interface().(binaryCoder).encodeBinary(w)
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(binaryCoderType) {
+		return v.Addr().Interface().(binaryCoder).encodeBinary(w)
+	}
+
+	if v.Type() == timeType {
+		w.writeTime(v.Interface().(time.Time))
+		return nil
+	}
+
+	if v.Type().Implements(textMarshalerType) {
+		if v.Kind() == reflect.Pointer && v.IsNil() {
+			w.writeNil()
+			return nil
+		}
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return err
+		}
+		w.writeString(string(text))
+
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			w.writeNil()
+			return nil
+		}
+		return encodeBinaryValue(w, v.Elem())
+
+	case reflect.Bool:
+		w.writeBool(v.Bool())
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		w.writeInt(v.Int())
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64, reflect.Uintptr:
+		w.writeUint(v.Uint())
+
+	case reflect.Float32:
+		w.writeFloat32(float32(v.Float()))
+
+	case reflect.Float64:
+		w.writeFloat64(v.Float())
+
+	case reflect.String:
+		w.writeString(v.String())
+
+	case reflect.Slice:
+		if v.IsNil() {
+			w.writeNil()
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			w.writeBytes(v.Bytes())
+			return nil
+		}
+		return encodeBinaryArray(w, v)
+
+	case reflect.Array:
+		return encodeBinaryArray(w, v)
+
+	case reflect.Map:
+		if v.IsNil() {
+			w.writeNil()
+			return nil
+		}
+		w.writeMapHeader(v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			if err := encodeBinaryValue(w, iter.Key()); err != nil {
+				return err
+			}
+			if err := encodeBinaryValue(w, iter.Value()); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Struct:
+		fields := exportedFields(v.Type())
+		w.writeMapHeader(len(fields))
+		for _, field := range fields {
+			w.writeString(field.Name)
+			if err := encodeBinaryValue(w, v.FieldByIndex(field.Index)); err != nil {
+				return err
+			}
+		}
+
+	default:
+		return fmt.Errorf("orderedmap: cannot encode value of type %s", v.Type())
+	}
+
+	return nil
+}
+
+func encodeBinaryArray(w binaryWriter, v reflect.Value) error {
+	w.writeArrayHeader(v.Len())
+	for i := 0; i < v.Len(); i++ {
+		if err := encodeBinaryValue(w, v.Index(i)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func exportedFields(t reflect.Type) []reflect.StructField {
+	var fields []reflect.StructField
+	for _, field := range reflect.VisibleFields(t) {
+		if field.IsExported() && !field.Anonymous {
+			fields = append(fields, field)
+		}
+	}
+
+	return fields
+}
+
+// decodeBinaryValue decodes the value that starts with tok into v.
+func decodeBinaryValue(r binaryReader, tok binaryToken, v reflect.Value) error {
+	if tok.kind == binaryBreak {
+		return fmt.Errorf("orderedmap: unexpected break")
+	}
+
+	if v.Kind() == reflect.Pointer {
+		if tok.kind == binaryNil {
+			v.SetZero()
+			return nil
+		}
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		if m, ok := v.Interface().(binaryCoder); ok {
+			return m.decodeBinary(r, tok)
+		}
+
+		return decodeBinaryValue(r, tok, v.Elem())
+	}
+
+	if v.Kind() == reflect.Interface && v.NumMethod() == 0 {
+		value, err := decodeBinaryAny(r, tok)
+		if err != nil {
+			return err
+		}
+		if value == nil {
+			v.SetZero()
+		} else {
+			v.Set(reflect.ValueOf(value))
+		}
+
+		return nil
+	}
+
+	if v.CanAddr() {
+		if m, ok := v.Addr().Interface().(binaryCoder); ok {
+			return m.decodeBinary(r, tok)
+		}
+	}
+
+	if tok.kind == binaryNil {
+		v.SetZero()
+		return nil
+	}
+
+	if v.Type() == timeType && tok.kind == binaryTime {
+		v.Set(reflect.ValueOf(tok.t))
+		return nil
+	}
+
+	if tok.kind == binaryString && v.CanAddr() {
+		if tu, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+			return tu.UnmarshalText(tok.s)
+		}
+	}
+
+	mismatch := func() error {
+		return fmt.Errorf("orderedmap: cannot decode %s into %s", tok.kind, v.Type())
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		if tok.kind != binaryBool {
+			return mismatch()
+		}
+		v.SetBool(tok.b)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var n int64
+		switch tok.kind {
+		case binaryInt:
+			n = tok.i
+		case binaryUint:
+			if tok.u > math.MaxInt64 {
+				return fmt.Errorf("orderedmap: %d overflows %s", tok.u, v.Type())
+			}
+			n = int64(tok.u)
+		default:
+			return mismatch()
+		}
+		if v.OverflowInt(n) {
+			return fmt.Errorf("orderedmap: %d overflows %s", n, v.Type())
+		}
+		v.SetInt(n)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64, reflect.Uintptr:
+		var n uint64
+		switch tok.kind {
+		case binaryUint:
+			n = tok.u
+		case binaryInt:
+			if tok.i < 0 {
+				return fmt.Errorf("orderedmap: %d overflows %s", tok.i, v.Type())
+			}
+			n = uint64(tok.i)
+		default:
+			return mismatch()
+		}
+		if v.OverflowUint(n) {
+			return fmt.Errorf("orderedmap: %d overflows %s", n, v.Type())
+		}
+		v.SetUint(n)
+
+	case reflect.Float32, reflect.Float64:
+		switch tok.kind {
+		case binaryFloat:
+			v.SetFloat(tok.f)
+		case binaryInt:
+			v.SetFloat(float64(tok.i))
+		case binaryUint:
+			v.SetFloat(float64(tok.u))
+		default:
+			return mismatch()
+		}
+
+	case reflect.String:
+		if tok.kind != binaryString && tok.kind != binaryBytes {
+			return mismatch()
+		}
+		v.SetString(string(tok.s))
+
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 &&
+			(tok.kind == binaryBytes || tok.kind == binaryString) {
+			v.SetBytes(append([]byte(nil), tok.s...))
+			return nil
+		}
+		if tok.kind != binaryArray {
+			return mismatch()
+		}
+
+		v.Set(reflect.MakeSlice(v.Type(), 0, max(tok.n, 0)))
+		return decodeBinaryElements(r, tok, func(i int, tok binaryToken) error {
+			v.Set(reflect.Append(v, reflect.Zero(v.Type().Elem())))
+			return decodeBinaryValue(r, tok, v.Index(i))
+		})
+
+	case reflect.Array:
+		if tok.kind != binaryArray {
+			return mismatch()
+		}
+		v.SetZero()
+
+		return decodeBinaryElements(r, tok, func(i int, tok binaryToken) error {
+			if i >= v.Len() {
+				var discard any
+				return decodeBinaryValue(r, tok, reflect.ValueOf(&discard).Elem())
+			}
+			return decodeBinaryValue(r, tok, v.Index(i))
+		})
+
+	case reflect.Map:
+		if tok.kind != binaryMap {
+			return mismatch()
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+
+		return decodeBinaryPairs(r, tok, func(keyTok binaryToken) error {
+			key := reflect.New(v.Type().Key()).Elem()
+			if err := decodeBinaryValue(r, keyTok, key); err != nil {
+				return err
+			}
+
+			valueTok, err := r.next()
+			if err != nil {
+				return err
+			}
+			value := reflect.New(v.Type().Elem()).Elem()
+			if err := decodeBinaryValue(r, valueTok, value); err != nil {
+				return err
+			}
+			v.SetMapIndex(key, value)
+
+			return nil
+		})
+
+	case reflect.Struct:
+		if tok.kind != binaryMap {
+			return mismatch()
+		}
+
+		return decodeBinaryPairs(r, tok, func(keyTok binaryToken) error {
+			var name string
+			if err := decodeBinaryValue(r, keyTok, reflect.ValueOf(&name).Elem()); err != nil {
+				return err
+			}
+
+			valueTok, err := r.next()
+			if err != nil {
+				return err
+			}
+
+			field, ok := v.Type().FieldByName(name)
+			if !ok || !field.IsExported() {
+				var discard any
+				return decodeBinaryValue(r, valueTok, reflect.ValueOf(&discard).Elem())
+			}
+
+			return decodeBinaryValue(r, valueTok, v.FieldByIndex(field.Index))
+		})
+
+	default:
+		return mismatch()
+	}
+
+	return nil
+}
+
+// decodeBinaryAny decodes the value that starts with tok in the same way as
+// decoding into an interface value. Maps become *OrderedMap[string, any] if
+// all of their keys are strings, otherwise *OrderedMap[any, any].
+func decodeBinaryAny(r binaryReader, tok binaryToken) (any, error) {
+	switch tok.kind {
+	case binaryNil:
+		return nil, nil
+	case binaryBool:
+		return tok.b, nil
+	case binaryInt:
+		return tok.i, nil
+	case binaryUint:
+		return tok.u, nil
+	case binaryFloat:
+		return tok.f, nil
+	case binaryString:
+		return string(tok.s), nil
+	case binaryBytes:
+		return append([]byte(nil), tok.s...), nil
+	case binaryTime:
+		return tok.t, nil
+
+	case binaryArray:
+		values := make([]any, 0, max(tok.n, 0))
+		err := decodeBinaryElements(r, tok, func(_ int, tok binaryToken) error {
+			value, err := decodeBinaryAny(r, tok)
+			values = append(values, value)
+			return err
+		})
+
+		return values, err
+
+	case binaryMap:
+		m := NewOrderedMap[any, any]()
+		stringKeys := true
+		err := decodeBinaryPairs(r, tok, func(keyTok binaryToken) error {
+			key, err := decodeBinaryAny(r, keyTok)
+			if err != nil {
+				return err
+			}
+			if err := checkBinaryKey(key, keyTok); err != nil {
+				return err
+			}
+			if _, ok := key.(string); !ok {
+				stringKeys = false
+			}
+
+			valueTok, err := r.next()
+			if err != nil {
+				return err
+			}
+			value, err := decodeBinaryAny(r, valueTok)
+			if err != nil {
+				return err
+			}
+			m.Set(key, value)
+
+			return nil
+		})
+		if err != nil || !stringKeys {
+			return m, err
+		}
+
+		sm := NewOrderedMapWithCapacity[string, any](m.Len())
+		for key, value := range m.AllFromFront() {
+			sm.Set(key.(string), value)
+		}
+
+		return sm, nil
+	}
+
+	return nil, fmt.Errorf("orderedmap: unexpected %s", tok.kind)
+}
+
+// decodeBinaryElements calls fn with the first token of each element in an
+// array.
+func decodeBinaryElements(r binaryReader, tok binaryToken, fn func(i int, tok binaryToken) error) error {
+	if err := r.enter(); err != nil {
+		return err
+	}
+	defer r.leave()
+
+	for i := 0; tok.n < 0 || i < tok.n; i++ {
+		elTok, err := r.next()
+		if err != nil {
+			return err
+		}
+		if elTok.kind == binaryBreak && tok.n < 0 {
+			return nil
+		}
+		if err := fn(i, elTok); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// decodeBinaryPairs calls fn with the first token of each key in a map. fn is
+// responsible for reading the value.
+func decodeBinaryPairs(r binaryReader, tok binaryToken, fn func(keyTok binaryToken) error) error {
+	return decodeBinaryElements(r, tok, func(_ int, keyTok binaryToken) error {
+		return fn(keyTok)
+	})
+}
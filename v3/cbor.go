@@ -0,0 +1,346 @@
+package orderedmap
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+)
+
+// CBOR major types.
+const (
+	cborUint   = 0
+	cborNegInt = 1
+	cborBytes  = 2
+	cborString = 3
+	cborArray  = 4
+	cborMap    = 5
+	cborTag    = 6
+	cborSimple = 7
+)
+
+// MarshalCBOR encodes the map as a CBOR (RFC 8949) map with the elements in
+// the same order as the map (front to back). Nested ordered maps are also
+// written in order.
+//
+// Values are encoded in the same way as MarshalMsgpack, except that time.Time
+// is written as an RFC 3339 string with tag 0.
+//
+// The method name and signature match the Marshaler interface used by popular
+// CBOR packages, so ordered maps are encoded in order by them too.
+func (m *OrderedMap[K, V]) MarshalCBOR() ([]byte, error) {
+	w := &cborWriter{}
+	if err := m.encodeBinary(w); err != nil {
+		return nil, err
+	}
+
+	return w.buf.Bytes(), nil
+}
+
+// UnmarshalCBOR decodes a CBOR map, adding the elements in the order they
+// appear. Existing elements are kept, and a null leaves the map unchanged.
+//
+// Values are decoded in the same way as UnmarshalMsgpack. Maps, arrays and
+// strings of indefinite length are supported. Tags 0 and 1 are decoded as
+// time.Time, and all other tags are ignored (the tagged value is decoded).
+func (m *OrderedMap[K, V]) UnmarshalCBOR(data []byte) error {
+	r := &cborReader{data: data}
+	tok, err := r.next()
+	if err != nil {
+		return err
+	}
+	if err := m.decodeBinary(r, tok); err != nil {
+		return err
+	}
+	if r.pos != len(r.data) {
+		return fmt.Errorf("orderedmap: %d bytes of trailing data", len(r.data)-r.pos)
+	}
+
+	return nil
+}
+
+type cborWriter struct {
+	buf bytes.Buffer
+}
+
+// writeHead writes the initial byte (and argument) of a data item using the
+// shortest form for n.
+func (w *cborWriter) writeHead(major byte, n uint64) {
+	major <<= 5
+	switch {
+	case n < 24:
+		w.buf.WriteByte(major | byte(n))
+	case n <= math.MaxUint8:
+		w.buf.Write([]byte{major | 24, byte(n)})
+	case n <= math.MaxUint16:
+		w.buf.WriteByte(major | 25)
+		w.buf.Write(binary.BigEndian.AppendUint16(nil, uint16(n)))
+	case n <= math.MaxUint32:
+		w.buf.WriteByte(major | 26)
+		w.buf.Write(binary.BigEndian.AppendUint32(nil, uint32(n)))
+	default:
+		w.buf.WriteByte(major | 27)
+		w.buf.Write(binary.BigEndian.AppendUint64(nil, n))
+	}
+}
+
+func (w *cborWriter) writeNil() {
+	w.buf.WriteByte(0xf6)
+}
+
+func (w *cborWriter) writeBool(b bool) {
+	if b {
+		w.buf.WriteByte(0xf5)
+	} else {
+		w.buf.WriteByte(0xf4)
+	}
+}
+
+func (w *cborWriter) writeInt(i int64) {
+	if i < 0 {
+		w.writeHead(cborNegInt, uint64(-(i + 1)))
+	} else {
+		w.writeHead(cborUint, uint64(i))
+	}
+}
+
+func (w *cborWriter) writeUint(u uint64) {
+	w.writeHead(cborUint, u)
+}
+
+func (w *cborWriter) writeFloat32(f float32) {
+	w.buf.WriteByte(0xfa)
+	w.buf.Write(binary.BigEndian.AppendUint32(nil, math.Float32bits(f)))
+}
+
+func (w *cborWriter) writeFloat64(f float64) {
+	w.buf.WriteByte(0xfb)
+	w.buf.Write(binary.BigEndian.AppendUint64(nil, math.Float64bits(f)))
+}
+
+func (w *cborWriter) writeString(s string) {
+	w.writeHead(cborString, uint64(len(s)))
+	w.buf.WriteString(s)
+}
+
+func (w *cborWriter) writeBytes(b []byte) {
+	w.writeHead(cborBytes, uint64(len(b)))
+	w.buf.Write(b)
+}
+
+func (w *cborWriter) writeTime(t time.Time) {
+	w.writeHead(cborTag, 0)
+	w.writeString(t.Format(time.RFC3339Nano))
+}
+
+func (w *cborWriter) writeArrayHeader(n int) {
+	w.writeHead(cborArray, uint64(n))
+}
+
+func (w *cborWriter) writeMapHeader(n int) {
+	w.writeHead(cborMap, uint64(n))
+}
+
+type cborReader struct {
+	binaryDepth
+	data []byte
+	pos  int
+}
+
+func (r *cborReader) read(n uint64) ([]byte, error) {
+	if n > uint64(len(r.data)-r.pos) {
+		return nil, errBinaryUnexpected
+	}
+	b := r.data[r.pos : r.pos+int(n)]
+	r.pos += int(n)
+
+	return b, nil
+}
+
+// readHead reads the initial byte of a data item and its argument. indefinite
+// is true if the additional information is 31.
+func (r *cborReader) readHead() (major, info byte, arg uint64, indefinite bool, err error) {
+	b, err := r.read(1)
+	if err != nil {
+		return 0, 0, 0, false, err
+	}
+
+	major, info = b[0]>>5, b[0]&0x1f
+	switch {
+	case info < 24:
+		return major, info, uint64(info), false, nil
+	case info <= 27:
+		b, err := r.read(1 << (info - 24))
+		if err != nil {
+			return 0, 0, 0, false, err
+		}
+		for _, c := range b {
+			arg = arg<<8 | uint64(c)
+		}
+		return major, info, arg, false, nil
+	case info == 31:
+		return major, info, 0, true, nil
+	}
+
+	return 0, 0, 0, false, fmt.Errorf("orderedmap: invalid CBOR additional information %d", info)
+}
+
+// readLength checks that a length of n items (each at least one byte) can fit
+// in the data that is left so that corrupt input cannot cause huge
+// allocations.
+func (r *cborReader) readLength(n uint64) (int, error) {
+	if n > uint64(len(r.data)-r.pos) {
+		return 0, errBinaryUnexpected
+	}
+
+	return int(n), nil
+}
+
+func (r *cborReader) next() (binaryToken, error) {
+	major, info, arg, indefinite, err := r.readHead()
+	if err != nil {
+		return binaryToken{}, err
+	}
+
+	if indefinite {
+		switch major {
+		case cborBytes, cborString:
+			return r.readChunks(major)
+		case cborArray:
+			return binaryToken{kind: binaryArray, n: -1}, nil
+		case cborMap:
+			return binaryToken{kind: binaryMap, n: -1}, nil
+		case cborSimple:
+			return binaryToken{kind: binaryBreak}, nil
+		}
+
+		return binaryToken{}, fmt.Errorf("orderedmap: invalid indefinite length for CBOR major type %d", major)
+	}
+
+	switch major {
+	case cborUint:
+		if arg <= math.MaxInt64 {
+			return binaryToken{kind: binaryInt, i: int64(arg)}, nil
+		}
+		return binaryToken{kind: binaryUint, u: arg}, nil
+
+	case cborNegInt:
+		if arg > math.MaxInt64 {
+			return binaryToken{}, fmt.Errorf("orderedmap: CBOR integer -1-%d overflows int64", arg)
+		}
+		return binaryToken{kind: binaryInt, i: -1 - int64(arg)}, nil
+
+	case cborBytes, cborString:
+		b, err := r.read(arg)
+		kind := binaryBytes
+		if major == cborString {
+			kind = binaryString
+		}
+		return binaryToken{kind: kind, s: b}, err
+
+	case cborArray:
+		n, err := r.readLength(arg)
+		return binaryToken{kind: binaryArray, n: n}, err
+
+	case cborMap:
+		// Each pair is at least two bytes.
+		if arg > uint64(len(r.data)-r.pos)/2 {
+			return binaryToken{}, errBinaryUnexpected
+		}
+		return binaryToken{kind: binaryMap, n: int(arg)}, nil
+
+	case cborTag:
+		return r.readTag(arg)
+	}
+
+	switch info {
+	case 20, 21:
+		return binaryToken{kind: binaryBool, b: info == 21}, nil
+	case 22, 23:
+		return binaryToken{kind: binaryNil}, nil
+	case 25:
+		return binaryToken{kind: binaryFloat, f: halfToFloat64(uint16(arg))}, nil
+	case 26:
+		return binaryToken{kind: binaryFloat, f: float64(math.Float32frombits(uint32(arg)))}, nil
+	case 27:
+		return binaryToken{kind: binaryFloat, f: math.Float64frombits(arg)}, nil
+	}
+
+	return binaryToken{}, fmt.Errorf("orderedmap: unsupported CBOR simple value %d", arg)
+}
+
+// readChunks reads a string of indefinite length by joining its chunks, which
+// must all be definite length strings of the same major type.
+func (r *cborReader) readChunks(major byte) (binaryToken, error) {
+	var s []byte
+	for {
+		chunkMajor, _, arg, indefinite, err := r.readHead()
+		if err != nil {
+			return binaryToken{}, err
+		}
+		if chunkMajor == cborSimple && indefinite {
+			break
+		}
+		if chunkMajor != major || indefinite {
+			return binaryToken{}, fmt.Errorf("orderedmap: invalid chunk in CBOR string of indefinite length")
+		}
+		b, err := r.read(arg)
+		if err != nil {
+			return binaryToken{}, err
+		}
+		s = append(s, b...)
+	}
+
+	if major == cborString {
+		return binaryToken{kind: binaryString, s: s}, nil
+	}
+
+	return binaryToken{kind: binaryBytes, s: s}, nil
+}
+
+// readTag reads the value of a tag. Tag 0 (an RFC 3339 string) and tag 1
+// (seconds since the epoch) are returned as a time, all other tags are
+// ignored.
+func (r *cborReader) readTag(tag uint64) (binaryToken, error) {
+	tok, err := r.next()
+	if err != nil || (tag != 0 && tag != 1) {
+		return tok, err
+	}
+
+	switch {
+	case tag == 0 && tok.kind == binaryString:
+		t, err := time.Parse(time.RFC3339Nano, string(tok.s))
+		return binaryToken{kind: binaryTime, t: t}, err
+
+	case tag == 1 && tok.kind == binaryInt:
+		return binaryToken{kind: binaryTime, t: time.Unix(tok.i, 0)}, nil
+
+	case tag == 1 && tok.kind == binaryFloat:
+		sec, frac := math.Modf(tok.f)
+		return binaryToken{kind: binaryTime, t: time.Unix(int64(sec), int64(frac*1e9))}, nil
+	}
+
+	return binaryToken{}, fmt.Errorf("orderedmap: invalid %s for CBOR tag %d", tok.kind, tag)
+}
+
+// halfToFloat64 converts an IEEE 754 half-precision float.
+func halfToFloat64(h uint16) float64 {
+	sign := 1.0
+	if h&0x8000 != 0 {
+		sign = -1
+	}
+	exp, mant := int(h>>10)&0x1f, float64(h&0x3ff)
+
+	switch exp {
+	case 0:
+		return sign * math.Ldexp(mant, -24)
+	case 0x1f:
+		if mant == 0 {
+			return math.Inf(int(sign))
+		}
+		return math.NaN()
+	}
+
+	return sign * math.Ldexp(mant+1024, exp-25)
+}
@@ -0,0 +1,168 @@
+package orderedmap_test
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/elliotchance/orderedmap/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderedMap_MarshalCBOR(t *testing.T) {
+	t.Run("EmptyMap", func(t *testing.T) {
+		data, err := orderedmap.NewOrderedMap[string, int]().MarshalCBOR()
+		require.NoError(t, err)
+		assert.Equal(t, []byte{0xa0}, data)
+	})
+
+	t.Run("NilMap", func(t *testing.T) {
+		var m *orderedmap.OrderedMap[string, int]
+		data, err := m.MarshalCBOR()
+		require.NoError(t, err)
+		assert.Equal(t, []byte{0xf6}, data)
+	})
+
+	t.Run("RetainsOrder", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, any]()
+		m.Set("z", 1)
+		m.Set("a", -500)
+		m.Set("m", []any{false, nil})
+		data, err := m.MarshalCBOR()
+		require.NoError(t, err)
+		assert.Equal(t, []byte{
+			0xa3,
+			0x61, 'z', 0x01,
+			0x61, 'a', 0x39, 0x01, 0xf3,
+			0x61, 'm', 0x82, 0xf4, 0xf6,
+		}, data)
+	})
+
+	t.Run("Time", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, time.Time]()
+		m.Set("t", time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+		data, err := m.MarshalCBOR()
+		require.NoError(t, err)
+		assert.Equal(t, append([]byte{0xa1, 0x61, 't', 0xc0, 0x74}, "2024-01-02T03:04:05Z"...), data)
+	})
+}
+
+func TestOrderedMap_UnmarshalCBOR(t *testing.T) {
+	t.Run("NestedAny", func(t *testing.T) {
+		inner := orderedmap.NewOrderedMap[string, any]()
+		inner.Set("y", float32(1.5))
+		inner.Set("x", []byte("raw"))
+		m := orderedmap.NewOrderedMap[string, any]()
+		m.Set("inner", inner)
+		data, err := m.MarshalCBOR()
+		require.NoError(t, err)
+
+		m2 := orderedmap.NewOrderedMap[string, any]()
+		require.NoError(t, m2.UnmarshalCBOR(data))
+		got := m2.GetOrDefault("inner", nil).(*orderedmap.OrderedMap[string, any])
+		assert.Equal(t, []string{"y", "x"}, slices.Collect(got.Keys()))
+		assert.Equal(t, 1.5, got.GetOrDefault("y", nil))
+		assert.Equal(t, []byte("raw"), got.GetOrDefault("x", nil))
+	})
+
+	t.Run("IndefiniteLength", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, any]()
+		require.NoError(t, m.UnmarshalCBOR([]byte{
+			0xbf,
+			0x7f, 0x61, 'b', 0x61, 'c', 0xff, 0x9f, 0x01, 0x02, 0xff,
+			0x61, 'a', 0x5f, 0x41, 0x01, 0x41, 0x02, 0xff,
+			0xff,
+		}))
+		assert.Equal(t, []string{"bc", "a"}, slices.Collect(m.Keys()))
+		assert.Equal(t, []any{int64(1), int64(2)}, m.GetOrDefault("bc", nil))
+		assert.Equal(t, []byte{1, 2}, m.GetOrDefault("a", nil))
+	})
+
+	t.Run("SimpleAndFloats", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, any]()
+		require.NoError(t, m.UnmarshalCBOR([]byte{
+			0xa4,
+			0x61, 'h', 0xf9, 0x3e, 0x00,
+			0x61, 's', 0xfa, 0x3f, 0xc0, 0x00, 0x00,
+			0x61, 'u', 0xf7,
+			0x61, 't', 0xf5,
+		}))
+		assert.Equal(t, 1.5, m.GetOrDefault("h", nil))
+		assert.Equal(t, 1.5, m.GetOrDefault("s", nil))
+		assert.Nil(t, m.GetOrDefault("u", 0))
+		assert.Equal(t, true, m.GetOrDefault("t", nil))
+	})
+
+	t.Run("Tags", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, any]()
+		require.NoError(t, m.UnmarshalCBOR([]byte{
+			0xa2,
+			0x61, 'e', 0xc1, 0x1a, 0x65, 0x53, 0xf1, 0x00,
+			0x61, 'u', 0xd8, 0x20, 0x61, 'x',
+		}))
+		assert.True(t, time.Unix(1700000000, 0).Equal(m.GetOrDefault("e", nil).(time.Time)))
+		assert.Equal(t, "x", m.GetOrDefault("u", nil))
+	})
+
+	t.Run("Time", func(t *testing.T) {
+		tm := time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC)
+		m := orderedmap.NewOrderedMap[string, time.Time]()
+		m.Set("t", tm)
+		data, err := m.MarshalCBOR()
+		require.NoError(t, err)
+
+		m2 := orderedmap.NewOrderedMap[string, time.Time]()
+		require.NoError(t, m2.UnmarshalCBOR(data))
+		assert.True(t, tm.Equal(m2.GetOrDefault("t", time.Time{})))
+	})
+
+	t.Run("NegativeOverflow", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, uint]()
+		err := m.UnmarshalCBOR([]byte{0xa1, 0x61, 'a', 0x20})
+		assert.ErrorContains(t, err, "overflows uint")
+	})
+
+	t.Run("MaxDepth", func(t *testing.T) {
+		nested := func(depth int) []byte {
+			data := append([]byte{0xa1, 0x61, 'a'}, bytes.Repeat([]byte{0x81}, depth)...)
+			return append(data, 0xf6)
+		}
+
+		m := orderedmap.NewOrderedMap[string, any]()
+		require.NoError(t, m.UnmarshalCBOR(nested(100)))
+		assert.ErrorContains(t, m.UnmarshalCBOR(nested(5_000_000)), "exceeded max depth")
+	})
+
+	t.Run("UnexpectedBreak", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, int]()
+		assert.ErrorContains(t, m.UnmarshalCBOR([]byte{0xa1, 0x61, 'a', 0xff}), "unexpected break")
+	})
+
+	t.Run("NilKey", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, any]()
+		require.NoError(t, m.UnmarshalCBOR([]byte{0xa1, 0x61, 'a', 0xa1, 0xf6, 0x01}))
+		inner := m.GetOrDefault("a", nil).(*orderedmap.OrderedMap[any, any])
+		assert.Equal(t, int64(1), inner.GetOrDefault(nil, nil))
+	})
+
+	t.Run("UnhashableKey", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[any, any]()
+		assert.ErrorContains(t, m.UnmarshalCBOR([]byte{0xa1, 0x80, 0x01}), "as a map key")
+
+		nested := orderedmap.NewOrderedMap[string, any]()
+		assert.ErrorContains(t, nested.UnmarshalCBOR([]byte{0xa1, 0x61, 'a', 0xa1, 0x80, 0x01}), "as a map key")
+	})
+
+	t.Run("Truncated", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, int]()
+		assert.ErrorContains(t, m.UnmarshalCBOR([]byte{0xbf, 0x61, 'a', 0x01}), "unexpected end")
+		assert.ErrorContains(t, m.UnmarshalCBOR([]byte{0xbb, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}), "unexpected end")
+	})
+
+	t.Run("TrailingData", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, int]()
+		assert.ErrorContains(t, m.UnmarshalCBOR([]byte{0xa0, 0x00}), "trailing data")
+	})
+}
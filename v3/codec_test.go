@@ -0,0 +1,47 @@
+package orderedmap_test
+
+import (
+	"testing"
+
+	"github.com/elliotchance/orderedmap/v3"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestOrderedMap_CrossCodecRoundTrip(t *testing.T) {
+	type orderedMap = orderedmap.OrderedMap[int, bool]
+	codecs := []struct {
+		name      string
+		marshal   func(m *orderedMap) ([]byte, error)
+		unmarshal func(m *orderedMap, data []byte) error
+	}{
+		{"JSON", (*orderedMap).MarshalJSON, (*orderedMap).UnmarshalJSON},
+		{"YAML", func(m *orderedMap) ([]byte, error) {
+			return yaml.Marshal(m)
+		}, func(m *orderedMap, data []byte) error {
+			return yaml.Unmarshal(data, m)
+		}},
+		{"Gob", (*orderedMap).GobEncode, (*orderedMap).GobDecode},
+		{"Msgpack", (*orderedMap).MarshalMsgpack, (*orderedMap).UnmarshalMsgpack},
+		{"CBOR", (*orderedMap).MarshalCBOR, (*orderedMap).UnmarshalCBOR},
+	}
+
+	m, keys, values := newIteratorsFixture()
+	for _, from := range codecs {
+		for _, to := range codecs {
+			t.Run(from.name+"To"+to.name, func(t *testing.T) {
+				data, err := from.marshal(m)
+				require.NoError(t, err)
+				decoded := orderedmap.NewOrderedMap[int, bool]()
+				require.NoError(t, from.unmarshal(decoded, data))
+				assertOrderedMap(t, decoded, keys, values)
+
+				data, err = to.marshal(decoded)
+				require.NoError(t, err)
+				decoded = orderedmap.NewOrderedMap[int, bool]()
+				require.NoError(t, to.unmarshal(decoded, data))
+				assertOrderedMap(t, decoded, keys, values)
+			})
+		}
+	}
+}
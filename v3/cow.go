@@ -0,0 +1,157 @@
+package orderedmap
+
+import (
+	"iter"
+	"sync"
+	"sync/atomic"
+)
+
+// COWOrderedMap is a copy-on-write ordered map for data that is read much more
+// often than it is written, such as configuration. Readers never block: each
+// write copies the current version, changes the copy and then publishes it
+// atomically, so a reader sees either the old or the new version in full.
+//
+// Writes are serialized with a mutex and cost O(n), so use Batch to make many
+// changes with a single copy.
+//
+// The zero value is an empty map ready to use. A COWOrderedMap must not be
+// copied after first use.
+type COWOrderedMap[K comparable, V any] struct {
+	mu      sync.Mutex
+	current atomic.Pointer[OrderedMap[K, V]]
+}
+
+func NewCOWOrderedMap[K comparable, V any]() *COWOrderedMap[K, V] {
+	return &COWOrderedMap[K, V]{}
+}
+
+// load returns the current version, which must not be changed.
+func (m *COWOrderedMap[K, V]) load() *OrderedMap[K, V] {
+	if current := m.current.Load(); current != nil {
+		return current
+	}
+
+	return NewOrderedMap[K, V]()
+}
+
+// Snapshot returns the current version of the map. It will not change, even if
+// the map is written to afterwards.
+func (m *COWOrderedMap[K, V]) Snapshot() *ReadOnlyOrderedMap[K, V] {
+	return &ReadOnlyOrderedMap[K, V]{m: m.load()}
+}
+
+// Get returns the value for a key in the current version. If the key does not
+// exist, the second return parameter will be false and the value will be nil.
+func (m *COWOrderedMap[K, V]) Get(key K) (V, bool) {
+	return m.load().Get(key)
+}
+
+// Has checks if a key exists in the current version.
+func (m *COWOrderedMap[K, V]) Has(key K) bool {
+	return m.load().Has(key)
+}
+
+// Len returns the number of elements in the current version.
+func (m *COWOrderedMap[K, V]) Len() int {
+	return m.load().Len()
+}
+
+// Set will set (or replace) a value for a key and publish the new version. If
+// the key was new, then true will be returned.
+func (m *COWOrderedMap[K, V]) Set(key K, value V) (isNew bool) {
+	m.Batch(func(b *OrderedMap[K, V]) {
+		isNew = b.Set(key, value)
+	})
+
+	return
+}
+
+// Delete will remove a key from the map and publish the new version. It will
+// return true if the key was removed (the key did exist). Nothing is copied if
+// the key does not exist.
+func (m *COWOrderedMap[K, V]) Delete(key K) (didDelete bool) {
+	if !m.Has(key) {
+		return false
+	}
+
+	m.Batch(func(b *OrderedMap[K, V]) {
+		didDelete = b.Delete(key)
+	})
+
+	return
+}
+
+// Batch copies the current version and calls fn to change the copy, which is
+// then published as the new version. Readers will not see any of the changes
+// until fn returns. Other writers wait for fn to return, so it must not write
+// to m itself, and b must not be used after fn returns.
+func (m *COWOrderedMap[K, V]) Batch(fn func(b *OrderedMap[K, V])) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	b := m.load().Copy()
+	fn(b)
+	m.current.Store(b)
+}
+
+// ReadOnlyOrderedMap is a read-only view of an ordered map, such as a snapshot
+// returned by COWOrderedMap.Snapshot. It is safe for concurrent use.
+type ReadOnlyOrderedMap[K comparable, V any] struct {
+	m *OrderedMap[K, V]
+}
+
+// Get returns the value for a key. If the key does not exist, the second return
+// parameter will be false and the value will be nil.
+func (m *ReadOnlyOrderedMap[K, V]) Get(key K) (V, bool) {
+	return m.m.Get(key)
+}
+
+// GetOrDefault returns the value for a key. If the key does not exist, returns
+// the default value instead.
+func (m *ReadOnlyOrderedMap[K, V]) GetOrDefault(key K, defaultValue V) V {
+	return m.m.GetOrDefault(key, defaultValue)
+}
+
+// Has checks if a key exists in the map.
+func (m *ReadOnlyOrderedMap[K, V]) Has(key K) bool {
+	return m.m.Has(key)
+}
+
+// Len returns the number of elements in the map.
+func (m *ReadOnlyOrderedMap[K, V]) Len() int {
+	return m.m.Len()
+}
+
+// AllFromFront returns an iterator that yields all elements in the map starting
+// at the front (oldest Set element).
+func (m *ReadOnlyOrderedMap[K, V]) AllFromFront() iter.Seq2[K, V] {
+	return m.m.AllFromFront()
+}
+
+// AllFromBack returns an iterator that yields all elements in the map starting
+// at the back (most recent Set element).
+func (m *ReadOnlyOrderedMap[K, V]) AllFromBack() iter.Seq2[K, V] {
+	return m.m.AllFromBack()
+}
+
+// Keys returns an iterator that yields all the keys in the map starting at the
+// front (oldest Set element).
+func (m *ReadOnlyOrderedMap[K, V]) Keys() iter.Seq[K] {
+	return m.m.Keys()
+}
+
+// Values returns an iterator that yields all the values in the map starting at
+// the front (oldest Set element).
+func (m *ReadOnlyOrderedMap[K, V]) Values() iter.Seq[V] {
+	return m.m.Values()
+}
+
+// Copy returns a new OrderedMap with the same elements that can be changed.
+func (m *ReadOnlyOrderedMap[K, V]) Copy() *OrderedMap[K, V] {
+	return m.m.Copy()
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (m *ReadOnlyOrderedMap[K, V]) MarshalJSON() ([]byte, error) {
+	return m.m.MarshalJSON()
+}
@@ -0,0 +1,122 @@
+package orderedmap_test
+
+import (
+	"encoding/json"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/elliotchance/orderedmap/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCOWOrderedMap(t *testing.T) {
+	t.Run("ZeroValueIsUsable", func(t *testing.T) {
+		var m orderedmap.COWOrderedMap[string, int]
+		assert.Equal(t, 0, m.Len())
+		assert.Equal(t, 0, m.Snapshot().Len())
+		assert.False(t, m.Delete("a"))
+		assert.True(t, m.Set("a", 1))
+		assert.False(t, m.Set("a", 2))
+
+		value, ok := m.Get("a")
+		assert.True(t, ok)
+		assert.Equal(t, 2, value)
+	})
+
+	t.Run("SnapshotDoesNotChange", func(t *testing.T) {
+		m := orderedmap.NewCOWOrderedMap[string, int]()
+		m.Set("a", 1)
+		m.Set("b", 2)
+
+		s := m.Snapshot()
+		m.Set("a", 3)
+		m.Set("c", 4)
+		assert.True(t, m.Delete("b"))
+
+		assert.Equal(t, []string{"a", "b"}, slices.Collect(s.Keys()))
+		assert.Equal(t, []int{1, 2}, slices.Collect(s.Values()))
+		assert.Equal(t, 1, s.GetOrDefault("a", 0))
+		assert.False(t, s.Has("c"))
+
+		s2 := m.Snapshot()
+		assert.Equal(t, []string{"a", "c"}, slices.Collect(s2.Keys()))
+		assert.Equal(t, []int{3, 4}, slices.Collect(s2.Values()))
+
+		var back []string
+		for key := range s2.AllFromBack() {
+			back = append(back, key)
+		}
+		assert.Equal(t, []string{"c", "a"}, back)
+	})
+
+	t.Run("Batch", func(t *testing.T) {
+		m := orderedmap.NewCOWOrderedMap[string, int]()
+		m.Set("a", 1)
+		before := m.Snapshot()
+
+		m.Batch(func(b *orderedmap.OrderedMap[string, int]) {
+			b.Set("b", 2)
+			b.Set("c", 3)
+			b.Delete("a")
+
+			// Not visible until the batch is finished.
+			assert.Equal(t, []string{"a"}, slices.Collect(m.Snapshot().Keys()))
+		})
+
+		assert.Equal(t, []string{"a"}, slices.Collect(before.Keys()))
+		assert.Equal(t, []string{"b", "c"}, slices.Collect(m.Snapshot().Keys()))
+	})
+
+	t.Run("CopyIsWritable", func(t *testing.T) {
+		m := orderedmap.NewCOWOrderedMap[string, int]()
+		m.Set("a", 1)
+
+		c := m.Snapshot().Copy()
+		c.Set("b", 2)
+		assert.Equal(t, 1, m.Len())
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		m := orderedmap.NewCOWOrderedMap[string, int]()
+		m.Set("b", 1)
+		m.Set("a", 2)
+
+		data, err := json.Marshal(m.Snapshot())
+		require.NoError(t, err)
+		assert.Equal(t, `{"b":1,"a":2}`, string(data))
+	})
+
+	t.Run("ConcurrentReaders", func(t *testing.T) {
+		m := orderedmap.NewCOWOrderedMap[int, int]()
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				m.Batch(func(b *orderedmap.OrderedMap[int, int]) {
+					b.Set(2*i, i)
+					b.Set(2*i+1, i)
+				})
+			}
+		}()
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					// Batches are published together, so the length is always
+					// even.
+					s := m.Snapshot()
+					assert.Equal(t, 0, s.Len()%2)
+					assert.Equal(t, s.Len(), len(slices.Collect(s.Keys())))
+				}
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, 400, m.Len())
+	})
+}
@@ -0,0 +1,48 @@
+package orderedmap
+
+import (
+	"bytes"
+	"encoding/gob"
+)
+
+// gobElement is the wire format for one element. Elements are sent as a slice
+// because gob does not keep the order of maps.
+type gobElement[K comparable, V any] struct {
+	Key   K
+	Value V
+}
+
+// GobEncode implements gob.GobEncoder. The elements are encoded in the same
+// order as the map (front to back).
+//
+// As with any other gob value, concrete types stored in an interface key or
+// value (including nested ordered maps) must be registered with gob.Register.
+func (m *OrderedMap[K, V]) GobEncode() ([]byte, error) {
+	elements := make([]gobElement[K, V], 0, m.Len())
+	for key, value := range m.AllFromFront() {
+		elements = append(elements, gobElement[K, V]{key, value})
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(elements); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// GobDecode implements gob.GobDecoder. The elements are added in the order
+// they were encoded and existing elements are kept.
+func (m *OrderedMap[K, V]) GobDecode(data []byte) error {
+	var elements []gobElement[K, V]
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&elements); err != nil {
+		return err
+	}
+
+	m.lazyInit()
+	for _, el := range elements {
+		m.Set(el.Key, el.Value)
+	}
+
+	return nil
+}
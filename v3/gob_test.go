@@ -0,0 +1,54 @@
+package orderedmap_test
+
+import (
+	"bytes"
+	"encoding/gob"
+	"slices"
+	"testing"
+
+	"github.com/elliotchance/orderedmap/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderedMap_Gob(t *testing.T) {
+	t.Run("EmptyMap", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, gob.NewEncoder(&buf).Encode(orderedmap.NewOrderedMap[string, int]()))
+
+		var m orderedmap.OrderedMap[string, int]
+		require.NoError(t, gob.NewDecoder(&buf).Decode(&m))
+		assert.Equal(t, 0, m.Len())
+	})
+
+	t.Run("StructField", func(t *testing.T) {
+		type document struct {
+			Title  string
+			Fields *orderedmap.OrderedMap[string, any]
+		}
+		gob.Register(orderedmap.NewOrderedMap[string, any]())
+
+		inner := orderedmap.NewOrderedMap[string, any]()
+		inner.Set("b", 2)
+		inner.Set("a", nil)
+		fields := orderedmap.NewOrderedMap[string, any]()
+		fields.Set("z", "last")
+		fields.Set("inner", inner)
+
+		var buf bytes.Buffer
+		require.NoError(t, gob.NewEncoder(&buf).Encode(document{"doc", fields}))
+
+		var doc document
+		require.NoError(t, gob.NewDecoder(&buf).Decode(&doc))
+		assert.Equal(t, "doc", doc.Title)
+		assert.Equal(t, []string{"z", "inner"}, slices.Collect(doc.Fields.Keys()))
+		got := doc.Fields.GetOrDefault("inner", nil).(*orderedmap.OrderedMap[string, any])
+		assert.Equal(t, []string{"b", "a"}, slices.Collect(got.Keys()))
+		assert.Equal(t, 2, got.GetOrDefault("b", nil))
+	})
+
+	t.Run("InvalidData", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, int]()
+		assert.Error(t, m.GobDecode([]byte{0x01, 0x02}))
+	})
+}
@@ -0,0 +1,43 @@
+package orderedmap_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/elliotchance/orderedmap/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+// newIteratorsFixture returns the map used by TestIterators, along with its
+// keys and values in order.
+func newIteratorsFixture() (m *orderedmap.OrderedMap[int, bool], keys []int, values []bool) {
+	keys = []int{5, 3, 1, 4}
+	values = []bool{true, false, false, true}
+	m = orderedmap.NewOrderedMap[int, bool]()
+	for i, key := range keys {
+		m.Set(key, values[i])
+	}
+
+	return
+}
+
+// assertOrderedMap checks that m has exactly keys and values, in that order
+// from the front and in reverse from the back.
+func assertOrderedMap[K comparable, V any](t *testing.T, m *orderedmap.OrderedMap[K, V], keys []K, values []V) {
+	t.Helper()
+	assert.Equal(t, keys, slices.Collect(m.Keys()))
+	assert.Equal(t, values, slices.Collect(m.Values()))
+	assert.Equal(t, len(keys), m.Len())
+	for i, key := range keys {
+		value, ok := m.Get(key)
+		assert.True(t, ok)
+		assert.Equal(t, values[i], value)
+	}
+
+	var back []K
+	for key := range m.AllFromBack() {
+		back = append(back, key)
+	}
+	slices.Reverse(back)
+	assert.Equal(t, keys, back)
+}
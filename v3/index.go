@@ -0,0 +1,269 @@
+package orderedmap
+
+import "iter"
+
+// orderIndex is an order statistic tree over the elements of a list, so that
+// the element at a position, and the position of an element, can be found in
+// O(log n). It is a treap: a binary tree in list order that is kept balanced
+// by giving each node a random priority that is never lower than the priority
+// of its children.
+type orderIndex[K comparable, V any] struct {
+	root  *indexNode[K, V]
+	nodes map[*Element[K, V]]*indexNode[K, V]
+
+	priorities keyHasher
+	seq        uint64
+}
+
+type indexNode[K comparable, V any] struct {
+	element             *Element[K, V]
+	left, right, parent *indexNode[K, V]
+	priority            uint64
+
+	// size is the number of nodes in this subtree, including this one.
+	size int
+}
+
+func (n *indexNode[K, V]) getSize() int {
+	if n == nil {
+		return 0
+	}
+
+	return n.size
+}
+
+func (n *indexNode[K, V]) updateSize() {
+	n.size = 1 + n.left.getSize() + n.right.getSize()
+}
+
+func newOrderIndex[K comparable, V any](l *list[K, V]) *orderIndex[K, V] {
+	t := &orderIndex[K, V]{
+		nodes:      map[*Element[K, V]]*indexNode[K, V]{},
+		priorities: newKeyHasher(),
+	}
+	for e := l.Front(); e != nil; e = e.Next() {
+		t.insertAfter(e, e.Prev())
+	}
+
+	return t
+}
+
+// insertAfter adds e, which has just been linked into the list after mark (or
+// at the front if mark is nil).
+func (t *orderIndex[K, V]) insertAfter(e, mark *Element[K, V]) {
+	t.seq++
+	n := &indexNode[K, V]{element: e, priority: t.priorities.uint64(t.seq), size: 1}
+	t.nodes[e] = n
+
+	// The new node goes at the leftmost position of the subtree that follows
+	// mark, which is always a free slot.
+	switch {
+	case t.root == nil:
+		t.root = n
+		return
+
+	case mark == nil:
+		n.parent = t.root
+		for n.parent.left != nil {
+			n.parent = n.parent.left
+		}
+		n.parent.left = n
+
+	case t.nodes[mark].right == nil:
+		n.parent = t.nodes[mark]
+		n.parent.right = n
+
+	default:
+		n.parent = t.nodes[mark].right
+		for n.parent.left != nil {
+			n.parent = n.parent.left
+		}
+		n.parent.left = n
+	}
+
+	for a := n.parent; a != nil; a = a.parent {
+		a.size++
+	}
+	for n.parent != nil && n.parent.priority < n.priority {
+		t.rotateUp(n)
+	}
+}
+
+// remove removes e, which is being unlinked from the list.
+func (t *orderIndex[K, V]) remove(e *Element[K, V]) {
+	n := t.nodes[e]
+	delete(t.nodes, e)
+
+	// Rotate the node down until it is a leaf, so that it can be detached.
+	for n.left != nil || n.right != nil {
+		if n.right == nil || (n.left != nil && n.left.priority > n.right.priority) {
+			t.rotateUp(n.left)
+		} else {
+			t.rotateUp(n.right)
+		}
+	}
+
+	p := n.parent
+	switch {
+	case p == nil:
+		t.root = nil
+	case p.left == n:
+		p.left = nil
+	default:
+		p.right = nil
+	}
+	for ; p != nil; p = p.parent {
+		p.size--
+	}
+}
+
+// rotateUp swaps n with its parent, keeping the order of the nodes.
+func (t *orderIndex[K, V]) rotateUp(n *indexNode[K, V]) {
+	p, g := n.parent, n.parent.parent
+	if n == p.left {
+		p.left = n.right
+		if n.right != nil {
+			n.right.parent = p
+		}
+		n.right = p
+	} else {
+		p.right = n.left
+		if n.left != nil {
+			n.left.parent = p
+		}
+		n.left = p
+	}
+	p.parent, n.parent = n, g
+
+	switch {
+	case g == nil:
+		t.root = n
+	case g.left == p:
+		g.left = n
+	default:
+		g.right = n
+	}
+
+	p.updateSize()
+	n.updateSize()
+}
+
+// at returns the element at index i, which must be in range.
+func (t *orderIndex[K, V]) at(i int) *Element[K, V] {
+	n := t.root
+	for {
+		left := n.left.getSize()
+		switch {
+		case i < left:
+			n = n.left
+		case i == left:
+			return n.element
+		default:
+			i -= left + 1
+			n = n.right
+		}
+	}
+}
+
+// indexOf returns the index of e.
+func (t *orderIndex[K, V]) indexOf(e *Element[K, V]) int {
+	n := t.nodes[e]
+	i := n.left.getSize()
+	for ; n.parent != nil; n = n.parent {
+		if n == n.parent.right {
+			i += n.parent.left.getSize() + 1
+		}
+	}
+
+	return i
+}
+
+// EnableIndex keeps an index of the positions of the elements so that At,
+// IndexOf, Slice and DeleteAt take O(log n) instead of O(n). Get and Has are
+// not affected, but every change to the order (including Set of a new key and
+// Delete) becomes O(log n). Building the index takes O(n log n).
+//
+// Calling EnableIndex again has no effect. The index is not copied by Copy.
+func (m *OrderedMap[K, V]) EnableIndex() {
+	if m.ll.index == nil {
+		m.ll.index = newOrderIndex(&m.ll)
+	}
+}
+
+// DisableIndex stops keeping the index created by EnableIndex.
+func (m *OrderedMap[K, V]) DisableIndex() {
+	m.ll.index = nil
+}
+
+// At returns the element at index i, where the front of the map is 0. If i is
+// out of range the element will be nil.
+//
+// At is O(log n) if EnableIndex has been called, otherwise it walks from the
+// nearest end of the map in O(n).
+func (m *OrderedMap[K, V]) At(i int) *Element[K, V] {
+	n := m.Len()
+	switch {
+	case i < 0 || i >= n:
+		return nil
+	case m.ll.index != nil:
+		return m.ll.index.at(i)
+	case i < n/2:
+		el := m.Front()
+		for ; i > 0; i-- {
+			el = el.Next()
+		}
+		return el
+	}
+
+	el := m.Back()
+	for i = n - 1 - i; i > 0; i-- {
+		el = el.Prev()
+	}
+	return el
+}
+
+// IndexOf returns the index of key, where the front of the map is 0, or -1 if
+// the key does not exist.
+//
+// IndexOf is O(log n) if EnableIndex has been called, otherwise it walks from
+// the front of the map in O(n).
+func (m *OrderedMap[K, V]) IndexOf(key K) int {
+	element, ok := m.kv[key]
+	switch {
+	case !ok:
+		return -1
+	case m.ll.index != nil:
+		return m.ll.index.indexOf(element)
+	}
+
+	i := 0
+	for el := m.Front(); el != element; el = el.Next() {
+		i++
+	}
+	return i
+}
+
+// Slice returns an iterator that yields the elements from index i up to (but
+// not including) index j. The indexes are limited to the range of the map, so
+// Slice(0, m.Len()) is the same as AllFromFront.
+func (m *OrderedMap[K, V]) Slice(i, j int) iter.Seq2[K, V] {
+	return func(yield func(key K, value V) bool) {
+		i, j := max(i, 0), min(j, m.Len())
+		for el := m.At(i); i < j; el, i = el.Next(), i+1 {
+			if !yield(el.Key, el.Value) {
+				return
+			}
+		}
+	}
+}
+
+// DeleteAt will remove the element at index i, where the front of the map is
+// 0. It will return true if the element was removed (i was in range).
+func (m *OrderedMap[K, V]) DeleteAt(i int) bool {
+	element := m.At(i)
+	if element == nil {
+		return false
+	}
+
+	return m.Delete(element.Key)
+}
@@ -0,0 +1,165 @@
+package orderedmap_test
+
+import (
+	"math/rand"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/elliotchance/orderedmap/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderedMap_At(t *testing.T) {
+	for _, indexed := range []bool{false, true} {
+		t.Run("Indexed"+strconv.FormatBool(indexed), func(t *testing.T) {
+			m := orderedmap.NewOrderedMap[string, int]()
+			if indexed {
+				m.EnableIndex()
+			}
+			assert.Nil(t, m.At(0))
+
+			m.Set("a", 1)
+			m.Set("b", 2)
+			m.Set("c", 3)
+			m.Set("d", 4)
+			m.Set("e", 5)
+
+			for i, key := range []string{"a", "b", "c", "d", "e"} {
+				require.NotNil(t, m.At(i))
+				assert.Same(t, m.GetElement(key), m.At(i))
+			}
+			assert.Nil(t, m.At(-1))
+			assert.Nil(t, m.At(5))
+		})
+	}
+}
+
+func TestOrderedMap_IndexOf(t *testing.T) {
+	for _, indexed := range []bool{false, true} {
+		t.Run("Indexed"+strconv.FormatBool(indexed), func(t *testing.T) {
+			m := newABCD()
+			if indexed {
+				m.EnableIndex()
+			}
+
+			assert.Equal(t, 0, m.IndexOf("a"))
+			assert.Equal(t, 3, m.IndexOf("d"))
+			assert.Equal(t, -1, m.IndexOf("x"))
+
+			m.MoveToFront("d")
+			m.Delete("b")
+			assert.Equal(t, 0, m.IndexOf("d"))
+			assert.Equal(t, 1, m.IndexOf("a"))
+			assert.Equal(t, 2, m.IndexOf("c"))
+			assert.Equal(t, -1, m.IndexOf("b"))
+		})
+	}
+}
+
+func TestOrderedMap_Slice(t *testing.T) {
+	m := newABCD()
+	m.EnableIndex()
+
+	collect := func(i, j int) []string {
+		var keys []string
+		for key := range m.Slice(i, j) {
+			keys = append(keys, key)
+		}
+		return keys
+	}
+
+	assert.Equal(t, []string{"b", "c"}, collect(1, 3))
+	assert.Equal(t, []string{"a", "b", "c", "d"}, collect(0, 4))
+	assert.Equal(t, []string{"a", "b"}, collect(-5, 2))
+	assert.Equal(t, []string{"c", "d"}, collect(2, 10))
+	assert.Nil(t, collect(3, 3))
+	assert.Nil(t, collect(3, 1))
+	assert.Nil(t, collect(4, 5))
+
+	for key := range m.Slice(0, 4) {
+		assert.Equal(t, "a", key)
+		break
+	}
+}
+
+func TestOrderedMap_DeleteAt(t *testing.T) {
+	m := newABCD()
+	m.EnableIndex()
+
+	assert.True(t, m.DeleteAt(1))
+	assertOrderedMap(t, m, []string{"a", "c", "d"}, []int{1, 3, 4})
+	assert.True(t, m.DeleteAt(2))
+	assertOrderedMap(t, m, []string{"a", "c"}, []int{1, 3})
+	assert.False(t, m.DeleteAt(2))
+	assert.False(t, m.DeleteAt(-1))
+	assert.True(t, m.DeleteAt(0))
+	assert.True(t, m.DeleteAt(0))
+	assertOrderedMap[string, int](t, m, nil, nil)
+}
+
+func TestOrderedMap_EnableIndex(t *testing.T) {
+	t.Run("ExistingElements", func(t *testing.T) {
+		m := newABCD()
+		m.EnableIndex()
+		m.EnableIndex()
+		assert.Equal(t, 2, m.IndexOf("c"))
+		assert.Equal(t, "d", m.At(3).Key)
+
+		m.DisableIndex()
+		m.Set("e", 5)
+		assert.Equal(t, 4, m.IndexOf("e"))
+	})
+
+	t.Run("RandomOperations", func(t *testing.T) {
+		r := rand.New(rand.NewSource(1))
+		m := orderedmap.NewOrderedMap[string, int]()
+		m.EnableIndex()
+		m.EnableJournal(0)
+
+		for i := 0; i < 3000; i++ {
+			key, mark := strconv.Itoa(r.Intn(100)), strconv.Itoa(r.Intn(100))
+			switch r.Intn(9) {
+			case 0, 1:
+				m.Set(key, i)
+			case 2:
+				m.Delete(key)
+			case 3:
+				m.DeleteAt(r.Intn(m.Len() + 1))
+			case 4:
+				m.MoveToFront(key)
+			case 5:
+				m.MoveAfter(key, mark)
+			case 6:
+				m.InsertBefore(mark, key, i)
+			case 7:
+				m.SetFront(key, i)
+			case 8:
+				m.Undo()
+			}
+
+			if i%50 == 0 || i > 2950 {
+				keys := slices.Collect(m.Keys())
+				for j, key := range keys {
+					require.Equal(t, key, m.At(j).Key)
+					require.Equal(t, j, m.IndexOf(key))
+				}
+				require.Nil(t, m.At(len(keys)))
+			}
+		}
+	})
+}
+
+func BenchmarkOrderedMap_At(b *testing.B) {
+	m := orderedmap.NewOrderedMap[int, int]()
+	m.EnableIndex()
+	for i := 0; i < 100_000; i++ {
+		m.Set(i, i)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.At(i % 100_000)
+	}
+}
@@ -0,0 +1,69 @@
+// Package jsonvalue compares the JSON values used by the jsonpatch and
+// jsonpath packages, which are made of *orderedmap.OrderedMap[string, any]
+// objects, []any arrays and Go scalars.
+package jsonvalue
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strconv"
+
+	"github.com/elliotchance/orderedmap/v3"
+)
+
+// Equal reports whether two JSON values are equal: objects are equal if they
+// have the same members regardless of their order, arrays are equal if they
+// have equal elements in the same order, and numbers are compared by their
+// value whatever their Go type.
+func Equal(a, b any) bool {
+	switch a := a.(type) {
+	case *orderedmap.OrderedMap[string, any]:
+		b, ok := b.(*orderedmap.OrderedMap[string, any])
+		if !ok || a.Len() != b.Len() {
+			return false
+		}
+		for key, value := range a.AllFromFront() {
+			other, ok := b.Get(key)
+			if !ok || !Equal(value, other) {
+				return false
+			}
+		}
+		return true
+
+	case []any:
+		b, ok := b.([]any)
+		return ok && slices.EqualFunc(a, b, Equal)
+	}
+
+	if x, ok := Number(a); ok {
+		y, ok := Number(b)
+		return ok && x == y
+	}
+
+	return reflect.DeepEqual(a, b)
+}
+
+// Number returns v as a float64 if it is a number, which can be any Go integer
+// or float type, or a json.Number.
+func Number(v any) (float64, bool) {
+	if n, ok := v.(json.Number); ok {
+		f, err := strconv.ParseFloat(string(n), 64)
+		return f, err == nil
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64, reflect.Uintptr:
+		return float64(rv.Uint()), true
+
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,40 @@
+package jsonvalue_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/elliotchance/orderedmap/v3"
+	"github.com/elliotchance/orderedmap/v3/internal/jsonvalue"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEqual(t *testing.T) {
+	a := orderedmap.NewOrderedMap[string, any]()
+	a.Set("x", 1)
+	a.Set("y", []any{"s", nil})
+	b := orderedmap.NewOrderedMap[string, any]()
+	b.Set("y", []any{"s", nil})
+	b.Set("x", 1.0)
+
+	assert.True(t, jsonvalue.Equal(a, b))
+	assert.True(t, jsonvalue.Equal(json.Number("2"), uint8(2)))
+	assert.False(t, jsonvalue.Equal([]any{1, 2}, []any{2, 1}))
+	assert.False(t, jsonvalue.Equal("1", 1))
+
+	b.Set("z", true)
+	assert.False(t, jsonvalue.Equal(a, b))
+}
+
+func TestNumber(t *testing.T) {
+	for _, v := range []any{3, int8(3), uint64(3), float32(3), 3.0, json.Number("3")} {
+		f, ok := jsonvalue.Number(v)
+		assert.True(t, ok, v)
+		assert.Equal(t, 3.0, f, v)
+	}
+
+	_, ok := jsonvalue.Number("3")
+	assert.False(t, ok)
+	_, ok = jsonvalue.Number(json.Number("x"))
+	assert.False(t, ok)
+}
@@ -0,0 +1,307 @@
+package orderedmap
+
+// journal records the changes to a map so that they can be undone and redone.
+// It is only allocated once EnableJournal is called.
+type journal[K comparable, V any] struct {
+	maxHistory int
+
+	// undo and redo are stacks, the most recent entry is at the end.
+	undo, redo []journalEntry[K, V]
+
+	// ops is the number of operations in undo, not counting checkpoints.
+	ops int
+}
+
+type journalOp int
+
+const (
+	journalCheckpoint journalOp = iota
+
+	// journalInsert adds key with value after the element for after (or at
+	// the front if hasAfter is false).
+	journalInsert
+
+	// journalDelete removes key. It records the same fields as journalInsert
+	// so that it can be inverted.
+	journalDelete
+
+	// journalUpdate changes the value for key from old to value.
+	journalUpdate
+
+	// journalRename renames each key in from to the key at the same index in
+	// to, all at once so that keys can be swapped.
+	journalRename
+
+	// journalMove moves key from after oldAfter to after after (or the front
+	// if oldHasAfter or hasAfter is false), and changes its value from old to
+	// value.
+	journalMove
+
+	// journalReorder changes the order of all of the keys from the order in
+	// from to the order in to.
+	journalReorder
+)
+
+// journalEntry is a single invertible operation, or a checkpoint.
+type journalEntry[K comparable, V any] struct {
+	op          journalOp
+	key         K
+	value, old  V
+	after       K
+	hasAfter    bool
+	oldAfter    K
+	oldHasAfter bool
+	from, to    []K
+}
+
+// invert returns the operation that undoes e.
+func (e journalEntry[K, V]) invert() journalEntry[K, V] {
+	switch e.op {
+	case journalInsert:
+		e.op = journalDelete
+	case journalDelete:
+		e.op = journalInsert
+	case journalUpdate:
+		e.value, e.old = e.old, e.value
+	case journalRename, journalReorder:
+		e.from, e.to = e.to, e.from
+	case journalMove:
+		e.value, e.old = e.old, e.value
+		e.after, e.oldAfter = e.oldAfter, e.after
+		e.hasAfter, e.oldHasAfter = e.oldHasAfter, e.hasAfter
+	}
+
+	return e
+}
+
+// EnableJournal starts recording every change such as Set, Delete, ReplaceKey,
+// moves and sorts (along with the previous value or position of the element)
+// so that they can be undone with Undo and redone with Redo. At most
+// maxHistory operations are kept, the oldest are forgotten first. If
+// maxHistory is zero there is no limit.
+//
+// Calling EnableJournal again only changes the limit.
+func (m *OrderedMap[K, V]) EnableJournal(maxHistory int) {
+	if m.journal == nil {
+		m.journal = &journal[K, V]{}
+	}
+	m.journal.maxHistory = maxHistory
+	m.journal.trim()
+}
+
+// DisableJournal stops recording changes and forgets the history.
+func (m *OrderedMap[K, V]) DisableJournal() {
+	m.journal = nil
+}
+
+// Checkpoint marks the current state in the history. UndoToCheckpoint and
+// RedoToCheckpoint undo and redo all of the operations between checkpoints,
+// such as all of the changes made by a single action in an editor. It does
+// nothing if the journal is not enabled.
+func (m *OrderedMap[K, V]) Checkpoint() {
+	if m.journal != nil {
+		m.journal.undo = append(m.journal.undo, journalEntry[K, V]{op: journalCheckpoint})
+	}
+}
+
+// CanUndo returns true if there is an operation to undo.
+func (m *OrderedMap[K, V]) CanUndo() bool {
+	return m.journal != nil && m.journal.ops > 0
+}
+
+// CanRedo returns true if there is an operation to redo.
+func (m *OrderedMap[K, V]) CanRedo() bool {
+	if m.journal == nil {
+		return false
+	}
+	for _, e := range m.journal.redo {
+		if e.op != journalCheckpoint {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Undo reverts the most recent operation, restoring the previous value,
+// position and key of the element. It returns false if there is nothing to
+// undo.
+func (m *OrderedMap[K, V]) Undo() bool {
+	if !m.CanUndo() {
+		return false
+	}
+
+	j := m.journal
+	for {
+		e := j.pop(&j.undo)
+		j.redo = append(j.redo, e)
+		if e.op != journalCheckpoint {
+			j.ops--
+			m.applyJournal(e.invert())
+			return true
+		}
+	}
+}
+
+// Redo applies the most recently undone operation again. It returns false if
+// there is nothing to redo. Making any change other than Undo or Redo clears
+// what can be redone.
+func (m *OrderedMap[K, V]) Redo() bool {
+	if !m.CanRedo() {
+		return false
+	}
+
+	j := m.journal
+	for {
+		e := j.pop(&j.redo)
+		j.undo = append(j.undo, e)
+		if e.op != journalCheckpoint {
+			j.ops++
+			m.applyJournal(e)
+			return true
+		}
+	}
+}
+
+// UndoToCheckpoint reverts all the operations since the most recent
+// checkpoint. If there have been no operations since that checkpoint, the
+// operations before it are undone back to the previous checkpoint instead. It
+// returns false if there is nothing to undo.
+func (m *OrderedMap[K, V]) UndoToCheckpoint() bool {
+	if !m.Undo() {
+		return false
+	}
+
+	j := m.journal
+	for len(j.undo) > 0 && j.undo[len(j.undo)-1].op != journalCheckpoint {
+		m.Undo()
+	}
+
+	return true
+}
+
+// RedoToCheckpoint applies the undone operations again up to the next
+// checkpoint. It returns false if there is nothing to redo.
+func (m *OrderedMap[K, V]) RedoToCheckpoint() bool {
+	if !m.Redo() {
+		return false
+	}
+
+	j := m.journal
+	for len(j.redo) > 0 && j.redo[len(j.redo)-1].op != journalCheckpoint {
+		m.Redo()
+	}
+
+	return true
+}
+
+func (j *journal[K, V]) pop(stack *[]journalEntry[K, V]) journalEntry[K, V] {
+	e := (*stack)[len(*stack)-1]
+	*stack = (*stack)[:len(*stack)-1]
+
+	return e
+}
+
+// record adds an operation that has just been made to the history.
+func (j *journal[K, V]) record(e journalEntry[K, V]) {
+	j.undo = append(j.undo, e)
+	j.redo = nil
+	j.ops++
+	j.trim()
+}
+
+// trim forgets the oldest operations (and any checkpoints before them) until
+// there are no more than maxHistory.
+func (j *journal[K, V]) trim() {
+	if j.maxHistory <= 0 || j.ops <= j.maxHistory {
+		return
+	}
+
+	i := 0
+	for ; j.ops > j.maxHistory; i++ {
+		if j.undo[i].op != journalCheckpoint {
+			j.ops--
+		}
+	}
+	for i < len(j.undo) && j.undo[i].op == journalCheckpoint {
+		i++
+	}
+	j.undo = append(j.undo[:0:0], j.undo[i:]...)
+}
+
+// recordJournal records an operation if the journal is enabled.
+func (m *OrderedMap[K, V]) recordJournal(e journalEntry[K, V]) {
+	if m.journal != nil {
+		m.journal.record(e)
+	}
+}
+
+// journalPosition returns the fields of a journal entry that record the
+// position of element.
+func journalPosition[K comparable, V any](element *Element[K, V]) (after K, hasAfter bool) {
+	if prev := element.Prev(); prev != nil {
+		return prev.Key, true
+	}
+
+	return
+}
+
+// applyJournal makes the change described by e without recording it.
+func (m *OrderedMap[K, V]) applyJournal(e journalEntry[K, V]) {
+	j := m.journal
+	m.journal = nil
+	defer func() {
+		m.journal = j
+	}()
+
+	switch e.op {
+	case journalInsert:
+		var mark *Element[K, V]
+		if e.hasAfter {
+			mark = m.kv[e.after]
+		}
+		element := &Element[K, V]{Key: e.key, Value: e.value}
+		m.ll.InsertAfter(element, mark)
+		m.kv[e.key] = element
+
+	case journalDelete:
+		m.Delete(e.key)
+
+	case journalUpdate:
+		m.kv[e.key].Value = e.value
+
+	case journalRename:
+		m.renameKeys(e.from, e.to)
+
+	case journalMove:
+		var mark *Element[K, V]
+		if e.hasAfter {
+			mark = m.kv[e.after]
+		}
+		m.relink(m.kv[e.key], mark, e.value)
+
+	case journalReorder:
+		elements := make([]*Element[K, V], len(e.to))
+		for i, key := range e.to {
+			elements[i] = m.kv[key]
+		}
+		m.ll.Relink(elements)
+	}
+}
+
+// renameKeys renames each key in from to the key at the same index in to,
+// keeping their positions. All of the keys in from must exist, and the keys in
+// to must not exist unless they are also in from, so keys can be swapped.
+func (m *OrderedMap[K, V]) renameKeys(from, to []K) {
+	elements := make([]*Element[K, V], len(from))
+	for i, key := range from {
+		elements[i] = m.kv[key]
+		delete(m.kv, key)
+	}
+	for i, element := range elements {
+		element.Key = to[i]
+		m.kv[to[i]] = element
+	}
+
+	m.recordJournal(journalEntry[K, V]{op: journalRename, from: from, to: to})
+}
@@ -0,0 +1,219 @@
+package orderedmap_test
+
+import (
+	"math/rand"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/elliotchance/orderedmap/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderedMap_Undo(t *testing.T) {
+	t.Run("NotEnabled", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, int]()
+		m.Set("a", 1)
+		m.Checkpoint()
+		assert.False(t, m.CanUndo())
+		assert.False(t, m.Undo())
+		assert.False(t, m.Redo())
+	})
+
+	t.Run("Set", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, int]()
+		m.EnableJournal(0)
+		m.Set("a", 1)
+		m.Set("b", 2)
+		m.Set("a", 3)
+
+		require.True(t, m.Undo())
+		assertOrderedMap(t, m, []string{"a", "b"}, []int{1, 2})
+		require.True(t, m.Undo())
+		assertOrderedMap(t, m, []string{"a"}, []int{1})
+		require.True(t, m.Undo())
+		assertOrderedMap[string, int](t, m, nil, nil)
+		assert.False(t, m.Undo())
+
+		require.True(t, m.Redo())
+		require.True(t, m.Redo())
+		require.True(t, m.Redo())
+		assert.False(t, m.Redo())
+		assertOrderedMap(t, m, []string{"a", "b"}, []int{3, 2})
+	})
+
+	t.Run("DeleteRestoresPosition", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, int]()
+		m.EnableJournal(0)
+		m.Set("a", 1)
+		m.Set("b", 2)
+		m.Set("c", 3)
+		m.Delete("b")
+		m.Delete("a")
+		assert.False(t, m.Delete("x"))
+
+		m.Undo()
+		assertOrderedMap(t, m, []string{"a", "c"}, []int{1, 3})
+		m.Undo()
+		assertOrderedMap(t, m, []string{"a", "b", "c"}, []int{1, 2, 3})
+		m.Redo()
+		assertOrderedMap(t, m, []string{"a", "c"}, []int{1, 3})
+	})
+
+	t.Run("ReplaceKey", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, int]()
+		m.EnableJournal(0)
+		m.Set("a", 1)
+		m.Set("b", 2)
+		m.ReplaceKey("a", "z")
+
+		m.Undo()
+		assertOrderedMap(t, m, []string{"a", "b"}, []int{1, 2})
+		m.Redo()
+		assertOrderedMap(t, m, []string{"z", "b"}, []int{1, 2})
+	})
+
+	t.Run("NewChangeClearsRedo", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, int]()
+		m.EnableJournal(0)
+		m.Set("a", 1)
+		m.Set("b", 2)
+		m.Undo()
+		assert.True(t, m.CanRedo())
+
+		m.Set("c", 3)
+		assert.False(t, m.CanRedo())
+		assertOrderedMap(t, m, []string{"a", "c"}, []int{1, 3})
+	})
+
+	t.Run("Checkpoints", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, int]()
+		m.EnableJournal(0)
+		m.Set("a", 1)
+		m.Set("b", 2)
+		m.Checkpoint()
+		m.Set("c", 3)
+		m.Delete("a")
+		m.Checkpoint()
+		m.Set("b", 4)
+
+		require.True(t, m.UndoToCheckpoint())
+		assertOrderedMap(t, m, []string{"b", "c"}, []int{2, 3})
+		require.True(t, m.UndoToCheckpoint())
+		assertOrderedMap(t, m, []string{"a", "b"}, []int{1, 2})
+		require.True(t, m.UndoToCheckpoint())
+		assertOrderedMap[string, int](t, m, nil, nil)
+		assert.False(t, m.UndoToCheckpoint())
+
+		require.True(t, m.RedoToCheckpoint())
+		assertOrderedMap(t, m, []string{"a", "b"}, []int{1, 2})
+		require.True(t, m.RedoToCheckpoint())
+		assertOrderedMap(t, m, []string{"b", "c"}, []int{2, 3})
+
+		// Undo and Redo cross checkpoints one operation at a time.
+		require.True(t, m.Undo())
+		assertOrderedMap(t, m, []string{"a", "b", "c"}, []int{1, 2, 3})
+		require.True(t, m.RedoToCheckpoint())
+		require.True(t, m.RedoToCheckpoint())
+		assertOrderedMap(t, m, []string{"b", "c"}, []int{4, 3})
+		assert.False(t, m.RedoToCheckpoint())
+	})
+
+	t.Run("MaxHistory", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, int]()
+		m.EnableJournal(2)
+		m.Set("a", 1)
+		m.Checkpoint()
+		m.Set("b", 2)
+		m.Set("c", 3)
+
+		assert.True(t, m.Undo())
+		assert.True(t, m.Undo())
+		assert.False(t, m.Undo())
+		assertOrderedMap(t, m, []string{"a"}, []int{1})
+
+		m.Redo()
+		m.Redo()
+		m.EnableJournal(1)
+		assert.True(t, m.Undo())
+		assert.False(t, m.Undo())
+		assertOrderedMap(t, m, []string{"a", "b"}, []int{1, 2})
+	})
+
+	t.Run("DisableJournal", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, int]()
+		m.EnableJournal(0)
+		m.Set("a", 1)
+		m.DisableJournal()
+		assert.False(t, m.Undo())
+	})
+
+	t.Run("TxCommitWithSwappedKeys", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, int]()
+		m.Set("a", 1)
+		m.Set("b", 2)
+		m.Set("c", 3)
+		m.EnableJournal(0)
+		m.Checkpoint()
+
+		tx := m.Begin()
+		tx.ReplaceKey("a", "tmp")
+		tx.ReplaceKey("c", "a")
+		tx.ReplaceKey("tmp", "c")
+		tx.Set("b", 20)
+		tx.Set("d", 4)
+		require.NoError(t, tx.Commit())
+		assertOrderedMap(t, m, []string{"c", "b", "a", "d"}, []int{1, 20, 3, 4})
+
+		require.True(t, m.UndoToCheckpoint())
+		assertOrderedMap(t, m, []string{"a", "b", "c"}, []int{1, 2, 3})
+		require.True(t, m.RedoToCheckpoint())
+		assertOrderedMap(t, m, []string{"c", "b", "a", "d"}, []int{1, 20, 3, 4})
+	})
+
+	t.Run("UndoAllAndRedoAll", func(t *testing.T) {
+		r := rand.New(rand.NewSource(1))
+		m := orderedmap.NewOrderedMap[string, int]()
+		m.EnableJournal(0)
+
+		type state struct {
+			keys   []string
+			values []int
+		}
+		var states []state
+		save := func() {
+			states = append(states, state{slices.Collect(m.Keys()), slices.Collect(m.Values())})
+		}
+
+		save()
+		for i := 0; i < 300; i++ {
+			key, other := strconv.Itoa(r.Intn(20)), strconv.Itoa(r.Intn(20))
+			var changed bool
+			switch r.Intn(3) {
+			case 0:
+				m.Set(key, i)
+				changed = true
+			case 1:
+				changed = m.Delete(key)
+			case 2:
+				changed = m.ReplaceKey(key, other)
+			}
+			if changed {
+				save()
+			}
+		}
+
+		for i := len(states) - 2; i >= 0; i-- {
+			require.True(t, m.Undo())
+			assertOrderedMap(t, m, states[i].keys, states[i].values)
+		}
+		assert.False(t, m.Undo())
+
+		for i := 1; i < len(states); i++ {
+			require.True(t, m.Redo())
+			assertOrderedMap(t, m, states[i].keys, states[i].values)
+		}
+		assert.False(t, m.Redo())
+	})
+}
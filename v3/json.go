@@ -0,0 +1,264 @@
+package orderedmap
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// ErrDuplicateKey is returned when decoding an object that contains the same
+// key more than once and the DuplicateKeyError policy is in effect.
+var ErrDuplicateKey = errors.New("orderedmap: duplicate key")
+
+// DuplicateKeyPolicy controls what happens when a decoded object contains the
+// same key more than once.
+type DuplicateKeyPolicy int
+
+const (
+	// DuplicateKeyLastWins keeps the last value for a key, but the key stays in
+	// the position where it first appeared. This is the same behavior as
+	// calling Set for each key in turn, and it is the default.
+	DuplicateKeyLastWins DuplicateKeyPolicy = iota
+
+	// DuplicateKeyMoveToBack keeps the last value for a key and moves the key
+	// to the position of its last occurrence.
+	DuplicateKeyMoveToBack
+
+	// DuplicateKeyError stops decoding and returns an error wrapping
+	// ErrDuplicateKey.
+	DuplicateKeyError
+)
+
+var textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+
+// MarshalJSON implements json.Marshaler. The map is encoded as a JSON object
+// with the keys in the same order as the map (front to back).
+//
+// Keys are encoded the same way encoding/json encodes the keys of a plain
+// map: string keys are used directly, encoding.TextMarshaler keys are
+// marshaled, and integer keys are converted to strings.
+func (m *OrderedMap[K, V]) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return []byte("null"), nil
+	}
+
+	var buf bytes.Buffer
+	if err := NewJSONEncoder(&buf).Encode(m); err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimSuffix(buf.Bytes(), []byte{'\n'}), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler. The keys of the JSON object are
+// added to the map in the order they appear. Like encoding/json does for plain
+// maps, existing elements are kept and a JSON null leaves the map unchanged.
+//
+// Duplicate keys are handled with DuplicateKeyLastWins. Use
+// UnmarshalJSONWithPolicy to choose a different policy.
+func (m *OrderedMap[K, V]) UnmarshalJSON(data []byte) error {
+	return m.UnmarshalJSONWithPolicy(data, DuplicateKeyLastWins)
+}
+
+// UnmarshalJSONWithPolicy works like UnmarshalJSON but handles keys that appear
+// more than once in the object with the provided policy.
+//
+// If V is the empty interface, nested objects are decoded into
+// *OrderedMap[string, any] instead of map[string]any so that their order is
+// also preserved.
+func (m *OrderedMap[K, V]) UnmarshalJSONWithPolicy(data []byte, policy DuplicateKeyPolicy) error {
+	if string(bytes.TrimSpace(data)) == "null" {
+		return nil
+	}
+
+	d := NewJSONDecoder(bytes.NewReader(data))
+	d.SetDuplicateKeyPolicy(policy)
+	if err := m.decodeJSON(d); err != nil {
+		return err
+	}
+
+	return d.checkEOF()
+}
+
+// decodeJSON reads a single JSON object from d and sets each of its keys in
+// order.
+func (m *OrderedMap[K, V]) decodeJSON(d *JSONDecoder) error {
+	offset := d.dec.InputOffset()
+	tok, err := d.dec.Token()
+	if err != nil {
+		return err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return &json.UnmarshalTypeError{
+			Value:  jsonTokenKind(tok),
+			Type:   reflect.TypeOf(m),
+			Offset: offset,
+		}
+	}
+
+	return m.decodeJSONMembers(d)
+}
+
+// decodeJSONMembers sets each key of an object whose opening brace has already
+// been consumed from d. The closing brace is consumed before returning.
+func (m *OrderedMap[K, V]) decodeJSONMembers(d *JSONDecoder) error {
+	m.lazyInit()
+
+	// With the default policy Set already does the right thing, so keys only
+	// need to be tracked for the other policies.
+	var seen map[K]struct{}
+	if d.duplicates != DuplicateKeyLastWins {
+		seen = make(map[K]struct{})
+	}
+
+	for d.dec.More() {
+		offset := d.dec.InputOffset()
+		tok, err := d.dec.Token()
+		if err != nil {
+			return err
+		}
+
+		key, err := decodeJSONKey[K](tok.(string))
+		if err != nil {
+			return &json.UnmarshalTypeError{
+				Value:  "string " + strconv.Quote(tok.(string)),
+				Type:   reflect.TypeFor[K](),
+				Offset: offset,
+				Field:  tok.(string),
+			}
+		}
+
+		var value V
+		if v, ok := any(&value).(*any); ok {
+			*v, err = d.Decode()
+		} else {
+			err = d.dec.Decode(&value)
+		}
+		if err != nil {
+			return err
+		}
+
+		if _, ok := seen[key]; ok {
+			switch d.duplicates {
+			case DuplicateKeyError:
+				return fmt.Errorf("%w: %q", ErrDuplicateKey, tok)
+
+			case DuplicateKeyMoveToBack:
+				m.Delete(key)
+			}
+		}
+		if seen != nil {
+			seen[key] = struct{}{}
+		}
+
+		m.Set(key, value)
+	}
+
+	// Consume the closing brace.
+	_, err := d.dec.Token()
+
+	return err
+}
+
+// encodeJSONKey returns the string used as the JSON object key for key.
+func encodeJSONKey(key any) (string, error) {
+	if s, ok := key.(string); ok {
+		return s, nil
+	}
+
+	v := reflect.ValueOf(key)
+	if !v.IsValid() {
+		return "", &json.UnsupportedValueError{Str: "nil key"}
+	}
+
+	if v.Kind() == reflect.String {
+		return v.String(), nil
+	}
+
+	if tm, ok := key.(encoding.TextMarshaler); ok {
+		if v.Kind() == reflect.Pointer && v.IsNil() {
+			return "", nil
+		}
+		text, err := tm.MarshalText()
+		if err != nil {
+			return "", &json.MarshalerError{Type: v.Type(), Err: err}
+		}
+
+		return string(text), nil
+	}
+
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	}
+
+	return "", &json.UnsupportedTypeError{Type: v.Type()}
+}
+
+// decodeJSONKey converts a JSON object key into K, following the same rules as
+// encoding/json does for the keys of a plain map.
+func decodeJSONKey[K comparable](s string) (key K, err error) {
+	v := reflect.ValueOf(&key).Elem()
+	if reflect.PointerTo(v.Type()).Implements(textUnmarshalerType) {
+		err = v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var n int64
+		n, err = strconv.ParseInt(s, 10, 64)
+		if err == nil && v.OverflowInt(n) {
+			err = strconv.ErrRange
+		}
+		v.SetInt(n)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64, reflect.Uintptr:
+		var n uint64
+		n, err = strconv.ParseUint(s, 10, 64)
+		if err == nil && v.OverflowUint(n) {
+			err = strconv.ErrRange
+		}
+		v.SetUint(n)
+
+	default:
+		err = &json.UnsupportedTypeError{Type: v.Type()}
+	}
+
+	return
+}
+
+// jsonTokenKind describes a token for error messages in the same way
+// encoding/json does.
+func jsonTokenKind(tok json.Token) string {
+	switch tok := tok.(type) {
+	case json.Delim:
+		if tok == '[' {
+			return "array"
+		}
+		return "object"
+
+	case bool:
+		return "bool"
+
+	case float64, json.Number:
+		return "number"
+
+	case string:
+		return "string"
+	}
+
+	return "null"
+}
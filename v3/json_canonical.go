@@ -0,0 +1,240 @@
+package orderedmap
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// SetCanonical switches the encoder between writing objects in insertion order
+// (the default) and writing the JSON Canonicalization Scheme (RFC 8785).
+//
+// In canonical mode the keys of every object (including nested ordered maps and
+// values that are not ordered maps) are sorted by their UTF-16 code units,
+// there is no whitespace (SetIndent and SetEscapeHTML are ignored), strings use
+// the minimal escaping and numbers are written in the shortest form used by
+// ECMAScript. As the scheme requires, numbers are treated as IEEE 754 double
+// precision values, so integers larger than 2^53 may lose precision. NaN and
+// infinite values are an error.
+func (e *JSONEncoder) SetCanonical(on bool) {
+	e.canonical = on
+}
+
+// canonicalMember is an object member waiting to be sorted.
+type canonicalMember struct {
+	key   string
+	value any
+}
+
+// encodeCanonical writes v as RFC 8785 JSON.
+func (e *JSONEncoder) encodeCanonical(v any) error {
+	switch v := v.(type) {
+	case jsonObjectEncoder:
+		return v.encodeJSON(e, 0)
+
+	case nil:
+		e.w.WriteString("null")
+
+	case bool:
+		e.w.WriteString(strconv.FormatBool(v))
+
+	case string:
+		return e.writeCanonicalString(v)
+
+	case float64:
+		s, err := formatCanonicalNumber(v)
+		if err != nil {
+			return err
+		}
+		e.w.WriteString(s)
+
+	case json.Number:
+		f, err := strconv.ParseFloat(string(v), 64)
+		if err != nil {
+			return fmt.Errorf("orderedmap: invalid number %q: %w", v, err)
+		}
+		return e.encodeCanonical(f)
+
+	case []any:
+		if v == nil {
+			e.w.WriteString("null")
+			return nil
+		}
+		e.w.WriteByte('[')
+		for i, value := range v {
+			if i > 0 {
+				e.w.WriteByte(',')
+			}
+			if err := e.encodeCanonical(value); err != nil {
+				return err
+			}
+		}
+		e.w.WriteByte(']')
+
+	case map[string]any:
+		if v == nil {
+			e.w.WriteString("null")
+			return nil
+		}
+		members := make([]canonicalMember, 0, len(v))
+		for key, value := range v {
+			members = append(members, canonicalMember{key, value})
+		}
+		return e.writeCanonicalObject(members)
+
+	default:
+		// Everything else is reduced to the types above by going through
+		// encoding/json, which also takes care of json.Marshaler and struct
+		// tags.
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.UseNumber()
+		var generic any
+		if err := dec.Decode(&generic); err != nil {
+			return err
+		}
+		return e.encodeCanonical(generic)
+	}
+
+	return nil
+}
+
+// writeCanonicalObject sorts the members and writes them as an object.
+func (e *JSONEncoder) writeCanonicalObject(members []canonicalMember) error {
+	slices.SortFunc(members, func(a, b canonicalMember) int {
+		return compareUTF16(a.key, b.key)
+	})
+
+	e.w.WriteByte('{')
+	for i, member := range members {
+		if i > 0 {
+			e.w.WriteByte(',')
+		}
+		if err := e.encodeMember(member.key, member.value, 0); err != nil {
+			return err
+		}
+	}
+	e.w.WriteByte('}')
+
+	return nil
+}
+
+// writeCanonicalString writes s with only the escaping required by RFC 8785.
+func (e *JSONEncoder) writeCanonicalString(s string) error {
+	if !utf8.ValidString(s) {
+		return fmt.Errorf("orderedmap: invalid UTF-8 in string %q", s)
+	}
+
+	e.w.WriteByte('"')
+	for _, c := range s {
+		switch c {
+		case '"':
+			e.w.WriteString(`\"`)
+		case '\\':
+			e.w.WriteString(`\\`)
+		case '\b':
+			e.w.WriteString(`\b`)
+		case '\f':
+			e.w.WriteString(`\f`)
+		case '\n':
+			e.w.WriteString(`\n`)
+		case '\r':
+			e.w.WriteString(`\r`)
+		case '\t':
+			e.w.WriteString(`\t`)
+		default:
+			if c < 0x20 {
+				fmt.Fprintf(e.w, `\u%04x`, c)
+			} else {
+				e.w.WriteRune(c)
+			}
+		}
+	}
+	e.w.WriteByte('"')
+
+	return nil
+}
+
+// formatCanonicalNumber formats f in the same way as the ECMAScript
+// Number.prototype.toString, as required by RFC 8785.
+func formatCanonicalNumber(f float64) (string, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return "", fmt.Errorf("orderedmap: unsupported number %v", f)
+	}
+	if f == 0 {
+		// This also covers negative zero.
+		return "0", nil
+	}
+
+	var sign string
+	if f < 0 {
+		sign, f = "-", -f
+	}
+
+	// The shortest digits that round trip, and n such that the value is
+	// 0.digits * 10^n.
+	mantissa, exp, _ := strings.Cut(strconv.FormatFloat(f, 'e', -1, 64), "e")
+	digits := strings.Replace(mantissa, ".", "", 1)
+	n, _ := strconv.Atoi(exp)
+	n++
+	k := len(digits)
+
+	switch {
+	case k <= n && n <= 21:
+		return sign + digits + strings.Repeat("0", n-k), nil
+
+	case 0 < n && n <= 21:
+		return sign + digits[:n] + "." + digits[n:], nil
+
+	case -6 < n && n <= 0:
+		return sign + "0." + strings.Repeat("0", -n) + digits, nil
+	}
+
+	s := digits[:1]
+	if k > 1 {
+		s += "." + digits[1:]
+	}
+	if n-1 >= 0 {
+		s += "e+" + strconv.Itoa(n-1)
+	} else {
+		s += "e" + strconv.Itoa(n-1)
+	}
+
+	return sign + s, nil
+}
+
+// compareUTF16 compares strings by their UTF-16 code units, which only differs
+// from comparing runes for characters outside the Basic Multilingual Plane.
+func compareUTF16(a, b string) int {
+	for a != "" && b != "" {
+		ra, na := utf8.DecodeRuneInString(a)
+		rb, nb := utf8.DecodeRuneInString(b)
+		if ra != rb {
+			if ua, ub := utf16Unit(ra), utf16Unit(rb); ua != ub {
+				return ua - ub
+			}
+			return int(ra - rb)
+		}
+		a, b = a[na:], b[nb:]
+	}
+
+	return len(a) - len(b)
+}
+
+// utf16Unit returns the first UTF-16 code unit of r, which is a high
+// surrogate for characters outside the Basic Multilingual Plane.
+func utf16Unit(r rune) int {
+	if r >= 0x10000 {
+		return 0xd800 + int((r-0x10000)>>10)
+	}
+
+	return int(r)
+}
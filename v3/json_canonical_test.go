@@ -0,0 +1,107 @@
+package orderedmap_test
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/elliotchance/orderedmap/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encodeCanonical(t *testing.T, v any) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	e := orderedmap.NewJSONEncoder(&buf)
+	e.SetCanonical(true)
+	require.NoError(t, e.Encode(v))
+
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+func TestJSONEncoder_SetCanonical(t *testing.T) {
+	t.Run("RFC8785Example", func(t *testing.T) {
+		v, err := orderedmap.DecodeJSON([]byte(`{
+			"numbers": [333333333.33333329, 1E30, 4.50, 2e-3, 0.000000000000000000000000001],
+			"string": "\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/",
+			"literals": [null, true, false]
+		}`))
+		require.NoError(t, err)
+		assert.Equal(t,
+			`{"literals":[null,true,false],"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],"string":"€$\u000f\nA'B\"\\\\\"/"}`,
+			encodeCanonical(t, v))
+	})
+
+	t.Run("SortsByUTF16", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, int]()
+		for i, key := range []string{"\u20ac", "\r", "\ufb33", "1", "\U0001F600", "\u0080", "\u00f6"} {
+			m.Set(key, i)
+		}
+		assert.Equal(t,
+			"{\"\\r\":1,\"1\":3,\"\u0080\":5,\"\u00f6\":6,\"\u20ac\":0,\"\U0001F600\":4,\"\ufb33\":2}",
+			encodeCanonical(t, m))
+	})
+
+	t.Run("Nested", func(t *testing.T) {
+		type record struct {
+			Zeta  string         `json:"zeta"`
+			Alpha map[string]int `json:"alpha"`
+		}
+		inner := orderedmap.NewOrderedMap[string, any]()
+		inner.Set("b", record{"<z>", map[string]int{"y": 2, "x": 1}})
+		inner.Set("a", []any{orderedmap.NewOrderedMapWithElements(
+			&orderedmap.Element[string, int]{Key: "d", Value: 1},
+			&orderedmap.Element[string, int]{Key: "c", Value: 2},
+		)})
+		m := orderedmap.NewOrderedMap[string, any]()
+		m.Set("z", inner)
+		m.Set("y", uint64(1)<<40)
+		m.Set("x", float32(0.1))
+		assert.Equal(t,
+			`{"x":0.1,"y":1099511627776,"z":{"a":[{"c":2,"d":1}],"b":{"alpha":{"x":1,"y":2},"zeta":"<z>"}}}`,
+			encodeCanonical(t, m))
+	})
+
+	t.Run("IgnoresIndent", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, int]()
+		m.Set("b", 1)
+		m.Set("a", 2)
+
+		var buf bytes.Buffer
+		e := orderedmap.NewJSONEncoder(&buf)
+		e.SetIndent("", "  ")
+		e.SetCanonical(true)
+		require.NoError(t, e.Encode(m))
+		assert.Equal(t, "{\"a\":2,\"b\":1}\n", buf.String())
+	})
+
+	t.Run("Numbers", func(t *testing.T) {
+		for f, expected := range map[float64]string{
+			0:                      "0",
+			math.Copysign(0, -1):   "0",
+			-1.5:                   "-1.5",
+			1e21:                   "1e+21",
+			1e20:                   "100000000000000000000",
+			123e-9:                 "1.23e-7",
+			0.000001:               "0.000001",
+			9007199254740993:       "9007199254740992",
+			math.MaxFloat64:        "1.7976931348623157e+308",
+			5e-324:                 "5e-324",
+			-0.0000033333333333333: "-0.0000033333333333333",
+		} {
+			assert.Equal(t, expected, encodeCanonical(t, f), f)
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		for _, v := range []any{math.NaN(), math.Inf(1), "\xff"} {
+			var buf bytes.Buffer
+			e := orderedmap.NewJSONEncoder(&buf)
+			e.SetCanonical(true)
+			assert.Error(t, e.Encode(v))
+		}
+	})
+}
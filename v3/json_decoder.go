@@ -0,0 +1,109 @@
+package orderedmap
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// JSONDecoder reads JSON values from an input stream. It works like
+// json.Decoder decoding into an interface value, except that every JSON object
+// (at any depth) is decoded into an *OrderedMap[string, any] so that the order
+// of the keys is never lost.
+//
+// The other JSON types are decoded in the same way as encoding/json: arrays
+// become []any, strings become string, numbers become float64 (or json.Number
+// after calling UseNumber), booleans become bool and null becomes nil.
+type JSONDecoder struct {
+	dec        *json.Decoder
+	duplicates DuplicateKeyPolicy
+}
+
+// NewJSONDecoder returns a new decoder that reads from r.
+func NewJSONDecoder(r io.Reader) *JSONDecoder {
+	return &JSONDecoder{
+		dec: json.NewDecoder(r),
+	}
+}
+
+// UseNumber causes the decoder to decode numbers as json.Number instead of
+// float64.
+func (d *JSONDecoder) UseNumber() {
+	d.dec.UseNumber()
+}
+
+// SetDuplicateKeyPolicy controls how keys that appear more than once in the
+// same object are handled. The default is DuplicateKeyLastWins.
+func (d *JSONDecoder) SetDuplicateKeyPolicy(policy DuplicateKeyPolicy) {
+	d.duplicates = policy
+}
+
+// More reports whether there is another value to decode in the input stream.
+func (d *JSONDecoder) More() bool {
+	return d.dec.More()
+}
+
+// Decode reads the next JSON value from the input stream.
+func (d *JSONDecoder) Decode() (any, error) {
+	tok, err := d.dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		m := NewOrderedMap[string, any]()
+		if err := m.decodeJSONMembers(d); err != nil {
+			return nil, err
+		}
+
+		return m, nil
+
+	case json.Delim('['):
+		values := []any{}
+		for d.dec.More() {
+			value, err := d.Decode()
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+
+		// Consume the closing bracket.
+		if _, err := d.dec.Token(); err != nil {
+			return nil, err
+		}
+
+		return values, nil
+	}
+
+	return tok, nil
+}
+
+// checkEOF returns an error if there is anything other than whitespace left in
+// the input stream.
+func (d *JSONDecoder) checkEOF() error {
+	if _, err := d.dec.Token(); err != io.EOF {
+		return fmt.Errorf("orderedmap: invalid character after top-level value at offset %d", d.dec.InputOffset())
+	}
+
+	return nil
+}
+
+// DecodeJSON decodes a single JSON document. Every object in the document is
+// decoded into an *OrderedMap[string, any]. See JSONDecoder for the types used
+// for other values, and to keep numbers as json.Number.
+func DecodeJSON(data []byte) (any, error) {
+	d := NewJSONDecoder(bytes.NewReader(data))
+	value, err := d.Decode()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := d.checkEOF(); err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
@@ -0,0 +1,120 @@
+package orderedmap_test
+
+import (
+	"encoding/json"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/elliotchance/orderedmap/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeJSON(t *testing.T) {
+	t.Run("Scalars", func(t *testing.T) {
+		for data, expected := range map[string]any{
+			`"foo"`: "foo",
+			`1.5`:   1.5,
+			`true`:  true,
+			`null`:  nil,
+		} {
+			value, err := orderedmap.DecodeJSON([]byte(data))
+			require.NoError(t, err)
+			assert.Equal(t, expected, value)
+		}
+	})
+
+	t.Run("NestedObjectsAreOrdered", func(t *testing.T) {
+		value, err := orderedmap.DecodeJSON([]byte(
+			`{"z":{"y":1,"x":[{"c":1,"b":2}]},"a":[]}`))
+		require.NoError(t, err)
+
+		m, ok := value.(*orderedmap.OrderedMap[string, any])
+		require.True(t, ok)
+		assert.Equal(t, []string{"z", "a"}, slices.Collect(m.Keys()))
+		assert.Equal(t, []any{}, m.GetOrDefault("a", nil))
+
+		z := m.GetOrDefault("z", nil).(*orderedmap.OrderedMap[string, any])
+		assert.Equal(t, []string{"y", "x"}, slices.Collect(z.Keys()))
+
+		x := z.GetOrDefault("x", nil).([]any)
+		require.Len(t, x, 1)
+		c := x[0].(*orderedmap.OrderedMap[string, any])
+		assert.Equal(t, []string{"c", "b"}, slices.Collect(c.Keys()))
+		assert.Equal(t, []any{1.0, 2.0}, slices.Collect(c.Values()))
+	})
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		data := `{"z":{"y":1,"x":[{"c":"d","b":null}]},"a":[true,false]}`
+		value, err := orderedmap.DecodeJSON([]byte(data))
+		require.NoError(t, err)
+
+		encoded, err := json.Marshal(value)
+		require.NoError(t, err)
+		assert.Equal(t, data, string(encoded))
+	})
+
+	t.Run("InvalidJSON", func(t *testing.T) {
+		_, err := orderedmap.DecodeJSON([]byte(`{"a":}`))
+		assert.Error(t, err)
+	})
+
+	t.Run("TrailingData", func(t *testing.T) {
+		_, err := orderedmap.DecodeJSON([]byte(`{} []`))
+		assert.Error(t, err)
+	})
+}
+
+func TestJSONDecoder(t *testing.T) {
+	t.Run("UseNumber", func(t *testing.T) {
+		d := orderedmap.NewJSONDecoder(strings.NewReader(
+			`{"a":12345678901234567890,"b":[1.50]}`))
+		d.UseNumber()
+		value, err := d.Decode()
+		require.NoError(t, err)
+
+		m := value.(*orderedmap.OrderedMap[string, any])
+		assert.Equal(t, json.Number("12345678901234567890"),
+			m.GetOrDefault("a", nil))
+		assert.Equal(t, []any{json.Number("1.50")}, m.GetOrDefault("b", nil))
+	})
+
+	t.Run("DuplicateKeyPolicy", func(t *testing.T) {
+		d := orderedmap.NewJSONDecoder(strings.NewReader(
+			`{"x":{"a":1,"a":2}}`))
+		d.SetDuplicateKeyPolicy(orderedmap.DuplicateKeyError)
+		_, err := d.Decode()
+		assert.ErrorIs(t, err, orderedmap.ErrDuplicateKey)
+	})
+
+	t.Run("Stream", func(t *testing.T) {
+		d := orderedmap.NewJSONDecoder(strings.NewReader(`{"b":1} {"a":2} 3`))
+		var values []any
+		for d.More() {
+			value, err := d.Decode()
+			require.NoError(t, err)
+			values = append(values, value)
+		}
+		require.Len(t, values, 3)
+		assert.Equal(t, 3.0, values[2])
+
+		_, err := d.Decode()
+		assert.Equal(t, io.EOF, err)
+	})
+}
+
+func TestOrderedMap_UnmarshalJSON_NestedAny(t *testing.T) {
+	m := orderedmap.NewOrderedMap[string, any]()
+	err := json.Unmarshal([]byte(`{"b":{"y":1,"x":2},"a":[{"d":1,"c":2}]}`), m)
+	require.NoError(t, err)
+
+	b, ok := m.GetOrDefault("b", nil).(*orderedmap.OrderedMap[string, any])
+	require.True(t, ok)
+	assert.Equal(t, []string{"y", "x"}, slices.Collect(b.Keys()))
+
+	a := m.GetOrDefault("a", nil).([]any)
+	c := a[0].(*orderedmap.OrderedMap[string, any])
+	assert.Equal(t, []string{"d", "c"}, slices.Collect(c.Keys()))
+}
@@ -0,0 +1,271 @@
+package orderedmap
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// JSONValueEncoder is called by a JSONEncoder for every value of an object
+// before it is encoded. The key is the encoded JSON key (before escaping).
+//
+// If the function writes the value itself it must write exactly one complete
+// JSON value to w and return true. Returning false leaves the value to be
+// encoded as normal. Values written by the function are not indented.
+type JSONValueEncoder func(w io.Writer, key string, value any) (handled bool, err error)
+
+// JSONEncoder writes JSON values to an output stream. It works like
+// json.Encoder, except that ordered maps (including ordered maps nested inside
+// other ordered maps or inside []any) are written element by element by walking
+// the map from front to back, rather than being marshaled into memory first.
+type JSONEncoder struct {
+	w            *bufio.Writer
+	prefix       string
+	indent       string
+	escapeHTML   bool
+	canonical    bool
+	encodeValue  JSONValueEncoder
+	scratch      bytes.Buffer
+	scratchCoder *json.Encoder
+
+	// err is the first error returned by Encode, see Encode.
+	err error
+}
+
+// jsonObjectEncoder is implemented by all *OrderedMap types so that the
+// encoder can stream them regardless of K and V.
+type jsonObjectEncoder interface {
+	encodeJSON(e *JSONEncoder, depth int) error
+}
+
+// NewJSONEncoder returns a new encoder that writes to w.
+func NewJSONEncoder(w io.Writer) *JSONEncoder {
+	e := &JSONEncoder{
+		w:          bufio.NewWriter(w),
+		escapeHTML: true,
+	}
+	e.scratchCoder = json.NewEncoder(&e.scratch)
+
+	return e
+}
+
+// SetIndent instructs the encoder to format each subsequent encoded value as if
+// indented by json.Indent with the same prefix and indent.
+func (e *JSONEncoder) SetIndent(prefix, indent string) {
+	e.prefix = prefix
+	e.indent = indent
+}
+
+// SetEscapeHTML specifies whether problematic HTML characters should be escaped
+// inside JSON quoted strings. The default is true, the same as json.Encoder.
+func (e *JSONEncoder) SetEscapeHTML(on bool) {
+	e.escapeHTML = on
+	e.scratchCoder.SetEscapeHTML(on)
+}
+
+// SetValueEncoder registers a function that is offered every object value
+// before it is encoded. Passing nil removes a previous function.
+func (e *JSONEncoder) SetValueEncoder(fn JSONValueEncoder) {
+	e.encodeValue = fn
+}
+
+// Encode writes the JSON encoding of v to the stream, followed by a newline
+// character.
+//
+// Values are written as they are encoded, so if Encode returns an error part of
+// the value may already have been written. The stream cannot be continued after
+// that, so every later call to Encode returns the same error.
+func (e *JSONEncoder) Encode(v any) error {
+	if e.err != nil {
+		return e.err
+	}
+
+	e.err = e.encode(v, 0)
+	if e.err == nil {
+		e.err = e.w.WriteByte('\n')
+	}
+	if e.err == nil {
+		e.err = e.w.Flush()
+	}
+
+	return e.err
+}
+
+func (e *JSONEncoder) encode(v any, depth int) error {
+	if e.canonical {
+		return e.encodeCanonical(v)
+	}
+
+	switch v := v.(type) {
+	case jsonObjectEncoder:
+		return v.encodeJSON(e, depth)
+
+	case []any:
+		if v == nil {
+			break
+		}
+
+		e.w.WriteByte('[')
+		for i, value := range v {
+			if i > 0 {
+				e.w.WriteByte(',')
+			}
+			e.newline(depth + 1)
+			if err := e.encode(value, depth+1); err != nil {
+				return err
+			}
+		}
+		if len(v) > 0 {
+			e.newline(depth)
+		}
+		e.w.WriteByte(']')
+
+		return nil
+	}
+
+	b, err := e.marshal(v)
+	if err != nil {
+		return err
+	}
+
+	if !e.indenting() {
+		_, err = e.w.Write(b)
+		return err
+	}
+
+	var buf bytes.Buffer
+	err = json.Indent(&buf, b, e.prefix+strings.Repeat(e.indent, depth), e.indent)
+	if err != nil {
+		return err
+	}
+	_, err = buf.WriteTo(e.w)
+
+	return err
+}
+
+// encodeKey writes an object key and the separator that follows it.
+func (e *JSONEncoder) encodeKey(key string) error {
+	if e.canonical {
+		if err := e.writeCanonicalString(key); err != nil {
+			return err
+		}
+		return e.w.WriteByte(':')
+	}
+
+	b, err := e.marshal(key)
+	if err != nil {
+		return err
+	}
+
+	e.w.Write(b)
+	e.w.WriteByte(':')
+	if e.indenting() {
+		e.w.WriteByte(' ')
+	}
+
+	return nil
+}
+
+// encodeMember writes a single key-value pair of an object.
+func (e *JSONEncoder) encodeMember(key string, value any, depth int) error {
+	if err := e.encodeKey(key); err != nil {
+		return err
+	}
+
+	if e.encodeValue != nil {
+		handled, err := e.encodeValue(e.w, key, value)
+		if err != nil || handled {
+			return err
+		}
+	}
+
+	return e.encode(value, depth)
+}
+
+// marshal returns the compact JSON encoding of v using the HTML escaping
+// setting of the encoder.
+func (e *JSONEncoder) marshal(v any) ([]byte, error) {
+	e.scratch.Reset()
+	if err := e.scratchCoder.Encode(v); err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimSuffix(e.scratch.Bytes(), []byte{'\n'}), nil
+}
+
+func (e *JSONEncoder) indenting() bool {
+	return !e.canonical && (e.prefix != "" || e.indent != "")
+}
+
+// newline starts a new line at the given depth if the encoder is indenting.
+func (e *JSONEncoder) newline(depth int) {
+	if !e.indenting() {
+		return
+	}
+
+	e.w.WriteByte('\n')
+	e.w.WriteString(e.prefix)
+	for i := 0; i < depth; i++ {
+		e.w.WriteString(e.indent)
+	}
+}
+
+// EncodeJSON writes the map as a JSON object to w, followed by a newline. It is
+// a shortcut for NewJSONEncoder(w).Encode(m).
+func (m *OrderedMap[K, V]) EncodeJSON(w io.Writer) error {
+	return NewJSONEncoder(w).Encode(m)
+}
+
+func (m *OrderedMap[K, V]) encodeJSON(e *JSONEncoder, depth int) error {
+	if m == nil {
+		_, err := e.w.WriteString("null")
+		return err
+	}
+
+	if e.canonical {
+		members := make([]canonicalMember, 0, m.Len())
+		for el := m.Front(); el != nil; el = el.Next() {
+			key, err := encodeJSONKey(el.Key)
+			if err != nil {
+				return err
+			}
+			members = append(members, canonicalMember{key, el.Value})
+		}
+
+		return e.writeCanonicalObject(members)
+	}
+
+	e.w.WriteByte('{')
+	for el := m.Front(); el != nil; el = el.Next() {
+		if el != m.Front() {
+			e.w.WriteByte(',')
+		}
+		e.newline(depth + 1)
+
+		key, err := encodeJSONKey(el.Key)
+		if err != nil {
+			return err
+		}
+		if err := e.encodeMember(key, el.Value, depth+1); err != nil {
+			return err
+		}
+	}
+	if m.Front() != nil {
+		e.newline(depth)
+	}
+	e.w.WriteByte('}')
+
+	return nil
+}
+
+// DecodeJSONObject reads the next JSON value from d, which must be an object,
+// and calls Set on m for each key as soon as its value has been parsed. The
+// whole object is never held in memory as raw JSON.
+//
+// Values are decoded with encoding/json, except when V is the empty interface
+// where they are decoded in the same way as JSONDecoder.Decode.
+func DecodeJSONObject[K comparable, V any](d *JSONDecoder, m *OrderedMap[K, V]) error {
+	return m.decodeJSON(d)
+}
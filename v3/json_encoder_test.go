@@ -0,0 +1,198 @@
+package orderedmap_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/elliotchance/orderedmap/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newNestedJSONMap() *orderedmap.OrderedMap[string, any] {
+	inner := orderedmap.NewOrderedMap[string, any]()
+	inner.Set("y", []int{1, 2})
+	inner.Set("x", orderedmap.NewOrderedMap[int, string]())
+
+	m := orderedmap.NewOrderedMap[string, any]()
+	m.Set("z", "<tag>")
+	m.Set("inner", inner)
+	m.Set("list", []any{inner, 3, []any{}})
+	m.Set("a", map[string]int{"q": 1})
+
+	return m
+}
+
+func TestJSONEncoder(t *testing.T) {
+	t.Run("Compact", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := orderedmap.NewJSONEncoder(&buf).Encode(newNestedJSONMap())
+		require.NoError(t, err)
+		assert.Equal(t,
+			`{"z":"\u003ctag\u003e","inner":{"y":[1,2],"x":{}},`+
+				`"list":[{"y":[1,2],"x":{}},3,[]],"a":{"q":1}}`+"\n",
+			buf.String())
+	})
+
+	t.Run("IndentMatchesMarshalIndent", func(t *testing.T) {
+		m := newNestedJSONMap()
+		expected, err := json.MarshalIndent(m, ">", "  ")
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		e := orderedmap.NewJSONEncoder(&buf)
+		e.SetIndent(">", "  ")
+		require.NoError(t, e.Encode(m))
+		assert.Equal(t, string(expected)+"\n", buf.String())
+	})
+
+	t.Run("DisableEscapeHTML", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, string]()
+		m.Set("<a>", "&")
+
+		var buf bytes.Buffer
+		e := orderedmap.NewJSONEncoder(&buf)
+		e.SetEscapeHTML(false)
+		require.NoError(t, e.Encode(m))
+		assert.Equal(t, `{"<a>":"&"}`+"\n", buf.String())
+	})
+
+	t.Run("ValueEncoder", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, any]()
+		m.Set("small", 1)
+		m.Set("large", []int{1, 2, 3})
+
+		var keys []string
+		var buf bytes.Buffer
+		e := orderedmap.NewJSONEncoder(&buf)
+		e.SetValueEncoder(func(w io.Writer, key string, value any) (bool, error) {
+			keys = append(keys, key)
+			if key != "large" {
+				return false, nil
+			}
+
+			// Stream the array one value at a time.
+			io.WriteString(w, "[")
+			for i, v := range value.([]int) {
+				if i > 0 {
+					io.WriteString(w, ",")
+				}
+				fmt.Fprint(w, v*10)
+			}
+			_, err := io.WriteString(w, "]")
+
+			return true, err
+		})
+		require.NoError(t, e.Encode(m))
+		assert.Equal(t, `{"small":1,"large":[10,20,30]}`+"\n", buf.String())
+		assert.Equal(t, []string{"small", "large"}, keys)
+	})
+
+	t.Run("ValueEncoderError", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, any]()
+		m.Set("a", 1)
+
+		e := orderedmap.NewJSONEncoder(io.Discard)
+		e.SetValueEncoder(func(io.Writer, string, any) (bool, error) {
+			return false, assert.AnError
+		})
+		assert.ErrorIs(t, e.Encode(m), assert.AnError)
+	})
+
+	t.Run("UnsupportedValue", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, any]()
+		m.Set("a", func() {})
+		assert.Error(t, orderedmap.NewJSONEncoder(io.Discard).Encode(m))
+	})
+
+	t.Run("ErrorIsSticky", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, any]()
+		m.Set("a", 1)
+		m.Set("b", make(chan int))
+
+		var buf bytes.Buffer
+		e := orderedmap.NewJSONEncoder(&buf)
+		err := e.Encode(m)
+		require.Error(t, err)
+
+		// The partial value is never written, and the stream is not continued.
+		assert.Equal(t, err, e.Encode(map[string]int{"x": 1}))
+		assert.Empty(t, buf.String())
+	})
+
+	t.Run("EncodeJSON", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[int, bool]()
+		m.Set(2, true)
+		m.Set(1, false)
+
+		var buf bytes.Buffer
+		require.NoError(t, m.EncodeJSON(&buf))
+		assert.Equal(t, `{"2":true,"1":false}`+"\n", buf.String())
+	})
+}
+
+func TestDecodeJSONObject(t *testing.T) {
+	t.Run("Stream", func(t *testing.T) {
+		d := orderedmap.NewJSONDecoder(strings.NewReader(
+			`{"b":1,"a":2}` + "\n" + `{"c":3}`))
+
+		m := orderedmap.NewOrderedMap[string, int]()
+		require.NoError(t, orderedmap.DecodeJSONObject(d, m))
+		assert.Equal(t, []string{"b", "a"}, slices.Collect(m.Keys()))
+
+		m2 := orderedmap.NewOrderedMap[string, int]()
+		require.NoError(t, orderedmap.DecodeJSONObject(d, m2))
+		assert.Equal(t, []string{"c"}, slices.Collect(m2.Keys()))
+
+		assert.False(t, d.More())
+	})
+
+	t.Run("SetsEachPairAsItIsParsed", func(t *testing.T) {
+		// The reader fails half way through the object, but everything before
+		// that point must already be in the map.
+		r := io.MultiReader(strings.NewReader(`{"b":1,"a":2,"c":`),
+			errReader{err: assert.AnError})
+		m := orderedmap.NewOrderedMap[string, int]()
+		err := orderedmap.DecodeJSONObject(orderedmap.NewJSONDecoder(r), m)
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.Equal(t, []string{"b", "a"}, slices.Collect(m.Keys()))
+	})
+
+	t.Run("DuplicateKeyPolicy", func(t *testing.T) {
+		d := orderedmap.NewJSONDecoder(strings.NewReader(`{"a":1,"b":2,"a":3}`))
+		d.SetDuplicateKeyPolicy(orderedmap.DuplicateKeyMoveToBack)
+		m := orderedmap.NewOrderedMap[string, int]()
+		require.NoError(t, orderedmap.DecodeJSONObject(d, m))
+		assert.Equal(t, []string{"b", "a"}, slices.Collect(m.Keys()))
+	})
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		m := newNestedJSONMap()
+		var buf bytes.Buffer
+		e := orderedmap.NewJSONEncoder(&buf)
+		e.SetIndent("", "\t")
+		require.NoError(t, e.Encode(m))
+
+		m2 := orderedmap.NewOrderedMap[string, any]()
+		d := orderedmap.NewJSONDecoder(&buf)
+		require.NoError(t, orderedmap.DecodeJSONObject(d, m2))
+		assert.Equal(t, slices.Collect(m.Keys()), slices.Collect(m2.Keys()))
+
+		inner := m2.GetOrDefault("inner", nil).(*orderedmap.OrderedMap[string, any])
+		assert.Equal(t, []string{"y", "x"}, slices.Collect(inner.Keys()))
+	})
+}
+
+// errReader is a reader that always fails with err.
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
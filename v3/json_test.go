@@ -0,0 +1,258 @@
+package orderedmap_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/elliotchance/orderedmap/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type upperKey string
+
+func (k upperKey) MarshalText() ([]byte, error) {
+	return []byte(strings.ToUpper(string(k))), nil
+}
+
+func (k *upperKey) UnmarshalText(text []byte) error {
+	*k = upperKey(strings.ToLower(string(text)))
+	return nil
+}
+
+type pointKey struct {
+	X, Y int
+}
+
+func (k pointKey) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("%d,%d", k.X, k.Y)), nil
+}
+
+func (k *pointKey) UnmarshalText(text []byte) error {
+	_, err := fmt.Sscanf(string(text), "%d,%d", &k.X, &k.Y)
+	return err
+}
+
+func TestOrderedMap_MarshalJSON(t *testing.T) {
+	t.Run("EmptyMap", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, int]()
+		data, err := json.Marshal(m)
+		require.NoError(t, err)
+		assert.Equal(t, `{}`, string(data))
+	})
+
+	t.Run("NilMap", func(t *testing.T) {
+		var m *orderedmap.OrderedMap[string, int]
+		data, err := json.Marshal(m)
+		require.NoError(t, err)
+		assert.Equal(t, `null`, string(data))
+	})
+
+	t.Run("RetainsOrder", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, any]()
+		m.Set("z", 1)
+		m.Set("a", "two")
+		m.Set("m", []int{3})
+		m.Set("b", nil)
+		data, err := json.Marshal(m)
+		require.NoError(t, err)
+		assert.Equal(t, `{"z":1,"a":"two","m":[3],"b":null}`, string(data))
+	})
+
+	t.Run("IntegerKeys", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[int, bool]()
+		m.Set(5, true)
+		m.Set(-3, false)
+		data, err := json.Marshal(m)
+		require.NoError(t, err)
+		assert.Equal(t, `{"5":true,"-3":false}`, string(data))
+	})
+
+	t.Run("StringKindKeysIgnoreTextMarshaler", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[upperKey, int]()
+		m.Set("foo", 1)
+		data, err := json.Marshal(m)
+		require.NoError(t, err)
+		assert.Equal(t, `{"foo":1}`, string(data))
+	})
+
+	t.Run("TextMarshalerKeys", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[pointKey, string]()
+		m.Set(pointKey{3, 4}, "a")
+		m.Set(pointKey{1, 2}, "b")
+		data, err := json.Marshal(m)
+		require.NoError(t, err)
+		assert.Equal(t, `{"3,4":"a","1,2":"b"}`, string(data))
+	})
+
+	t.Run("UnsupportedKeys", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[float64, string]()
+		m.Set(1.5, "a")
+		_, err := json.Marshal(m)
+		assert.Error(t, err)
+	})
+
+	t.Run("NestedInStruct", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, int]()
+		m.Set("b", 1)
+		m.Set("a", 2)
+		data, err := json.Marshal(struct {
+			Fields *orderedmap.OrderedMap[string, int] `json:"fields"`
+		}{m})
+		require.NoError(t, err)
+		assert.Equal(t, `{"fields":{"b":1,"a":2}}`, string(data))
+	})
+}
+
+func TestOrderedMap_UnmarshalJSON(t *testing.T) {
+	t.Run("RetainsOrder", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, int]()
+		err := json.Unmarshal([]byte(`{"z":1,"a":2,"m":3}`), m)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"z", "a", "m"}, slices.Collect(m.Keys()))
+		assert.Equal(t, []int{1, 2, 3}, slices.Collect(m.Values()))
+	})
+
+	t.Run("KeepsExistingElements", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, int]()
+		m.Set("a", 1)
+		m.Set("b", 2)
+		err := json.Unmarshal([]byte(`{"c":3,"a":4}`), m)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a", "b", "c"}, slices.Collect(m.Keys()))
+		assert.Equal(t, []int{4, 2, 3}, slices.Collect(m.Values()))
+	})
+
+	t.Run("ZeroValueMap", func(t *testing.T) {
+		var v struct {
+			Fields orderedmap.OrderedMap[string, int]
+		}
+		err := json.Unmarshal([]byte(`{"Fields":{"b":1,"a":2}}`), &v)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"b", "a"}, slices.Collect(v.Fields.Keys()))
+	})
+
+	t.Run("PointerInStruct", func(t *testing.T) {
+		var v struct {
+			Fields *orderedmap.OrderedMap[string, int]
+		}
+		err := json.Unmarshal([]byte(`{"Fields":{"b":1,"a":2}}`), &v)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"b", "a"}, slices.Collect(v.Fields.Keys()))
+	})
+
+	t.Run("Null", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, int]()
+		m.Set("a", 1)
+		require.NoError(t, m.UnmarshalJSON([]byte(`null`)))
+		assert.Equal(t, 1, m.Len())
+	})
+
+	t.Run("IntegerKeys", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[int8, string]()
+		err := json.Unmarshal([]byte(`{"5":"a","-3":"b"}`), m)
+		require.NoError(t, err)
+		assert.Equal(t, []int8{5, -3}, slices.Collect(m.Keys()))
+	})
+
+	t.Run("IntegerKeyOverflow", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[int8, string]()
+		err := json.Unmarshal([]byte(`{"500":"a"}`), m)
+		var typeErr *json.UnmarshalTypeError
+		assert.ErrorAs(t, err, &typeErr)
+	})
+
+	t.Run("InvalidUnsignedKey", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[uint, string]()
+		err := json.Unmarshal([]byte(`{"-1":"a"}`), m)
+		assert.Error(t, err)
+	})
+
+	t.Run("TextUnmarshalerKeys", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[upperKey, int]()
+		err := json.Unmarshal([]byte(`{"FOO":1,"BAR":2}`), m)
+		require.NoError(t, err)
+		assert.Equal(t, []upperKey{"foo", "bar"}, slices.Collect(m.Keys()))
+	})
+
+	t.Run("NotAnObject", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, int]()
+		err := json.Unmarshal([]byte(`[1,2]`), m)
+		var typeErr *json.UnmarshalTypeError
+		require.ErrorAs(t, err, &typeErr)
+		assert.Equal(t, "array", typeErr.Value)
+	})
+
+	t.Run("WrongValueType", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, int]()
+		err := json.Unmarshal([]byte(`{"a":"b"}`), m)
+		assert.Error(t, err)
+	})
+
+	t.Run("TrailingData", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, int]()
+		err := m.UnmarshalJSON([]byte(`{"a":1} {}`))
+		assert.Error(t, err)
+	})
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[int, bool]()
+		for _, k := range []int{5, 3, 1, 4} {
+			m.Set(k, k%2 == 0)
+		}
+		data, err := json.Marshal(m)
+		require.NoError(t, err)
+
+		m2 := orderedmap.NewOrderedMap[int, bool]()
+		require.NoError(t, json.Unmarshal(data, m2))
+		assert.Equal(t, slices.Collect(m.Keys()), slices.Collect(m2.Keys()))
+		assert.Equal(t, slices.Collect(m.Values()), slices.Collect(m2.Values()))
+	})
+}
+
+func TestOrderedMap_UnmarshalJSONWithPolicy(t *testing.T) {
+	data := []byte(`{"a":1,"b":2,"a":3}`)
+
+	t.Run("LastWins", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, int]()
+		err := m.UnmarshalJSONWithPolicy(data, orderedmap.DuplicateKeyLastWins)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a", "b"}, slices.Collect(m.Keys()))
+		assert.Equal(t, []int{3, 2}, slices.Collect(m.Values()))
+	})
+
+	t.Run("MoveToBack", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, int]()
+		err := m.UnmarshalJSONWithPolicy(data, orderedmap.DuplicateKeyMoveToBack)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"b", "a"}, slices.Collect(m.Keys()))
+		assert.Equal(t, []int{2, 3}, slices.Collect(m.Values()))
+	})
+
+	t.Run("MoveToBackIgnoresExistingElements", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, int]()
+		m.Set("b", 0)
+		m.Set("c", 0)
+		err := m.UnmarshalJSONWithPolicy(data, orderedmap.DuplicateKeyMoveToBack)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"b", "c", "a"}, slices.Collect(m.Keys()))
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, int]()
+		err := m.UnmarshalJSONWithPolicy(data, orderedmap.DuplicateKeyError)
+		assert.ErrorIs(t, err, orderedmap.ErrDuplicateKey)
+	})
+
+	t.Run("ErrorAllowsExistingElements", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, int]()
+		m.Set("b", 0)
+		err := m.UnmarshalJSONWithPolicy([]byte(`{"b":1}`),
+			orderedmap.DuplicateKeyError)
+		require.NoError(t, err)
+		assert.Equal(t, 1, m.GetOrDefault("b", 0))
+	})
+}
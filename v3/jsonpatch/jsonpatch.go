@@ -0,0 +1,266 @@
+// Package jsonpatch applies JSON Patch (RFC 6902) documents to trees of
+// *orderedmap.OrderedMap[string, any] objects and []any arrays, such as those
+// returned by orderedmap.DecodeJSON, keeping the order of object members
+// stable:
+//
+//   - add of a new member appends it to the end of the object, and add of an
+//     existing member replaces its value in place.
+//   - replace keeps the member in the same position.
+//   - move between two members of the same object renames the member in place
+//     (or, if the target exists, replaces its value in place).
+//
+// It also applies and creates JSON Merge Patch (RFC 7396) documents, where
+// existing members keep their position and new members are appended in the
+// order of the patch.
+package jsonpatch
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/elliotchance/orderedmap/v3"
+	"github.com/elliotchance/orderedmap/v3/internal/jsonvalue"
+	"github.com/elliotchance/orderedmap/v3/jsonpointer"
+)
+
+// ErrTestFailed is returned (wrapped) when a test operation does not match.
+var ErrTestFailed = errors.New("jsonpatch: test failed")
+
+// Operation is a single JSON Patch operation.
+type Operation struct {
+	// Op is one of "add", "remove", "replace", "move", "copy" or "test".
+	Op string
+
+	// Path is the JSON Pointer to the target of the operation.
+	Path string
+
+	// From is the JSON Pointer to the source of a move or copy.
+	From string
+
+	// Value is the value for add, replace and test.
+	Value any
+}
+
+// Patch is a sequence of operations that are applied in order.
+type Patch []Operation
+
+// DecodePatch parses a JSON Patch document. Object values are decoded as
+// *orderedmap.OrderedMap[string, any] so that they keep their order when they
+// are added to a document.
+func DecodePatch(data []byte) (Patch, error) {
+	v, err := orderedmap.DecodeJSON(data)
+	if err != nil {
+		return nil, err
+	}
+
+	items, ok := v.([]any)
+	if !ok {
+		return nil, errors.New("jsonpatch: patch must be an array")
+	}
+
+	patch := make(Patch, len(items))
+	for i, item := range items {
+		obj, ok := item.(*orderedmap.OrderedMap[string, any])
+		if !ok {
+			return nil, fmt.Errorf("jsonpatch: operation %d: must be an object", i)
+		}
+
+		op := &patch[i]
+		var missing string
+		op.Op, missing = stringMember(obj, "op", missing)
+		op.Path, missing = stringMember(obj, "path", missing)
+		switch op.Op {
+		case "move", "copy":
+			op.From, missing = stringMember(obj, "from", missing)
+		case "add", "replace", "test":
+			if !obj.Has("value") {
+				missing = "value"
+			}
+			op.Value = obj.GetOrDefault("value", nil)
+		}
+		if missing != "" {
+			return nil, fmt.Errorf("jsonpatch: operation %d: missing or invalid %q", i, missing)
+		}
+	}
+
+	return patch, nil
+}
+
+// stringMember returns the string member for key. If it is missing (and no
+// other member was missing) key is returned as the new missing member.
+func stringMember(obj *orderedmap.OrderedMap[string, any], key, missing string) (string, string) {
+	s, ok := obj.GetOrDefault(key, nil).(string)
+	if !ok && missing == "" {
+		missing = key
+	}
+
+	return s, missing
+}
+
+// ApplyPatch applies patch to doc and returns the new document. doc is not
+// modified; the patch is applied to a deep copy of it, so if any operation
+// fails the error is returned and the original document is unchanged.
+func ApplyPatch(doc any, patch Patch) (any, error) {
+	doc = deepCopy(doc)
+	for i, op := range patch {
+		var err error
+		doc, err = apply(doc, op)
+		if err != nil {
+			return nil, fmt.Errorf("jsonpatch: operation %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+
+	return doc, nil
+}
+
+func apply(doc any, op Operation) (any, error) {
+	path, err := jsonpointer.Parse(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add":
+		return add(doc, path, deepCopy(op.Value))
+
+	case "remove":
+		return path.Delete(doc)
+
+	case "replace":
+		return replace(doc, path, deepCopy(op.Value))
+
+	case "move":
+		from, err := jsonpointer.Parse(op.From)
+		if err != nil {
+			return nil, err
+		}
+		return move(doc, from, path)
+
+	case "copy":
+		from, err := jsonpointer.Parse(op.From)
+		if err != nil {
+			return nil, err
+		}
+		value, err := from.Get(doc)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, deepCopy(value))
+
+	case "test":
+		value, err := path.Get(doc)
+		if err != nil {
+			return nil, err
+		}
+		if !Equal(value, op.Value) {
+			return nil, ErrTestFailed
+		}
+		return doc, nil
+	}
+
+	return nil, fmt.Errorf("unknown operation %q", op.Op)
+}
+
+func add(doc any, path jsonpointer.Pointer, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	parent, token := path.Parent()
+	return parent.Update(doc, func(container any) (any, error) {
+		switch container := container.(type) {
+		case *orderedmap.OrderedMap[string, any]:
+			container.Set(token, value)
+			return container, nil
+
+		case []any:
+			i, err := jsonpointer.Index(token, len(container))
+			if err != nil {
+				return nil, err
+			}
+			return slices.Insert(container, i, value), nil
+		}
+
+		return nil, fmt.Errorf("cannot add to %T", container)
+	})
+}
+
+func replace(doc any, path jsonpointer.Pointer, value any) (any, error) {
+	if _, err := path.Get(doc); err != nil {
+		return nil, err
+	}
+
+	return path.Set(doc, value)
+}
+
+func move(doc any, from, path jsonpointer.Pointer) (any, error) {
+	value, err := from.Get(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	if from.IsPrefixOf(path) {
+		if len(from) == len(path) {
+			return doc, nil
+		}
+		return nil, errors.New("cannot move a value into itself")
+	}
+
+	// Renaming a member of an object keeps its position.
+	fromParent, fromKey := from.Parent()
+	if len(path) > 0 && slices.Equal(fromParent, path[:len(path)-1]) {
+		container, _ := fromParent.Get(doc)
+		if m, ok := container.(*orderedmap.OrderedMap[string, any]); ok {
+			_, toKey := path.Parent()
+			if !m.ReplaceKey(fromKey, toKey) {
+				m.Delete(fromKey)
+				m.Set(toKey, value)
+			}
+			return doc, nil
+		}
+	}
+
+	doc, err = from.Delete(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return add(doc, path, value)
+}
+
+// deepCopy copies the ordered maps and slices in v so that changing the copy
+// does not change v.
+func deepCopy(v any) any {
+	switch v := v.(type) {
+	case *orderedmap.OrderedMap[string, any]:
+		if v == nil {
+			return v
+		}
+		m := orderedmap.NewOrderedMapWithCapacity[string, any](v.Len())
+		for key, value := range v.AllFromFront() {
+			m.Set(key, deepCopy(value))
+		}
+		return m
+
+	case []any:
+		if v == nil {
+			return v
+		}
+		values := make([]any, len(v))
+		for i, value := range v {
+			values[i] = deepCopy(value)
+		}
+		return values
+	}
+
+	return v
+}
+
+// Equal reports whether two JSON values are equal, using the rules of the
+// test operation: objects are equal if they have the same members regardless
+// of their order, arrays are equal if they have equal elements in the same
+// order, and numbers are compared by their value whatever their Go type.
+func Equal(a, b any) bool {
+	return jsonvalue.Equal(a, b)
+}
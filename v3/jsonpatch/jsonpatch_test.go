@@ -0,0 +1,180 @@
+package jsonpatch_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/elliotchance/orderedmap/v3"
+	"github.com/elliotchance/orderedmap/v3/jsonpatch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// applyJSON applies a patch to a document, both given as JSON, and returns the
+// result as JSON so that the order of keys can be checked.
+func applyJSON(t *testing.T, doc, patch string) (string, error) {
+	t.Helper()
+
+	d, err := orderedmap.DecodeJSON([]byte(doc))
+	require.NoError(t, err)
+	p, err := jsonpatch.DecodePatch([]byte(patch))
+	require.NoError(t, err)
+
+	result, err := jsonpatch.ApplyPatch(d, p)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(result)
+	require.NoError(t, err)
+
+	return string(data), nil
+}
+
+func TestApplyPatch(t *testing.T) {
+	for _, test := range []struct {
+		name, doc, patch, expected string
+	}{
+		{
+			"AddAppends",
+			`{"b":1,"a":2}`,
+			`[{"op":"add","path":"/c","value":{"z":1,"y":2}}]`,
+			`{"b":1,"a":2,"c":{"z":1,"y":2}}`,
+		},
+		{
+			"AddExistingKeepsPosition",
+			`{"b":1,"a":2}`,
+			`[{"op":"add","path":"/b","value":3}]`,
+			`{"b":3,"a":2}`,
+		},
+		{
+			"AddToArray",
+			`{"foo":["bar","baz"]}`,
+			`[{"op":"add","path":"/foo/1","value":"qux"},{"op":"add","path":"/foo/-","value":"end"}]`,
+			`{"foo":["bar","qux","baz","end"]}`,
+		},
+		{
+			"AddNested",
+			`{"a":{"b":[{"c":1}]}}`,
+			`[{"op":"add","path":"/a/b/0/d","value":2}]`,
+			`{"a":{"b":[{"c":1,"d":2}]}}`,
+		},
+		{
+			"Remove",
+			`{"a":1,"b":[1,2,3],"c":3}`,
+			`[{"op":"remove","path":"/a"},{"op":"remove","path":"/b/1"}]`,
+			`{"b":[1,3],"c":3}`,
+		},
+		{
+			"ReplaceKeepsPosition",
+			`{"a":1,"b":2,"c":3}`,
+			`[{"op":"replace","path":"/b","value":"x"},{"op":"replace","path":"/a","value":[0]}]`,
+			`{"a":[0],"b":"x","c":3}`,
+		},
+		{
+			"MoveRenamesInPlace",
+			`{"a":1,"b":2,"c":3}`,
+			`[{"op":"move","from":"/b","path":"/z"}]`,
+			`{"a":1,"z":2,"c":3}`,
+		},
+		{
+			"MoveOntoExisting",
+			`{"a":1,"b":2,"c":3}`,
+			`[{"op":"move","from":"/a","path":"/c"}]`,
+			`{"b":2,"c":1}`,
+		},
+		{
+			"MoveBetweenObjects",
+			`{"a":{"x":1,"y":2},"b":{"z":3}}`,
+			`[{"op":"move","from":"/a/x","path":"/b/x"}]`,
+			`{"a":{"y":2},"b":{"z":3,"x":1}}`,
+		},
+		{
+			"MoveArrayElement",
+			`{"foo":["all","grass","cows","eat"]}`,
+			`[{"op":"move","from":"/foo/1","path":"/foo/3"}]`,
+			`{"foo":["all","cows","eat","grass"]}`,
+		},
+		{
+			"Copy",
+			`{"a":{"x":1},"b":2}`,
+			`[{"op":"copy","from":"/a","path":"/c"},{"op":"add","path":"/c/y","value":2}]`,
+			`{"a":{"x":1},"b":2,"c":{"x":1,"y":2}}`,
+		},
+		{
+			"TestPasses",
+			`{"a":{"x":1,"y":[1,2]}}`,
+			`[{"op":"test","path":"/a","value":{"y":[1,2],"x":1.0}}]`,
+			`{"a":{"x":1,"y":[1,2]}}`,
+		},
+		{
+			"ReplaceRoot",
+			`{"a":1}`,
+			`[{"op":"replace","path":"","value":[1]}]`,
+			`[1]`,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := applyJSON(t, test.doc, test.patch)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, result)
+		})
+	}
+}
+
+func TestApplyPatch_Errors(t *testing.T) {
+	for _, test := range []struct {
+		name, doc, patch string
+	}{
+		{"RemoveMissing", `{"a":1}`, `[{"op":"remove","path":"/b"}]`},
+		{"ReplaceMissing", `{"a":1}`, `[{"op":"replace","path":"/b","value":1}]`},
+		{"AddMissingParent", `{"a":1}`, `[{"op":"add","path":"/b/c","value":1}]`},
+		{"AddOutOfBounds", `{"a":[1]}`, `[{"op":"add","path":"/a/2","value":1}]`},
+		{"MoveIntoChild", `{"a":{"b":1}}`, `[{"op":"move","from":"/a","path":"/a/b"}]`},
+		{"Unknown", `{"a":1}`, `[{"op":"frob","path":"/a"}]`},
+		{"RemoveRoot", `{"a":1}`, `[{"op":"remove","path":""}]`},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := applyJSON(t, test.doc, test.patch)
+			assert.Error(t, err)
+		})
+	}
+
+	t.Run("TestFails", func(t *testing.T) {
+		_, err := applyJSON(t, `{"a":[1,2]}`, `[{"op":"test","path":"/a","value":[2,1]}]`)
+		assert.ErrorIs(t, err, jsonpatch.ErrTestFailed)
+	})
+
+	t.Run("DocumentUnchanged", func(t *testing.T) {
+		doc := orderedmap.NewOrderedMap[string, any]()
+		doc.Set("a", 1)
+		_, err := jsonpatch.ApplyPatch(doc, jsonpatch.Patch{
+			{Op: "add", Path: "/b", Value: 2},
+			{Op: "remove", Path: "/missing"},
+		})
+		assert.Error(t, err)
+		assert.Equal(t, 1, doc.Len())
+	})
+}
+
+func TestDecodePatch(t *testing.T) {
+	t.Run("NullValue", func(t *testing.T) {
+		p, err := jsonpatch.DecodePatch([]byte(`[{"op":"add","path":"/a","value":null}]`))
+		require.NoError(t, err)
+		assert.Equal(t, jsonpatch.Patch{{Op: "add", Path: "/a"}}, p)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		for _, patch := range []string{
+			`{}`,
+			`[1]`,
+			`[{"path":"/a"}]`,
+			`[{"op":"add","path":"/a"}]`,
+			`[{"op":"move","path":"/a"}]`,
+			`[{"op":"remove","path":1}]`,
+		} {
+			_, err := jsonpatch.DecodePatch([]byte(patch))
+			assert.Error(t, err, patch)
+		}
+	})
+}
@@ -0,0 +1,202 @@
+package jsonpatch
+
+import (
+	"slices"
+
+	"github.com/elliotchance/orderedmap/v3"
+)
+
+// OrderKey is the member used by MergePatchWithOrder and
+// CreateMergePatchWithOrder to carry the order of the keys of an object. Its
+// value is an array with the keys in their new order.
+const OrderKey = "$order"
+
+// MergePatch applies a JSON Merge Patch (RFC 7396) to target and returns it.
+// target is changed in place, unless it is nil in which case a new map is
+// returned.
+//
+// Members that already exist in target keep their position, new members are
+// appended in the same order as they appear in patch, and members with a null
+// (nil) value in patch are deleted. Values from patch are copied, so later
+// changes to patch do not affect target.
+func MergePatch(target, patch *orderedmap.OrderedMap[string, any]) *orderedmap.OrderedMap[string, any] {
+	return mergePatch(target, patch, false)
+}
+
+// MergePatchWithOrder works like MergePatch, except that if an object in
+// patch has an OrderKey member (as created by CreateMergePatchWithOrder) the
+// members of the corresponding object in target are reordered to match it.
+// Keys that are not listed keep their relative order after the listed keys.
+func MergePatchWithOrder(target, patch *orderedmap.OrderedMap[string, any]) *orderedmap.OrderedMap[string, any] {
+	return mergePatch(target, patch, true)
+}
+
+func mergePatch(target, patch *orderedmap.OrderedMap[string, any], withOrder bool) *orderedmap.OrderedMap[string, any] {
+	if target == nil {
+		target = orderedmap.NewOrderedMap[string, any]()
+	}
+	if patch == nil {
+		return target
+	}
+
+	var order []any
+	for key, value := range patch.AllFromFront() {
+		if withOrder && key == OrderKey {
+			order, _ = value.([]any)
+			continue
+		}
+
+		if value == nil {
+			target.Delete(key)
+			continue
+		}
+
+		target.Set(key, mergeValue(target.GetOrDefault(key, nil), value, withOrder))
+	}
+
+	if order != nil {
+		reorder(target, order)
+	}
+
+	return target
+}
+
+// mergeValue is the recursive part of the algorithm in RFC 7396.
+func mergeValue(target, patch any, withOrder bool) any {
+	patchObject, ok := patch.(*orderedmap.OrderedMap[string, any])
+	if !ok {
+		return deepCopy(patch)
+	}
+
+	targetObject, _ := target.(*orderedmap.OrderedMap[string, any])
+
+	return mergePatch(targetObject, patchObject, withOrder)
+}
+
+// reorder moves the keys listed in order to the front of m, in that order.
+func reorder(m *orderedmap.OrderedMap[string, any], order []any) {
+	listed := map[string]bool{}
+	var rest []string
+	for _, key := range order {
+		if key, ok := key.(string); ok {
+			listed[key] = true
+		}
+	}
+	for key := range m.Keys() {
+		if !listed[key] {
+			rest = append(rest, key)
+		}
+	}
+
+	moveToBack := func(key string) {
+		if value, ok := m.Get(key); ok {
+			m.Delete(key)
+			m.Set(key, value)
+		}
+	}
+	for _, key := range order {
+		if key, ok := key.(string); ok {
+			moveToBack(key)
+		}
+	}
+	for _, key := range rest {
+		moveToBack(key)
+	}
+}
+
+// CreateMergePatch returns the JSON Merge Patch (RFC 7396) that turns a into
+// b when it is applied with MergePatch. Members that are removed come first
+// (with a null value) followed by the members that are added or changed, in
+// the same order as b.
+//
+// A merge patch cannot set a value to null, so null values in b are treated as
+// if the member did not exist. Neither can it change the order of keys, which
+// is what CreateMergePatchWithOrder is for.
+func CreateMergePatch(a, b *orderedmap.OrderedMap[string, any]) *orderedmap.OrderedMap[string, any] {
+	return createMergePatch(a, b, false)
+}
+
+// CreateMergePatchWithOrder works like CreateMergePatch, except that for each
+// object where applying the patch would not produce the same order of keys as
+// b, an OrderKey member listing the keys of b is added. The patch must be
+// applied with MergePatchWithOrder.
+func CreateMergePatchWithOrder(a, b *orderedmap.OrderedMap[string, any]) *orderedmap.OrderedMap[string, any] {
+	return createMergePatch(a, b, true)
+}
+
+func createMergePatch(a, b *orderedmap.OrderedMap[string, any], withOrder bool) *orderedmap.OrderedMap[string, any] {
+	if a == nil {
+		a = orderedmap.NewOrderedMap[string, any]()
+	}
+	if b == nil {
+		b = orderedmap.NewOrderedMap[string, any]()
+	}
+
+	patch := orderedmap.NewOrderedMap[string, any]()
+	for key := range a.Keys() {
+		if other, ok := b.Get(key); !ok || other == nil {
+			patch.Set(key, nil)
+		}
+	}
+
+	for key, value := range b.AllFromFront() {
+		if value == nil {
+			continue
+		}
+
+		old, ok := a.Get(key)
+		oldObject, oldIsObject := old.(*orderedmap.OrderedMap[string, any])
+		newObject, newIsObject := value.(*orderedmap.OrderedMap[string, any])
+		switch {
+		case ok && oldIsObject && newIsObject:
+			if child := createMergePatch(oldObject, newObject, withOrder); child.Len() > 0 {
+				patch.Set(key, child)
+			}
+
+		case !ok || !Equal(old, value):
+			patch.Set(key, deepCopy(value))
+		}
+	}
+
+	if withOrder {
+		if order := mergedOrder(a, b, patch); order != nil {
+			patch.Set(OrderKey, order)
+		}
+	}
+
+	return patch
+}
+
+// mergedOrder returns the keys of b if they would not already be in that order
+// after applying patch to a, otherwise nil.
+func mergedOrder(a, b, patch *orderedmap.OrderedMap[string, any]) []any {
+	var merged []string
+	for key := range a.Keys() {
+		if value, ok := patch.Get(key); !ok || value != nil {
+			merged = append(merged, key)
+		}
+	}
+	for key, value := range patch.AllFromFront() {
+		if value != nil && !a.Has(key) {
+			merged = append(merged, key)
+		}
+	}
+
+	var want []string
+	for key, value := range b.AllFromFront() {
+		if value != nil {
+			want = append(want, key)
+		}
+	}
+
+	if slices.Equal(merged, want) {
+		return nil
+	}
+
+	order := make([]any, len(want))
+	for i, key := range want {
+		order[i] = key
+	}
+
+	return order
+}
@@ -0,0 +1,133 @@
+package jsonpatch_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/elliotchance/orderedmap/v3"
+	"github.com/elliotchance/orderedmap/v3/jsonpatch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decodeObject(t *testing.T, data string) *orderedmap.OrderedMap[string, any] {
+	t.Helper()
+
+	m := orderedmap.NewOrderedMap[string, any]()
+	require.NoError(t, json.Unmarshal([]byte(data), m))
+
+	return m
+}
+
+func encodeObject(t *testing.T, m *orderedmap.OrderedMap[string, any]) string {
+	t.Helper()
+
+	data, err := json.Marshal(m)
+	require.NoError(t, err)
+
+	return string(data)
+}
+
+func TestMergePatch(t *testing.T) {
+	for _, test := range []struct {
+		name, target, patch, expected string
+	}{
+		// The object cases from RFC 7396, Appendix A.
+		{"Replace", `{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{"Add", `{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{"Delete", `{"a":"b"}`, `{"a":null}`, `{}`},
+		{"DeleteOne", `{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{"ArrayReplaced", `{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{"ValueReplacedByArray", `{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{"Nested", `{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{"ArrayOfObjects", `{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{"NullInArray", `{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{"NewObjectDropsNulls", `{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+
+		{
+			"KeepsPositionAndAppendsInPatchOrder",
+			`{"z":1,"y":{"q":1,"p":2},"x":3}`,
+			`{"n":4,"y":{"p":5,"o":6},"m":7,"z":8}`,
+			`{"z":8,"y":{"q":1,"p":5,"o":6},"x":3,"n":4,"m":7}`,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			target := decodeObject(t, test.target)
+			result := jsonpatch.MergePatch(target, decodeObject(t, test.patch))
+			assert.Same(t, target, result)
+			assert.Equal(t, test.expected, encodeObject(t, result))
+		})
+	}
+
+	t.Run("NilTarget", func(t *testing.T) {
+		result := jsonpatch.MergePatch(nil, decodeObject(t, `{"a":1,"b":null}`))
+		assert.Equal(t, `{"a":1}`, encodeObject(t, result))
+	})
+
+	t.Run("CopiesValues", func(t *testing.T) {
+		patch := decodeObject(t, `{"a":{"b":[1]}}`)
+		result := jsonpatch.MergePatch(nil, patch)
+		patch.GetOrDefault("a", nil).(*orderedmap.OrderedMap[string, any]).Set("c", 2)
+		assert.Equal(t, `{"a":{"b":[1]}}`, encodeObject(t, result))
+	})
+}
+
+func TestCreateMergePatch(t *testing.T) {
+	for _, test := range []struct {
+		name, a, b, expected string
+	}{
+		{"Same", `{"a":1,"b":{"c":2}}`, `{"b":{"c":2.0},"a":1}`, `{}`},
+		{"Changes", `{"a":1,"b":2,"c":3}`, `{"c":4,"a":1,"d":5}`, `{"b":null,"c":4,"d":5}`},
+		{"Nested", `{"a":{"b":1,"c":2}}`, `{"a":{"b":1,"c":3}}`, `{"a":{"c":3}}`},
+		{"ObjectReplacesValue", `{"a":1}`, `{"a":{"b":2}}`, `{"a":{"b":2}}`},
+		{"NullsAreMissing", `{"a":1,"b":null}`, `{"a":null,"c":null}`, `{"a":null,"b":null}`},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			a, b := decodeObject(t, test.a), decodeObject(t, test.b)
+			patch := jsonpatch.CreateMergePatch(a, b)
+			assert.Equal(t, test.expected, encodeObject(t, patch))
+
+			result := jsonpatch.MergePatch(a, patch)
+			assert.True(t, jsonpatch.Equal(
+				jsonpatch.MergePatch(nil, b), result), encodeObject(t, result))
+		})
+	}
+}
+
+func TestCreateMergePatchWithOrder(t *testing.T) {
+	for _, test := range []struct {
+		name, a, b, expected string
+	}{
+		{"SameOrder", `{"a":1,"b":2}`, `{"a":1,"b":3,"c":4}`, `{"b":3,"c":4}`},
+		{"Reordered", `{"a":1,"b":2}`, `{"b":2,"a":1}`, `{"$order":["b","a"]}`},
+		{
+			"NestedReorderAndChange",
+			`{"x":{"a":1,"b":2,"c":3},"y":1}`,
+			`{"x":{"c":3,"d":4,"a":1},"y":1}`,
+			`{"x":{"b":null,"d":4,"$order":["c","d","a"]}}`,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			a, b := decodeObject(t, test.a), decodeObject(t, test.b)
+			patch := jsonpatch.CreateMergePatchWithOrder(a, b)
+			assert.Equal(t, test.expected, encodeObject(t, patch))
+
+			result := jsonpatch.MergePatchWithOrder(a, patch)
+			assert.Equal(t, encodeObject(t, b), encodeObject(t, result))
+		})
+	}
+
+	t.Run("UnlistedKeysFollow", func(t *testing.T) {
+		result := jsonpatch.MergePatchWithOrder(
+			decodeObject(t, `{"a":1,"b":2,"c":3}`),
+			decodeObject(t, `{"$order":["c","missing","a"]}`))
+		assert.Equal(t, `{"c":3,"a":1,"b":2}`, encodeObject(t, result))
+	})
+
+	t.Run("OrderKeyIsDataWithoutExtension", func(t *testing.T) {
+		result := jsonpatch.MergePatch(
+			decodeObject(t, `{"a":1,"b":2}`),
+			decodeObject(t, `{"$order":["b","a"]}`))
+		assert.Equal(t, `{"a":1,"b":2,"$order":["b","a"]}`, encodeObject(t, result))
+	})
+}
@@ -0,0 +1,426 @@
+package jsonpath
+
+import (
+	"regexp"
+	"unicode/utf8"
+
+	"github.com/elliotchance/orderedmap/v3"
+	"github.com/elliotchance/orderedmap/v3/internal/jsonvalue"
+)
+
+type segment struct {
+	descendant bool
+	selectors  []selector
+}
+
+// selector appends the nodes it selects from n to out. root is needed for
+// filters that refer to $.
+type selector interface {
+	apply(n *node, root any, out []*node) []*node
+}
+
+type nameSelector string
+
+type wildcardSelector struct{}
+
+type indexSelector int
+
+type sliceSelector struct {
+	start, end       int
+	hasStart, hasEnd bool
+	step             int
+}
+
+type filterSelector struct {
+	expr logicalExpr
+}
+
+// evalSegments returns the nodes selected by segs, starting from n.
+func evalSegments(n *node, segs []segment, root any) []*node {
+	nodes := []*node{n}
+	for _, seg := range segs {
+		var out []*node
+		for _, n := range nodes {
+			if seg.descendant {
+				out = seg.applyDescendants(n, root, out)
+				continue
+			}
+			for _, sel := range seg.selectors {
+				out = sel.apply(n, root, out)
+			}
+		}
+		nodes = out
+	}
+
+	return nodes
+}
+
+// applyDescendants applies the selectors to n and then to each of its
+// descendants, depth first.
+func (seg segment) applyDescendants(n *node, root any, out []*node) []*node {
+	for _, sel := range seg.selectors {
+		out = sel.apply(n, root, out)
+	}
+	eachChild(n, func(child *node) {
+		out = seg.applyDescendants(child, root, out)
+	})
+
+	return out
+}
+
+// eachChild calls fn for each member of an object or element of an array, in
+// order.
+func eachChild(n *node, fn func(child *node)) {
+	switch v := n.value.(type) {
+	case *orderedmap.OrderedMap[string, any]:
+		if v == nil {
+			return
+		}
+		for el := v.Front(); el != nil; el = el.Next() {
+			fn(&node{value: el.Value, parent: n, name: el.Key, index: -1})
+		}
+
+	case []any:
+		for i, value := range v {
+			fn(&node{value: value, parent: n, index: i})
+		}
+	}
+}
+
+func (s nameSelector) apply(n *node, _ any, out []*node) []*node {
+	if m, ok := n.value.(*orderedmap.OrderedMap[string, any]); ok && m != nil {
+		if value, ok := m.Get(string(s)); ok {
+			out = append(out, &node{value: value, parent: n, name: string(s), index: -1})
+		}
+	}
+
+	return out
+}
+
+func (wildcardSelector) apply(n *node, _ any, out []*node) []*node {
+	eachChild(n, func(child *node) {
+		out = append(out, child)
+	})
+
+	return out
+}
+
+func (s indexSelector) apply(n *node, _ any, out []*node) []*node {
+	values, ok := n.value.([]any)
+	if !ok {
+		return out
+	}
+
+	i := int(s)
+	if i < 0 {
+		i += len(values)
+	}
+	if i >= 0 && i < len(values) {
+		out = append(out, &node{value: values[i], parent: n, index: i})
+	}
+
+	return out
+}
+
+func (s sliceSelector) apply(n *node, _ any, out []*node) []*node {
+	values, ok := n.value.([]any)
+	if !ok || s.step == 0 {
+		return out
+	}
+
+	length := len(values)
+	normalize := func(i int) int {
+		if i < 0 {
+			return length + i
+		}
+		return i
+	}
+
+	if s.step > 0 {
+		lower, upper := 0, length
+		if s.hasStart {
+			lower = min(max(normalize(s.start), 0), length)
+		}
+		if s.hasEnd {
+			upper = min(max(normalize(s.end), 0), length)
+		}
+		for i := lower; i < upper; i += s.step {
+			out = append(out, &node{value: values[i], parent: n, index: i})
+		}
+
+		return out
+	}
+
+	upper, lower := length-1, -1
+	if s.hasStart {
+		upper = min(max(normalize(s.start), -1), length-1)
+	}
+	if s.hasEnd {
+		lower = min(max(normalize(s.end), -1), length-1)
+	}
+	for i := upper; lower < i; i += s.step {
+		out = append(out, &node{value: values[i], parent: n, index: i})
+	}
+
+	return out
+}
+
+func (s filterSelector) apply(n *node, root any, out []*node) []*node {
+	eachChild(n, func(child *node) {
+		if s.expr.test(child.value, root) {
+			out = append(out, child)
+		}
+	})
+
+	return out
+}
+
+// logicalExpr is a filter expression.
+type logicalExpr interface {
+	test(current, root any) bool
+}
+
+type orExpr []logicalExpr
+
+type andExpr []logicalExpr
+
+type notExpr struct {
+	expr logicalExpr
+}
+
+// existsExpr is true if the query selects at least one node.
+type existsExpr struct {
+	query *filterQuery
+}
+
+// funcTestExpr is a function that returns a logical value.
+type funcTestExpr struct {
+	fn *funcExpr
+}
+
+type compareExpr struct {
+	op          string
+	left, right operand
+}
+
+func (e orExpr) test(current, root any) bool {
+	for _, expr := range e {
+		if expr.test(current, root) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (e andExpr) test(current, root any) bool {
+	for _, expr := range e {
+		if !expr.test(current, root) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (e notExpr) test(current, root any) bool {
+	return !e.expr.test(current, root)
+}
+
+func (e existsExpr) test(current, root any) bool {
+	return len(e.query.nodes(current, root)) > 0
+}
+
+func (e funcTestExpr) test(current, root any) bool {
+	v, _ := e.fn.value(current, root)
+	b, _ := v.(bool)
+
+	return b
+}
+
+func (e compareExpr) test(current, root any) bool {
+	a, aok := e.left.value(current, root)
+	b, bok := e.right.value(current, root)
+
+	switch e.op {
+	case "==":
+		return equal(a, aok, b, bok)
+	case "!=":
+		return !equal(a, aok, b, bok)
+	case "<":
+		return aok && bok && less(a, b)
+	case "<=":
+		return (aok && bok && less(a, b)) || equal(a, aok, b, bok)
+	case ">":
+		return aok && bok && less(b, a)
+	case ">=":
+		return (aok && bok && less(b, a)) || equal(a, aok, b, bok)
+	}
+
+	return false
+}
+
+// operand is something that produces a value for a comparison or function
+// argument. The second result is false if there is no value (Nothing).
+type operand interface {
+	value(current, root any) (any, bool)
+}
+
+type literal struct {
+	v any
+}
+
+// filterQuery is a query inside a filter, relative to either the current node
+// (@) or the root ($).
+type filterQuery struct {
+	relative bool
+	segs     []segment
+}
+
+func (l literal) value(_, _ any) (any, bool) {
+	return l.v, true
+}
+
+func (q *filterQuery) nodes(current, root any) []*node {
+	if q.relative {
+		return evalSegments(&node{value: current, index: -1}, q.segs, root)
+	}
+
+	return evalSegments(&node{value: root, index: -1}, q.segs, root)
+}
+
+// value returns the value of a singular query.
+func (q *filterQuery) value(current, root any) (any, bool) {
+	nodes := q.nodes(current, root)
+	if len(nodes) != 1 {
+		return nil, false
+	}
+
+	return nodes[0].value, true
+}
+
+// singular reports whether the query can select at most one node.
+func (q *filterQuery) singular() bool {
+	for _, seg := range q.segs {
+		if seg.descendant || len(seg.selectors) != 1 {
+			return false
+		}
+		switch seg.selectors[0].(type) {
+		case nameSelector, indexSelector:
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+type exprType int
+
+const (
+	valueType exprType = iota
+	logicalType
+	nodesType
+)
+
+type function struct {
+	params []exprType
+	result exprType
+}
+
+// functions are the function extensions defined by RFC 9535.
+var functions = map[string]function{
+	"length": {[]exprType{valueType}, valueType},
+	"count":  {[]exprType{nodesType}, valueType},
+	"match":  {[]exprType{valueType, valueType}, logicalType},
+	"search": {[]exprType{valueType, valueType}, logicalType},
+	"value":  {[]exprType{nodesType}, valueType},
+}
+
+type funcExpr struct {
+	name string
+	fn   function
+	args []any
+
+	// pattern is compiled when parsing if the pattern of match or search is
+	// a literal.
+	pattern    *regexp.Regexp
+	patternErr error
+}
+
+func (f *funcExpr) value(current, root any) (any, bool) {
+	switch f.name {
+	case "length":
+		v, ok := f.args[0].(operand).value(current, root)
+		if !ok {
+			return nil, false
+		}
+		switch v := v.(type) {
+		case string:
+			return float64(utf8.RuneCountInString(v)), true
+		case []any:
+			return float64(len(v)), true
+		case *orderedmap.OrderedMap[string, any]:
+			if v != nil {
+				return float64(v.Len()), true
+			}
+		}
+		return nil, false
+
+	case "count":
+		return float64(len(f.args[0].(*filterQuery).nodes(current, root))), true
+
+	case "value":
+		nodes := f.args[0].(*filterQuery).nodes(current, root)
+		if len(nodes) != 1 {
+			return nil, false
+		}
+		return nodes[0].value, true
+
+	case "match", "search":
+		v, _ := f.args[0].(operand).value(current, root)
+		s, ok := v.(string)
+		if !ok {
+			return false, true
+		}
+
+		re, err := f.pattern, f.patternErr
+		if re == nil && err == nil {
+			v, _ := f.args[1].(operand).value(current, root)
+			pattern, ok := v.(string)
+			if !ok {
+				return false, true
+			}
+			re, err = compilePattern(pattern, f.name == "match")
+		}
+		if err != nil {
+			return false, true
+		}
+
+		return re.MatchString(s), true
+	}
+
+	return nil, false
+}
+
+// equal compares two values, either of which may be Nothing.
+func equal(a any, aok bool, b any, bok bool) bool {
+	if !aok || !bok {
+		return aok == bok
+	}
+
+	return jsonvalue.Equal(a, b)
+}
+
+// less is only true for two numbers or two strings.
+func less(a, b any) bool {
+	if x, ok := jsonvalue.Number(a); ok {
+		y, ok := jsonvalue.Number(b)
+		return ok && x < y
+	}
+
+	x, ok := a.(string)
+	y, ok2 := b.(string)
+
+	return ok && ok2 && x < y
+}
@@ -0,0 +1,153 @@
+// Package jsonpath evaluates JSONPath (RFC 9535) queries against documents
+// made of *orderedmap.OrderedMap[string, any] objects and []any arrays, such
+// as those returned by orderedmap.DecodeJSON.
+//
+// All of the RFC is supported: name, index, wildcard, slice and filter
+// selectors, descendant segments, and the length, count, match, search and
+// value functions. Results are returned in document order, which for objects
+// is the order of the ordered maps (front to back). Descendants are visited
+// before their following siblings, and each node before its own children.
+//
+// Numbers in documents can be any Go integer or float type, or json.Number.
+package jsonpath
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Path is a compiled JSONPath query. It is safe for concurrent use.
+type Path struct {
+	query string
+	segs  []segment
+}
+
+// Node is a value selected by a query along with its location.
+type Node struct {
+	// Location is the normalized path of the value, such as $['a'][0].
+	Location string
+
+	// Value is the selected value.
+	Value any
+}
+
+// Compile parses a JSONPath query.
+func Compile(query string) (*Path, error) {
+	p := &parser{s: query}
+	segs, err := p.parseQuery()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Path{query: query, segs: segs}, nil
+}
+
+// MustCompile is like Compile but panics if the query cannot be parsed.
+func MustCompile(query string) *Path {
+	p, err := Compile(query)
+	if err != nil {
+		panic(err)
+	}
+
+	return p
+}
+
+// Query compiles query and returns the values it selects from doc.
+func Query(doc any, query string) ([]any, error) {
+	p, err := Compile(query)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.Query(doc), nil
+}
+
+// String returns the source of the query.
+func (p *Path) String() string {
+	return p.query
+}
+
+// Query returns the values selected from doc, in document order.
+func (p *Path) Query(doc any) []any {
+	nodes := evalSegments(&node{value: doc}, p.segs, doc)
+	values := make([]any, len(nodes))
+	for i, n := range nodes {
+		values[i] = n.value
+	}
+
+	return values
+}
+
+// Select returns the nodes selected from doc, in document order.
+func (p *Path) Select(doc any) []Node {
+	nodes := evalSegments(&node{value: doc}, p.segs, doc)
+	result := make([]Node, len(nodes))
+	for i, n := range nodes {
+		result[i] = Node{Location: n.location(), Value: n.value}
+	}
+
+	return result
+}
+
+// node is a value in the document along with enough information to build its
+// location if it is selected.
+type node struct {
+	value  any
+	parent *node
+	name   string
+	index  int
+}
+
+func (n *node) location() string {
+	var parts []*node
+	for ; n.parent != nil; n = n.parent {
+		parts = append(parts, n)
+	}
+
+	var b strings.Builder
+	b.WriteByte('$')
+	for i := len(parts) - 1; i >= 0; i-- {
+		b.WriteByte('[')
+		if parts[i].index >= 0 {
+			b.WriteString(strconv.Itoa(parts[i].index))
+		} else {
+			writeNormalizedName(&b, parts[i].name)
+		}
+		b.WriteByte(']')
+	}
+
+	return b.String()
+}
+
+// writeNormalizedName writes a member name as a single quoted string using the
+// escaping of normalized paths.
+func writeNormalizedName(b *strings.Builder, name string) {
+	b.WriteByte('\'')
+	for _, c := range name {
+		switch c {
+		case '\'':
+			b.WriteString(`\'`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if c < 0x20 {
+				b.WriteString(`\u00`)
+				b.WriteByte("0123456789abcdef"[c>>4])
+				b.WriteByte("0123456789abcdef"[c&0xf])
+			} else {
+				b.WriteRune(c)
+			}
+		}
+	}
+	b.WriteByte('\'')
+}
@@ -0,0 +1,254 @@
+package jsonpath_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/elliotchance/orderedmap/v3"
+	"github.com/elliotchance/orderedmap/v3/jsonpath"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decode(t *testing.T, s string) any {
+	t.Helper()
+	v, err := orderedmap.DecodeJSON([]byte(s))
+	require.NoError(t, err)
+
+	return v
+}
+
+func locations(nodes []jsonpath.Node) []string {
+	var result []string
+	for _, n := range nodes {
+		result = append(result, n.Location)
+	}
+
+	return result
+}
+
+// The example document from RFC 9535.
+const bookstore = `{ "store": {
+	"book": [
+		{ "category": "reference",
+			"author": "Nigel Rees",
+			"title": "Sayings of the Century",
+			"price": 8.95
+		},
+		{ "category": "fiction",
+			"author": "Evelyn Waugh",
+			"title": "Sword of Honour",
+			"price": 12.99
+		},
+		{ "category": "fiction",
+			"author": "Herman Melville",
+			"title": "Moby Dick",
+			"isbn": "0-553-21311-3",
+			"price": 8.99
+		},
+		{ "category": "fiction",
+			"author": "J. R. R. Tolkien",
+			"title": "The Lord of the Rings",
+			"isbn": "0-395-19395-8",
+			"price": 22.99
+		}
+	],
+	"bicycle": {
+		"color": "red",
+		"price": 399
+	}
+} }`
+
+func TestQuery(t *testing.T) {
+	doc := decode(t, bookstore)
+
+	for _, test := range []struct {
+		query string
+		want  []any
+	}{
+		{`$.store.book[*].author`, []any{"Nigel Rees", "Evelyn Waugh", "Herman Melville", "J. R. R. Tolkien"}},
+		{`$..author`, []any{"Nigel Rees", "Evelyn Waugh", "Herman Melville", "J. R. R. Tolkien"}},
+		{`$.store..price`, []any{8.95, 12.99, 8.99, 22.99, float64(399)}},
+		{`$..book[2].title`, []any{"Moby Dick"}},
+		{`$..book[-1].title`, []any{"The Lord of the Rings"}},
+		{`$..book[:2].title`, []any{"Sayings of the Century", "Sword of Honour"}},
+		{`$..book[0,1].title`, []any{"Sayings of the Century", "Sword of Honour"}},
+		{`$..book[::-2].title`, []any{"The Lord of the Rings", "Sword of Honour"}},
+		{`$..book[?@.isbn].title`, []any{"Moby Dick", "The Lord of the Rings"}},
+		{`$..book[?@.price<10].title`, []any{"Sayings of the Century", "Moby Dick"}},
+		{`$..book[?!@.isbn && @.price > 10].title`, []any{"Sword of Honour"}},
+		{`$.store.book[?@.price == 8.99 || @.author == 'Nigel Rees'].price`, []any{8.95, 8.99}},
+		{`$..book[?length(@.title) > 16].title`, []any{"Sayings of the Century", "The Lord of the Rings"}},
+		{`$..book[?match(@.author, 'H.*')].title`, []any{"Moby Dick"}},
+		{`$..book[?search(@.author, 'R\\.')].title`, []any{"The Lord of the Rings"}},
+		{`$.store[?count(@.*) == 2].color`, []any{"red"}},
+		{`$..book[?@.price > $.store.bicycle.price]`, []any{}},
+		{`$.store["bicycle"]['color']`, []any{"red"}},
+		{`$.missing`, []any{}},
+	} {
+		t.Run(test.query, func(t *testing.T) {
+			got, err := jsonpath.Query(doc, test.query)
+			require.NoError(t, err)
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+func TestPath_Select(t *testing.T) {
+	t.Run("DocumentOrder", func(t *testing.T) {
+		doc := decode(t, `{"z": {"b": 1, "a": 2}, "y": [3, {"b": 4}]}`)
+		nodes := jsonpath.MustCompile(`$..b`).Select(doc)
+		assert.Equal(t, []string{`$['z']['b']`, `$['y'][1]['b']`}, locations(nodes))
+		assert.Equal(t, float64(1), nodes[0].Value)
+		assert.Equal(t, float64(4), nodes[1].Value)
+	})
+
+	t.Run("Wildcard", func(t *testing.T) {
+		doc := decode(t, `{"c": 1, "a": 2, "b": 3}`)
+		nodes := jsonpath.MustCompile(`$.*`).Select(doc)
+		assert.Equal(t, []string{`$['c']`, `$['a']`, `$['b']`}, locations(nodes))
+	})
+
+	t.Run("NormalizedNames", func(t *testing.T) {
+		doc := decode(t, `{"it's": {"a\nb": 1}}`)
+		nodes := jsonpath.MustCompile(`$..*`).Select(doc)
+		assert.Equal(t, []string{`$['it\'s']`, `$['it\'s']['a\nb']`}, locations(nodes))
+	})
+
+	t.Run("Root", func(t *testing.T) {
+		nodes := jsonpath.MustCompile(`$`).Select(decode(t, `[1]`))
+		assert.Equal(t, []string{`$`}, locations(nodes))
+	})
+}
+
+func TestSlice(t *testing.T) {
+	doc := decode(t, `[0, 1, 2, 3, 4, 5, 6]`)
+
+	for _, test := range []struct {
+		query string
+		want  []any
+	}{
+		{`$[1:3]`, []any{float64(1), float64(2)}},
+		{`$[5:]`, []any{float64(5), float64(6)}},
+		{`$[1:5:2]`, []any{float64(1), float64(3)}},
+		{`$[5:1:-2]`, []any{float64(5), float64(3)}},
+		{`$[::-1]`, []any{float64(6), float64(5), float64(4), float64(3), float64(2), float64(1), float64(0)}},
+		{`$[-2:]`, []any{float64(5), float64(6)}},
+		{`$[-100:2]`, []any{float64(0), float64(1)}},
+		{`$[0:10:0]`, []any{}},
+	} {
+		t.Run(test.query, func(t *testing.T) {
+			got, err := jsonpath.Query(doc, test.query)
+			require.NoError(t, err)
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+func TestFilter(t *testing.T) {
+	t.Run("Comparisons", func(t *testing.T) {
+		doc := decode(t, `[{"a": 1}, {"a": "b"}, {"a": null}, {"a": [1]}, {"a": {"x": 1, "y": 2}}, {}]`)
+
+		for _, test := range []struct {
+			query string
+			want  []string
+		}{
+			{`$[?@.a == 1]`, []string{`$[0]`}},
+			{`$[?@.a == null]`, []string{`$[2]`}},
+			{`$[?@.a != 1]`, []string{`$[1]`, `$[2]`, `$[3]`, `$[4]`, `$[5]`}},
+			{`$[?@.a >= 'a']`, []string{`$[1]`}},
+			{`$[?@.a == $[4].a]`, []string{`$[4]`}},
+			{`$[?@.b == @.c]`, []string{`$[0]`, `$[1]`, `$[2]`, `$[3]`, `$[4]`, `$[5]`}},
+			{`$[?@.a <= @.b]`, []string{`$[5]`}},
+			{`$[?@.a < 2]`, []string{`$[0]`}},
+			{`$[?value(@..x) == 1]`, []string{`$[4]`}},
+			{`$[?length(@.a) == 1]`, []string{`$[1]`, `$[3]`}},
+			{`$[?(@.a)]`, []string{`$[0]`, `$[1]`, `$[2]`, `$[3]`, `$[4]`}},
+		} {
+			t.Run(test.query, func(t *testing.T) {
+				nodes := jsonpath.MustCompile(test.query).Select(doc)
+				assert.Equal(t, test.want, locations(nodes))
+			})
+		}
+	})
+
+	t.Run("ObjectEqualityIgnoresOrder", func(t *testing.T) {
+		doc := decode(t, `{"a": {"x": 1, "y": 2}, "b": [{"y": 2, "x": 1}]}`)
+		nodes := jsonpath.MustCompile(`$.b[?@ == $.a]`).Select(doc)
+		assert.Equal(t, []string{`$['b'][0]`}, locations(nodes))
+	})
+
+	t.Run("GoNumbers", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, any]()
+		m.Set("a", []any{1, int64(2), uint8(3), 4.5})
+		got, err := jsonpath.Query(m, `$.a[?@ > 1 && @ < 4]`)
+		require.NoError(t, err)
+		assert.Equal(t, []any{int64(2), uint8(3)}, got)
+	})
+
+	t.Run("MatchIsAnchored", func(t *testing.T) {
+		doc := decode(t, `["ab", "abc", "xab"]`)
+		got, err := jsonpath.Query(doc, `$[?match(@, 'ab')]`)
+		require.NoError(t, err)
+		assert.Equal(t, []any{"ab"}, got)
+
+		got, err = jsonpath.Query(doc, `$[?search(@, 'ab')]`)
+		require.NoError(t, err)
+		assert.Equal(t, []any{"ab", "abc", "xab"}, got)
+	})
+}
+
+func TestCompile(t *testing.T) {
+	t.Run("String", func(t *testing.T) {
+		assert.Equal(t, `$.a[0]`, jsonpath.MustCompile(`$.a[0]`).String())
+	})
+
+	t.Run("SyntaxErrors", func(t *testing.T) {
+		for _, query := range []string{
+			``,
+			`a`,
+			`$.`,
+			`$.1a`,
+			`$[`,
+			`$[01]`,
+			`$[-0]`,
+			`$['a`,
+			`$[9007199254740992]`,
+			`$[?@.a == ]`,
+			`$[?@.* == 1]`,
+			`$[?length(@.*) == 1]`,
+			`$[?count(1) == 1]`,
+			`$[?match(@.a, 'a') == true]`,
+			`$[?length(@.a)]`,
+			`$[?foo(@.a)]`,
+			`$[?!@.a == 1]`,
+			`$ `,
+		} {
+			_, err := jsonpath.Compile(query)
+			var syntaxErr *jsonpath.SyntaxError
+			assert.ErrorAs(t, err, &syntaxErr, query)
+		}
+	})
+
+	t.Run("MaxDepth", func(t *testing.T) {
+		_, err := jsonpath.Compile(`$[?` + strings.Repeat("(", 100) + "@.a" + strings.Repeat(")", 100) + "]")
+		require.NoError(t, err)
+
+		for _, query := range []string{
+			`$[?` + strings.Repeat("(", 1000000),
+			`$[?` + strings.Repeat(`@[?`, 1000000),
+			`$[?` + strings.Repeat("length(", 1000000),
+		} {
+			_, err := jsonpath.Compile(query)
+			var syntaxErr *jsonpath.SyntaxError
+			require.ErrorAs(t, err, &syntaxErr)
+			assert.Contains(t, syntaxErr.Message, "exceeded max depth")
+		}
+	})
+
+	t.Run("MustCompilePanics", func(t *testing.T) {
+		assert.Panics(t, func() {
+			jsonpath.MustCompile(`$[`)
+		})
+	})
+}
@@ -0,0 +1,773 @@
+package jsonpath
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// maxIndex is the largest index allowed by RFC 9535 (the largest integer that
+// can be represented exactly as an IEEE 754 double).
+const maxIndex = 1<<53 - 1
+
+// maxDepth is the deepest that expressions (parentheses, filters and function
+// calls) can be nested, so that a query cannot overflow the stack.
+const maxDepth = 1000
+
+// SyntaxError is returned when a query cannot be parsed.
+type SyntaxError struct {
+	// Offset is the byte offset in the query where the error was found.
+	Offset int
+
+	// Message describes the problem.
+	Message string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("jsonpath: %s at offset %d", e.Message, e.Offset)
+}
+
+type parser struct {
+	s     string
+	pos   int
+	depth int
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return &SyntaxError{Offset: p.pos, Message: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) peek() byte {
+	if p.pos < len(p.s) {
+		return p.s[p.pos]
+	}
+
+	return 0
+}
+
+func (p *parser) consume(prefix string) bool {
+	if strings.HasPrefix(p.s[p.pos:], prefix) {
+		p.pos += len(prefix)
+		return true
+	}
+
+	return false
+}
+
+func (p *parser) expect(prefix string) error {
+	if !p.consume(prefix) {
+		return p.errorf("expected %q", prefix)
+	}
+
+	return nil
+}
+
+// enter is called before parsing a nested expression, and leave after.
+func (p *parser) enter() error {
+	if p.depth >= maxDepth {
+		return p.errorf("exceeded max depth of %d", maxDepth)
+	}
+	p.depth++
+
+	return nil
+}
+
+func (p *parser) leave() {
+	p.depth--
+}
+
+func (p *parser) skipBlank() {
+	for p.pos < len(p.s) && strings.IndexByte(" \t\n\r", p.s[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+func (p *parser) parseQuery() ([]segment, error) {
+	if err := p.expect("$"); err != nil {
+		return nil, err
+	}
+
+	segs, err := p.parseSegments()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(p.s) {
+		return nil, p.errorf("unexpected %q", p.s[p.pos:])
+	}
+
+	return segs, nil
+}
+
+// parseSegments parses segments until something that cannot start a segment
+// is found.
+func (p *parser) parseSegments() ([]segment, error) {
+	var segs []segment
+	for {
+		start := p.pos
+		p.skipBlank()
+		if c := p.peek(); c != '.' && c != '[' {
+			p.pos = start
+			return segs, nil
+		}
+
+		seg, err := p.parseSegment()
+		if err != nil {
+			return nil, err
+		}
+		segs = append(segs, seg)
+	}
+}
+
+func (p *parser) parseSegment() (segment, error) {
+	var seg segment
+	if p.consume("..") {
+		seg.descendant = true
+		if p.peek() == '[' {
+			sels, err := p.parseBracketed()
+			seg.selectors = sels
+			return seg, err
+		}
+	} else if p.peek() == '[' {
+		sels, err := p.parseBracketed()
+		seg.selectors = sels
+		return seg, err
+	} else {
+		p.pos++ // .
+	}
+
+	if p.consume("*") {
+		seg.selectors = []selector{wildcardSelector{}}
+		return seg, nil
+	}
+
+	name, ok := p.parseMemberName()
+	if !ok {
+		return seg, p.errorf("expected a member name or *")
+	}
+	seg.selectors = []selector{nameSelector(name)}
+
+	return seg, nil
+}
+
+// parseMemberName parses a member-name-shorthand.
+func (p *parser) parseMemberName() (string, bool) {
+	start := p.pos
+	for p.pos < len(p.s) {
+		r, size := utf8.DecodeRuneInString(p.s[p.pos:])
+		first := r == '_' || r >= 0x80 || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z')
+		if !first && (p.pos == start || r < '0' || r > '9') {
+			break
+		}
+		p.pos += size
+	}
+
+	return p.s[start:p.pos], p.pos > start
+}
+
+func (p *parser) parseBracketed() ([]selector, error) {
+	p.pos++ // [
+	var sels []selector
+	for {
+		p.skipBlank()
+		sel, err := p.parseSelector()
+		if err != nil {
+			return nil, err
+		}
+		sels = append(sels, sel)
+
+		p.skipBlank()
+		if p.consume("]") {
+			return sels, nil
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *parser) parseSelector() (selector, error) {
+	switch c := p.peek(); {
+	case c == '\'' || c == '"':
+		s, err := p.parseString()
+		return nameSelector(s), err
+
+	case c == '*':
+		p.pos++
+		return wildcardSelector{}, nil
+
+	case c == '?':
+		p.pos++
+		p.skipBlank()
+		expr, err := p.parseLogicalOr()
+		return filterSelector{expr}, err
+
+	case c == ':' || c == '-' || ('0' <= c && c <= '9'):
+		return p.parseIndexOrSlice()
+	}
+
+	return nil, p.errorf("invalid selector")
+}
+
+func (p *parser) parseIndexOrSlice() (selector, error) {
+	var sel sliceSelector
+	var err error
+
+	p.skipBlank()
+	if p.peek() != ':' {
+		if sel.start, err = p.parseInt(); err != nil {
+			return nil, err
+		}
+		sel.hasStart = true
+
+		start := p.pos
+		p.skipBlank()
+		if p.peek() != ':' {
+			p.pos = start
+			return indexSelector(sel.start), nil
+		}
+	}
+	p.pos++ // :
+
+	p.skipBlank()
+	if c := p.peek(); c == '-' || ('0' <= c && c <= '9') {
+		if sel.end, err = p.parseInt(); err != nil {
+			return nil, err
+		}
+		sel.hasEnd = true
+		p.skipBlank()
+	}
+
+	sel.step = 1
+	if p.consume(":") {
+		p.skipBlank()
+		if c := p.peek(); c == '-' || ('0' <= c && c <= '9') {
+			if sel.step, err = p.parseInt(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return sel, nil
+}
+
+// parseInt parses an integer as used by index and slice selectors.
+func (p *parser) parseInt() (int, error) {
+	start := p.pos
+	p.consume("-")
+	digits := p.pos
+	for '0' <= p.peek() && p.peek() <= '9' {
+		p.pos++
+	}
+
+	s := p.s[start:p.pos]
+	switch {
+	case p.pos == digits:
+		return 0, p.errorf("expected an integer")
+	case p.s[digits] == '0' && p.pos-digits > 1, s == "-0":
+		return 0, p.errorf("invalid integer %q", s)
+	}
+
+	n, err := strconv.Atoi(s)
+	if err != nil || n > maxIndex || n < -maxIndex {
+		return 0, p.errorf("integer %s is out of range", s)
+	}
+
+	return n, nil
+}
+
+// parseString parses a single or double quoted string literal.
+func (p *parser) parseString() (string, error) {
+	quote := p.s[p.pos]
+	p.pos++
+
+	var b strings.Builder
+	for {
+		if p.pos >= len(p.s) {
+			return "", p.errorf("unterminated string")
+		}
+
+		c := p.s[p.pos]
+		switch {
+		case c == quote:
+			p.pos++
+			return b.String(), nil
+
+		case c < 0x20:
+			return "", p.errorf("invalid character in string")
+
+		case c == '\\':
+			p.pos++
+			r, err := p.parseEscape(quote)
+			if err != nil {
+				return "", err
+			}
+			b.WriteRune(r)
+
+		default:
+			r, size := utf8.DecodeRuneInString(p.s[p.pos:])
+			if r == utf8.RuneError && size == 1 {
+				return "", p.errorf("invalid UTF-8 in string")
+			}
+			b.WriteRune(r)
+			p.pos += size
+		}
+	}
+}
+
+func (p *parser) parseEscape(quote byte) (rune, error) {
+	c := p.peek()
+	p.pos++
+	switch c {
+	case 'b':
+		return '\b', nil
+	case 'f':
+		return '\f', nil
+	case 'n':
+		return '\n', nil
+	case 'r':
+		return '\r', nil
+	case 't':
+		return '\t', nil
+	case '/', '\\':
+		return rune(c), nil
+	case quote:
+		return rune(quote), nil
+	case 'u':
+		r, err := p.parseHex4()
+		if err != nil {
+			return 0, err
+		}
+		if utf16.IsSurrogate(r) {
+			if r >= 0xdc00 || !p.consume(`\u`) {
+				return 0, p.errorf("invalid surrogate pair")
+			}
+			low, err := p.parseHex4()
+			if err != nil {
+				return 0, err
+			}
+			if r = utf16.DecodeRune(r, low); r == utf8.RuneError {
+				return 0, p.errorf("invalid surrogate pair")
+			}
+		}
+		return r, nil
+	}
+
+	return 0, p.errorf("invalid escape sequence")
+}
+
+func (p *parser) parseHex4() (rune, error) {
+	if p.pos+4 > len(p.s) {
+		return 0, p.errorf("invalid unicode escape")
+	}
+	n, err := strconv.ParseUint(p.s[p.pos:p.pos+4], 16, 16)
+	if err != nil {
+		return 0, p.errorf("invalid unicode escape")
+	}
+	p.pos += 4
+
+	return rune(n), nil
+}
+
+func (p *parser) parseLogicalOr() (logicalExpr, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+
+	expr, err := p.parseLogicalAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	or := orExpr{expr}
+	for {
+		start := p.pos
+		p.skipBlank()
+		if !p.consume("||") {
+			p.pos = start
+			break
+		}
+		p.skipBlank()
+		expr, err := p.parseLogicalAnd()
+		if err != nil {
+			return nil, err
+		}
+		or = append(or, expr)
+	}
+
+	if len(or) == 1 {
+		return or[0], nil
+	}
+
+	return or, nil
+}
+
+func (p *parser) parseLogicalAnd() (logicalExpr, error) {
+	expr, err := p.parseBasic()
+	if err != nil {
+		return nil, err
+	}
+
+	and := andExpr{expr}
+	for {
+		start := p.pos
+		p.skipBlank()
+		if !p.consume("&&") {
+			p.pos = start
+			break
+		}
+		p.skipBlank()
+		expr, err := p.parseBasic()
+		if err != nil {
+			return nil, err
+		}
+		and = append(and, expr)
+	}
+
+	if len(and) == 1 {
+		return and[0], nil
+	}
+
+	return and, nil
+}
+
+// parseBasic parses a paren-expr, comparison-expr or test-expr.
+func (p *parser) parseBasic() (logicalExpr, error) {
+	if p.consume("!") {
+		p.skipBlank()
+		expr, err := p.parseNegatable()
+		return notExpr{expr}, err
+	}
+
+	if p.consume("(") {
+		return p.parseParen()
+	}
+
+	start := p.pos
+	left, err := p.parseComparable()
+	if err != nil {
+		return nil, err
+	}
+
+	p.skipBlank()
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if p.consume(op) {
+			p.skipBlank()
+			right, err := p.parseComparable()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.checkComparable(left, start); err != nil {
+				return nil, err
+			}
+			if err := p.checkComparable(right, start); err != nil {
+				return nil, err
+			}
+			return compareExpr{op: op, left: left, right: right}, nil
+		}
+	}
+
+	// Without an operator, this must be a test-expr.
+	switch left := left.(type) {
+	case *filterQuery:
+		return existsExpr{left}, nil
+	case *funcExpr:
+		if left.fn.result == logicalType {
+			return funcTestExpr{left}, nil
+		}
+	}
+
+	return nil, &SyntaxError{Offset: start, Message: "expected a comparison or test expression"}
+}
+
+// parseNegatable parses what can follow "!", which is a parenthesized
+// expression or a test-expr.
+func (p *parser) parseNegatable() (logicalExpr, error) {
+	if p.consume("(") {
+		return p.parseParen()
+	}
+
+	start := p.pos
+	v, err := p.parseComparable()
+	if err != nil {
+		return nil, err
+	}
+
+	switch v := v.(type) {
+	case *filterQuery:
+		return existsExpr{v}, nil
+	case *funcExpr:
+		if v.fn.result == logicalType {
+			return funcTestExpr{v}, nil
+		}
+	}
+
+	return nil, &SyntaxError{Offset: start, Message: "expected a test expression"}
+}
+
+func (p *parser) parseParen() (logicalExpr, error) {
+	p.skipBlank()
+	expr, err := p.parseLogicalOr()
+	if err != nil {
+		return nil, err
+	}
+	p.skipBlank()
+
+	return expr, p.expect(")")
+}
+
+// checkComparable checks that a value used in a comparison produces a single
+// value.
+func (p *parser) checkComparable(v operand, offset int) error {
+	switch v := v.(type) {
+	case *filterQuery:
+		if !v.singular() {
+			return &SyntaxError{Offset: offset, Message: "a query in a comparison must be singular"}
+		}
+	case *funcExpr:
+		if v.fn.result != valueType {
+			return &SyntaxError{Offset: offset, Message: fmt.Sprintf("%s() cannot be used in a comparison", v.name)}
+		}
+	}
+
+	return nil
+}
+
+// parseComparable parses a literal, a query or a function call.
+func (p *parser) parseComparable() (operand, error) {
+	switch c := p.peek(); {
+	case c == '@' || c == '$':
+		p.pos++
+		segs, err := p.parseSegments()
+		return &filterQuery{relative: c == '@', segs: segs}, err
+
+	case c == '\'' || c == '"':
+		s, err := p.parseString()
+		return literal{s}, err
+
+	case c == '-' || ('0' <= c && c <= '9'):
+		return p.parseNumber()
+
+	case 'a' <= c && c <= 'z':
+		start := p.pos
+		for c := p.peek(); ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') || c == '_'; c = p.peek() {
+			p.pos++
+		}
+		name := p.s[start:p.pos]
+		switch name {
+		case "true":
+			return literal{true}, nil
+		case "false":
+			return literal{false}, nil
+		case "null":
+			return literal{nil}, nil
+		}
+		if p.peek() != '(' {
+			p.pos = start
+			return nil, p.errorf("unexpected %q", name)
+		}
+		return p.parseFunction(name, start)
+	}
+
+	return nil, p.errorf("expected a value")
+}
+
+// parseNumber parses a JSON number literal.
+func (p *parser) parseNumber() (operand, error) {
+	start := p.pos
+	p.consume("-")
+	intStart := p.pos
+	for '0' <= p.peek() && p.peek() <= '9' {
+		p.pos++
+	}
+	if p.pos == intStart || (p.s[intStart] == '0' && p.pos-intStart > 1) {
+		return nil, &SyntaxError{Offset: start, Message: "invalid number"}
+	}
+
+	if p.consume(".") {
+		fracStart := p.pos
+		for '0' <= p.peek() && p.peek() <= '9' {
+			p.pos++
+		}
+		if p.pos == fracStart {
+			return nil, &SyntaxError{Offset: start, Message: "invalid number"}
+		}
+	}
+
+	if c := p.peek(); c == 'e' || c == 'E' {
+		p.pos++
+		if c := p.peek(); c == '+' || c == '-' {
+			p.pos++
+		}
+		expStart := p.pos
+		for '0' <= p.peek() && p.peek() <= '9' {
+			p.pos++
+		}
+		if p.pos == expStart {
+			return nil, &SyntaxError{Offset: start, Message: "invalid number"}
+		}
+	}
+
+	f, err := strconv.ParseFloat(p.s[start:p.pos], 64)
+	if err != nil || math.IsInf(f, 0) {
+		return nil, &SyntaxError{Offset: start, Message: "invalid number"}
+	}
+
+	return literal{f}, nil
+}
+
+func (p *parser) parseFunction(name string, start int) (operand, error) {
+	fn, ok := functions[name]
+	if !ok {
+		return nil, &SyntaxError{Offset: start, Message: fmt.Sprintf("unknown function %s()", name)}
+	}
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+	p.pos++ // (
+
+	call := &funcExpr{name: name, fn: fn}
+	for i := 0; ; i++ {
+		p.skipBlank()
+		if p.consume(")") {
+			break
+		}
+		if i > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+			p.skipBlank()
+		}
+
+		argStart := p.pos
+		arg, err := p.parseArgument()
+		if err != nil {
+			return nil, err
+		}
+		if i >= len(fn.params) {
+			return nil, &SyntaxError{Offset: argStart, Message: fmt.Sprintf("too many arguments for %s()", name)}
+		}
+		if err := checkArgument(fn.params[i], arg); err != nil {
+			return nil, &SyntaxError{Offset: argStart, Message: fmt.Sprintf("argument %d of %s(): %s", i+1, name, err)}
+		}
+		call.args = append(call.args, arg)
+	}
+
+	if len(call.args) != len(fn.params) {
+		return nil, &SyntaxError{Offset: start, Message: fmt.Sprintf("%s() takes %d arguments", name, len(fn.params))}
+	}
+
+	// A literal pattern only needs to be compiled once.
+	if (name == "match" || name == "search") && len(call.args) == 2 {
+		if lit, ok := call.args[1].(literal); ok {
+			if s, ok := lit.v.(string); ok {
+				call.pattern, call.patternErr = compilePattern(s, name == "match")
+			}
+		}
+	}
+
+	return call, nil
+}
+
+// parseArgument parses a function argument, which can also be a logical
+// expression.
+func (p *parser) parseArgument() (any, error) {
+	start := p.pos
+	if c := p.peek(); c == '!' || c == '(' {
+		return p.parseLogicalOr()
+	}
+
+	v, err := p.parseComparable()
+	if err != nil {
+		return nil, err
+	}
+
+	after := p.pos
+	p.skipBlank()
+	if c := p.peek(); c == '=' || c == '!' || c == '<' || c == '>' || c == '&' || c == '|' {
+		p.pos = start
+		return p.parseLogicalOr()
+	}
+	p.pos = after
+
+	return v, nil
+}
+
+func checkArgument(param exprType, arg any) error {
+	switch param {
+	case valueType:
+		switch arg := arg.(type) {
+		case literal:
+			return nil
+		case *filterQuery:
+			if arg.singular() {
+				return nil
+			}
+		case *funcExpr:
+			if arg.fn.result == valueType {
+				return nil
+			}
+		}
+		return fmt.Errorf("expected a value")
+
+	case nodesType:
+		if _, ok := arg.(*filterQuery); ok {
+			return nil
+		}
+		return fmt.Errorf("expected a query")
+	}
+
+	switch arg := arg.(type) {
+	case logicalExpr:
+		return nil
+	case *filterQuery:
+		return nil
+	case *funcExpr:
+		if arg.fn.result == logicalType {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("expected a logical expression")
+}
+
+// compilePattern converts an I-Regexp (RFC 9485) into a Go regular expression.
+// The only difference that matters is that "." must not match "\n" or "\r",
+// where Go's "." only excludes "\n".
+func compilePattern(pattern string, full bool) (*regexp.Regexp, error) {
+	var b strings.Builder
+	if full {
+		b.WriteString(`\A(?:`)
+	}
+
+	inClass := false
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case c == '\\' && i+1 < len(pattern):
+			b.WriteByte(c)
+			i++
+			b.WriteByte(pattern[i])
+			continue
+		case c == '[':
+			inClass = true
+		case c == ']':
+			inClass = false
+		case c == '.' && !inClass:
+			b.WriteString(`[^\n\r]`)
+			continue
+		}
+		b.WriteByte(c)
+	}
+
+	if full {
+		b.WriteString(`)\z`)
+	}
+
+	return regexp.Compile(b.String())
+}
@@ -0,0 +1,259 @@
+// Package jsonpointer implements JSON Pointer (RFC 6901) for documents made of
+// *orderedmap.OrderedMap[string, any] objects and []any arrays, such as those
+// returned by orderedmap.DecodeJSON.
+package jsonpointer
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/elliotchance/orderedmap/v3"
+)
+
+// ErrNotFound is returned (wrapped) when a pointer does not refer to an
+// existing value.
+var ErrNotFound = errors.New("jsonpointer: value not found")
+
+// Pointer is a parsed JSON Pointer. Each item is an unescaped reference token,
+// so the empty Pointer refers to the whole document.
+type Pointer []string
+
+// Parse parses a JSON Pointer such as "/a/b~1c/0".
+func Parse(s string) (Pointer, error) {
+	if s == "" {
+		return Pointer{}, nil
+	}
+	if s[0] != '/' {
+		return nil, fmt.Errorf("jsonpointer: %q does not start with /", s)
+	}
+
+	tokens := strings.Split(s[1:], "/")
+	for i, token := range tokens {
+		for j := 0; j < len(token); j++ {
+			if token[j] == '~' && (j+1 == len(token) || (token[j+1] != '0' && token[j+1] != '1')) {
+				return nil, fmt.Errorf("jsonpointer: %q has an invalid escape sequence", s)
+			}
+		}
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+
+	return tokens, nil
+}
+
+// String returns the pointer with each reference token escaped.
+func (p Pointer) String() string {
+	var b strings.Builder
+	for _, token := range p {
+		b.WriteByte('/')
+		b.WriteString(strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1"))
+	}
+
+	return b.String()
+}
+
+// Parent returns the pointer to the value that contains the value p refers
+// to, and the reference token for it. It must not be called on the empty
+// Pointer.
+func (p Pointer) Parent() (Pointer, string) {
+	return p[:len(p)-1], p[len(p)-1]
+}
+
+// IsPrefixOf reports whether p refers to q or to one of its ancestors.
+func (p Pointer) IsPrefixOf(q Pointer) bool {
+	if len(p) > len(q) {
+		return false
+	}
+	for i := range p {
+		if p[i] != q[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Get returns the value that ptr refers to in doc.
+func Get(doc any, ptr string) (any, error) {
+	p, err := Parse(ptr)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.Get(doc)
+}
+
+// Get returns the value that p refers to in doc.
+func (p Pointer) Get(doc any) (any, error) {
+	for i, token := range p {
+		var err error
+		doc, err = Child(doc, token)
+		if err != nil {
+			return nil, fmt.Errorf("%w at %s", err, p[:i+1])
+		}
+	}
+
+	return doc, nil
+}
+
+// Set sets the value that ptr refers to in doc and returns the document, which
+// is only a different value if ptr is the empty pointer or an array had to
+// grow. See Pointer.Set.
+func Set(doc any, ptr string, value any) (any, error) {
+	p, err := Parse(ptr)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.Set(doc, value)
+}
+
+// Set sets the value that p refers to in doc and returns the document.
+//
+// An existing object member keeps its position, and a new member is appended.
+// An array element is replaced, or appended if the index is the length of the
+// array (or "-"). The parent of the value must already exist.
+func (p Pointer) Set(doc, value any) (any, error) {
+	if len(p) == 0 {
+		return value, nil
+	}
+
+	parent, token := p.Parent()
+	return parent.Update(doc, func(container any) (any, error) {
+		switch container := container.(type) {
+		case *orderedmap.OrderedMap[string, any]:
+			container.Set(token, value)
+			return container, nil
+
+		case []any:
+			i, err := Index(token, len(container))
+			if err != nil {
+				return nil, err
+			}
+			if i == len(container) {
+				return append(container, value), nil
+			}
+			container[i] = value
+			return container, nil
+		}
+
+		return nil, fmt.Errorf("jsonpointer: cannot set a member of %T", container)
+	})
+}
+
+// Delete removes the value that ptr refers to from doc and returns the
+// document. See Pointer.Delete.
+func Delete(doc any, ptr string) (any, error) {
+	p, err := Parse(ptr)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.Delete(doc)
+}
+
+// Delete removes the value that p refers to from doc and returns the document.
+// Removing an array element shifts the elements after it. It is an error if
+// the value does not exist or p is the empty pointer.
+func (p Pointer) Delete(doc any) (any, error) {
+	if len(p) == 0 {
+		return nil, errors.New("jsonpointer: cannot delete the whole document")
+	}
+
+	parent, token := p.Parent()
+	return parent.Update(doc, func(container any) (any, error) {
+		if _, err := Child(container, token); err != nil {
+			return nil, fmt.Errorf("%w at %s", err, p)
+		}
+
+		switch container := container.(type) {
+		case *orderedmap.OrderedMap[string, any]:
+			container.Delete(token)
+
+		case []any:
+			i, _ := Index(token, len(container))
+			return slices.Delete(container, i, i+1), nil
+		}
+
+		return container, nil
+	})
+}
+
+// Update calls fn with the value that p refers to in doc and replaces it with
+// the value fn returns, which is put back into each parent in turn. This is
+// needed for changes that create a new value, such as changing the length of
+// a []any. The document is returned.
+func (p Pointer) Update(doc any, fn func(value any) (any, error)) (any, error) {
+	if len(p) == 0 {
+		return fn(doc)
+	}
+
+	child, err := Child(doc, p[0])
+	if err != nil {
+		return nil, fmt.Errorf("%w at %s", err, p[:1])
+	}
+
+	child, err = p[1:].Update(child, fn)
+	if err != nil {
+		return nil, err
+	}
+
+	switch doc := doc.(type) {
+	case *orderedmap.OrderedMap[string, any]:
+		doc.Set(p[0], child)
+
+	case []any:
+		i, _ := Index(p[0], len(doc))
+		doc[i] = child
+	}
+
+	return doc, nil
+}
+
+// Child returns the member or array element of container for a single
+// reference token.
+func Child(container any, token string) (any, error) {
+	switch container := container.(type) {
+	case *orderedmap.OrderedMap[string, any]:
+		if container != nil {
+			if value, ok := container.Get(token); ok {
+				return value, nil
+			}
+		}
+		return nil, ErrNotFound
+
+	case []any:
+		i, err := Index(token, len(container))
+		if err != nil {
+			return nil, err
+		}
+		if i == len(container) {
+			return nil, ErrNotFound
+		}
+		return container[i], nil
+	}
+
+	return nil, fmt.Errorf("jsonpointer: cannot index %T", container)
+}
+
+// Index parses an array index reference token for an array of length n. The
+// token "-" (the position after the last element) is returned as n. An index
+// that is greater than n returns ErrNotFound.
+func Index(token string, n int) (int, error) {
+	if token == "-" {
+		return n, nil
+	}
+
+	if token == "" || (len(token) > 1 && token[0] == '0') || strings.TrimLeft(token, "0123456789") != "" {
+		return 0, fmt.Errorf("jsonpointer: invalid array index %q", token)
+	}
+
+	i, err := strconv.Atoi(token)
+	if err != nil || i > n {
+		return 0, ErrNotFound
+	}
+
+	return i, nil
+}
@@ -0,0 +1,137 @@
+package jsonpointer_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/elliotchance/orderedmap/v3"
+	"github.com/elliotchance/orderedmap/v3/jsonpointer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("Escapes", func(t *testing.T) {
+		p, err := jsonpointer.Parse("/a~1b/m~0n/~01")
+		require.NoError(t, err)
+		assert.Equal(t, jsonpointer.Pointer{"a/b", "m~n", "~1"}, p)
+		assert.Equal(t, "/a~1b/m~0n/~01", p.String())
+	})
+
+	t.Run("Root", func(t *testing.T) {
+		p, err := jsonpointer.Parse("")
+		require.NoError(t, err)
+		assert.Empty(t, p)
+		assert.Equal(t, "", p.String())
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		for _, s := range []string{"a", "/~", "/~2"} {
+			_, err := jsonpointer.Parse(s)
+			assert.Error(t, err, s)
+		}
+	})
+}
+
+func TestGet(t *testing.T) {
+	// The example document from RFC 6901.
+	doc, err := orderedmap.DecodeJSON([]byte(`{
+		"foo": ["bar", "baz"],
+		"": 0,
+		"a/b": 1,
+		"c%d": 2,
+		"e^f": 3,
+		"g|h": 4,
+		"i\\j": 5,
+		"k\"l": 6,
+		" ": 7,
+		"m~n": 8
+	}`))
+	require.NoError(t, err)
+
+	for ptr, expected := range map[string]any{
+		"/foo":   []any{"bar", "baz"},
+		"/foo/0": "bar",
+		"/":      0.0,
+		"/a~1b":  1.0,
+		"/c%d":   2.0,
+		"/e^f":   3.0,
+		"/g|h":   4.0,
+		"/i\\j":  5.0,
+		"/k\"l":  6.0,
+		"/ ":     7.0,
+		"/m~0n":  8.0,
+	} {
+		value, err := jsonpointer.Get(doc, ptr)
+		require.NoError(t, err, ptr)
+		assert.Equal(t, expected, value, ptr)
+	}
+
+	t.Run("NotFound", func(t *testing.T) {
+		for _, ptr := range []string{"/missing", "/foo/2", "/foo/-", "/foo/5"} {
+			_, err := jsonpointer.Get(doc, ptr)
+			assert.ErrorIs(t, err, jsonpointer.ErrNotFound, ptr)
+		}
+	})
+
+	t.Run("InvalidIndex", func(t *testing.T) {
+		for _, ptr := range []string{"/foo/01", "/foo/a", "/foo/", "/foo/0/x"} {
+			_, err := jsonpointer.Get(doc, ptr)
+			assert.Error(t, err, ptr)
+			assert.NotErrorIs(t, err, jsonpointer.ErrNotFound, ptr)
+		}
+	})
+}
+
+func TestSet(t *testing.T) {
+	newDoc := func() any {
+		doc, err := orderedmap.DecodeJSON([]byte(`{"a":{"x":1,"y":2},"b":[1,2]}`))
+		require.NoError(t, err)
+		return doc
+	}
+
+	for _, test := range []struct {
+		ptr      string
+		value    any
+		expected string
+	}{
+		{"/a/x", 3, `{"a":{"x":3,"y":2},"b":[1,2]}`},
+		{"/a/z", 3, `{"a":{"x":1,"y":2,"z":3},"b":[1,2]}`},
+		{"/b/0", 3, `{"a":{"x":1,"y":2},"b":[3,2]}`},
+		{"/b/2", 3, `{"a":{"x":1,"y":2},"b":[1,2,3]}`},
+		{"/b/-", 3, `{"a":{"x":1,"y":2},"b":[1,2,3]}`},
+		{"", 3, `3`},
+	} {
+		doc, err := jsonpointer.Set(newDoc(), test.ptr, test.value)
+		require.NoError(t, err, test.ptr)
+		data, err := json.Marshal(doc)
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, string(data), test.ptr)
+	}
+
+	t.Run("Errors", func(t *testing.T) {
+		for _, ptr := range []string{"/c/d", "/b/3", "/a/x/y", "x"} {
+			_, err := jsonpointer.Set(newDoc(), ptr, 1)
+			assert.Error(t, err, ptr)
+		}
+	})
+}
+
+func TestDelete(t *testing.T) {
+	doc, err := orderedmap.DecodeJSON([]byte(`{"a":{"x":1,"y":2},"b":[1,2,3]}`))
+	require.NoError(t, err)
+
+	doc, err = jsonpointer.Delete(doc, "/a/x")
+	require.NoError(t, err)
+	doc, err = jsonpointer.Delete(doc, "/b/1")
+	require.NoError(t, err)
+
+	data, err := json.Marshal(doc)
+	require.NoError(t, err)
+	assert.Equal(t, `{"a":{"y":2},"b":[1,3]}`, string(data))
+
+	for _, ptr := range []string{"", "/a/x", "/b/2", "/b/-"} {
+		_, err := jsonpointer.Delete(doc, ptr)
+		assert.Error(t, err, ptr)
+	}
+}
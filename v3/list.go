@@ -30,6 +30,10 @@ func (e *Element[K, V]) Prev() *Element[K, V] {
 // The list is immediately usable after instantiation without the need of a dedicated initialization.
 type list[K comparable, V any] struct {
 	root Element[K, V] // list head and tail
+
+	// index is only allocated once EnableIndex is called. It is updated by
+	// every method that links or unlinks an element.
+	index *orderIndex[K, V]
 }
 
 func (l *list[K, V]) IsEmpty() bool {
@@ -60,6 +64,10 @@ func (l *list[K, V]) Remove(e *Element[K, V]) {
 	}
 	e.next = nil // avoid memory leaks
 	e.prev = nil // avoid memory leaks
+
+	if l.index != nil {
+		l.index.remove(e)
+	}
 }
 
 // PushFront inserts a new element e with value v at the front of list l and returns e.
@@ -69,12 +77,15 @@ func (l *list[K, V]) PushFront(key K, value V) *Element[K, V] {
 		// It's the first element
 		l.root.next = e
 		l.root.prev = e
-		return e
+	} else {
+		e.next = l.root.next
+		l.root.next.prev = e
+		l.root.next = e
 	}
 
-	e.next = l.root.next
-	l.root.next.prev = e
-	l.root.next = e
+	if l.index != nil {
+		l.index.insertAfter(e, nil)
+	}
 	return e
 }
 
@@ -85,11 +96,131 @@ func (l *list[K, V]) PushBack(key K, value V) *Element[K, V] {
 		// It's the first element
 		l.root.next = e
 		l.root.prev = e
-		return e
+	} else {
+		e.prev = l.root.prev
+		l.root.prev.next = e
+		l.root.prev = e
 	}
 
-	e.prev = l.root.prev
-	l.root.prev.next = e
-	l.root.prev = e
+	if l.index != nil {
+		l.index.insertAfter(e, e.prev)
+	}
 	return e
 }
+
+// InsertAfter links e, which must not already be in a list, after mark. If
+// mark is nil, e is inserted at the front.
+func (l *list[K, V]) InsertAfter(e, mark *Element[K, V]) {
+	if mark == nil {
+		e.prev = nil
+		e.next = l.root.next
+		if l.root.next == nil {
+			l.root.prev = e
+		} else {
+			l.root.next.prev = e
+		}
+		l.root.next = e
+	} else {
+		e.prev = mark
+		e.next = mark.next
+		if mark.next == nil {
+			l.root.prev = e
+		} else {
+			mark.next.prev = e
+		}
+		mark.next = e
+	}
+
+	if l.index != nil {
+		l.index.insertAfter(e, mark)
+	}
+}
+
+// MoveAfter moves e, which must be in list l, to after mark. If mark is nil, e
+// is moved to the front. mark must not be e.
+func (l *list[K, V]) MoveAfter(e, mark *Element[K, V]) {
+	l.Remove(e)
+	l.InsertAfter(e, mark)
+}
+
+// Sort sorts the list with a merge sort that relinks the elements in place. It
+// is stable, and takes O(n log n) time with no extra memory.
+func (l *list[K, V]) Sort(cmp func(a, b *Element[K, V]) int) {
+	head := l.root.next
+	if head == nil {
+		return
+	}
+
+	// Each pass merges pairs of sorted runs of size elements, following only
+	// the next pointers. The prev pointers are set as the elements are
+	// appended to the merged list, so they are correct after the last pass.
+	var tail *Element[K, V]
+	for size := 1; ; size *= 2 {
+		var merges int
+		p := head
+		head, tail = nil, nil
+		for p != nil {
+			merges++
+			q, pSize := p, 0
+			for ; pSize < size && q != nil; pSize++ {
+				q = q.next
+			}
+			qSize := size
+
+			for pSize > 0 || (qSize > 0 && q != nil) {
+				var e *Element[K, V]
+				if pSize == 0 || (qSize > 0 && q != nil && cmp(p, q) > 0) {
+					e, q = q, q.next
+					qSize--
+				} else {
+					e, p = p, p.next
+					pSize--
+				}
+
+				if tail == nil {
+					head = e
+				} else {
+					tail.next = e
+				}
+				e.prev = tail
+				tail = e
+			}
+			p = q
+		}
+		tail.next = nil
+
+		if merges <= 1 {
+			break
+		}
+	}
+
+	l.root.next, l.root.prev = head, tail
+	if l.index != nil {
+		l.index = newOrderIndex(l)
+	}
+}
+
+// Relink links the elements, which must be all of the elements in list l, in
+// the order given.
+func (l *list[K, V]) Relink(elements []*Element[K, V]) {
+	if len(elements) == 0 {
+		return
+	}
+
+	var prev *Element[K, V]
+	for _, e := range elements {
+		e.prev = prev
+		if prev == nil {
+			l.root.next = e
+		} else {
+			prev.next = e
+		}
+		prev = e
+	}
+	prev.next = nil
+	l.root.prev = prev
+
+	if l.index != nil {
+		l.index = newOrderIndex(l)
+	}
+}
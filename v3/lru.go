@@ -0,0 +1,148 @@
+package orderedmap
+
+import "iter"
+
+// LRU is a cache that holds at most MaxLen elements. When a new key is set and
+// the cache is full, the least recently used element is evicted.
+//
+// The elements are kept in an OrderedMap from the least recently used (front)
+// to the most recently used (back). Using an element relinks it to the back,
+// so every operation is O(1).
+//
+// An LRU is not safe for concurrent use.
+type LRU[K comparable, V any] struct {
+	m          OrderedMap[K, V]
+	maxLen     int
+	touchOnGet bool
+	onEvict    func(key K, value V)
+}
+
+// NewLRU creates an empty cache that holds at most maxLen elements. If maxLen
+// is zero or less there is no limit.
+func NewLRU[K comparable, V any](maxLen int) *LRU[K, V] {
+	return &LRU[K, V]{
+		m:          OrderedMap[K, V]{kv: make(map[K]*Element[K, V])},
+		maxLen:     maxLen,
+		touchOnGet: true,
+	}
+}
+
+// MaxLen returns the maximum number of elements, as set by NewLRU or Resize.
+func (c *LRU[K, V]) MaxLen() int {
+	return c.maxLen
+}
+
+// SetTouchOnGet controls whether Get marks the element as the most recently
+// used, which it does by default. If touchOnGet is false only Set changes the
+// order, so elements are evicted in the order they were last set.
+func (c *LRU[K, V]) SetTouchOnGet(touchOnGet bool) {
+	c.touchOnGet = touchOnGet
+}
+
+// OnEvict sets a function that is called with each element that is evicted by
+// Set or Resize. It is not called for Delete.
+func (c *LRU[K, V]) OnEvict(fn func(key K, value V)) {
+	c.onEvict = fn
+}
+
+// Get returns the value for a key and (unless SetTouchOnGet(false) was used)
+// marks it as the most recently used. If the key does not exist, the second
+// return parameter will be false and the value will be nil.
+func (c *LRU[K, V]) Get(key K) (value V, ok bool) {
+	element := c.m.GetElement(key)
+	if element == nil {
+		return
+	}
+	if c.touchOnGet {
+		c.m.moveAfter(element, c.m.Back())
+	}
+
+	return element.Value, true
+}
+
+// Peek returns the value for a key without marking it as the most recently
+// used. If the key does not exist, the second return parameter will be false
+// and the value will be nil.
+func (c *LRU[K, V]) Peek(key K) (value V, ok bool) {
+	return c.m.Get(key)
+}
+
+// Has checks if a key exists in the cache, without marking it as the most
+// recently used.
+func (c *LRU[K, V]) Has(key K) bool {
+	return c.m.Has(key)
+}
+
+// Len returns the number of elements in the cache.
+func (c *LRU[K, V]) Len() int {
+	return c.m.Len()
+}
+
+// Set will set (or replace) a value for a key and mark it as the most recently
+// used. If the key was new, then true will be returned. If the cache was full,
+// the least recently used element is evicted.
+func (c *LRU[K, V]) Set(key K, value V) bool {
+	if element := c.m.GetElement(key); element != nil {
+		element.Value = value
+		c.m.moveAfter(element, c.m.Back())
+		return false
+	}
+
+	c.m.Set(key, value)
+	c.evict()
+	return true
+}
+
+// Delete will remove a key from the cache. It will return true if the key was
+// removed (the key did exist).
+func (c *LRU[K, V]) Delete(key K) (didDelete bool) {
+	return c.m.Delete(key)
+}
+
+// Resize changes the maximum number of elements. If there are more elements
+// than maxLen, the least recently used are evicted. The number of evicted
+// elements is returned. If maxLen is zero or less there is no limit.
+func (c *LRU[K, V]) Resize(maxLen int) (evicted int) {
+	c.maxLen = maxLen
+
+	return c.evict()
+}
+
+// evict removes the least recently used elements until there are no more than
+// maxLen.
+func (c *LRU[K, V]) evict() (evicted int) {
+	for c.maxLen > 0 && c.m.Len() > c.maxLen {
+		element := c.m.Front()
+		c.m.Delete(element.Key)
+		if c.onEvict != nil {
+			c.onEvict(element.Key, element.Value)
+		}
+		evicted++
+	}
+
+	return
+}
+
+// AllFromFront returns an iterator that yields all elements in the cache
+// starting at the front (least recently used element).
+func (c *LRU[K, V]) AllFromFront() iter.Seq2[K, V] {
+	return c.m.AllFromFront()
+}
+
+// AllFromBack returns an iterator that yields all elements in the cache
+// starting at the back (most recently used element).
+func (c *LRU[K, V]) AllFromBack() iter.Seq2[K, V] {
+	return c.m.AllFromBack()
+}
+
+// Keys returns an iterator that yields all the keys in the cache starting at
+// the least recently used.
+func (c *LRU[K, V]) Keys() iter.Seq[K] {
+	return c.m.Keys()
+}
+
+// Values returns an iterator that yields all the values in the cache starting
+// at the least recently used.
+func (c *LRU[K, V]) Values() iter.Seq[V] {
+	return c.m.Values()
+}
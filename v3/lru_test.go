@@ -0,0 +1,132 @@
+package orderedmap_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/elliotchance/orderedmap/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRU(t *testing.T) {
+	t.Run("EvictsLeastRecentlyUsed", func(t *testing.T) {
+		c := orderedmap.NewLRU[string, int](3)
+		assert.Equal(t, 3, c.MaxLen())
+
+		var evicted []string
+		c.OnEvict(func(key string, value int) {
+			evicted = append(evicted, key)
+		})
+
+		assert.True(t, c.Set("a", 1))
+		assert.True(t, c.Set("b", 2))
+		assert.True(t, c.Set("c", 3))
+		assert.Nil(t, evicted)
+
+		value, ok := c.Get("a")
+		assert.True(t, ok)
+		assert.Equal(t, 1, value)
+		assert.True(t, c.Set("d", 4))
+		assert.Equal(t, []string{"b"}, evicted)
+
+		assert.False(t, c.Set("c", 30))
+		assert.True(t, c.Set("e", 5))
+		assert.Equal(t, []string{"b", "a"}, evicted)
+
+		assert.Equal(t, 3, c.Len())
+		assert.Equal(t, []string{"d", "c", "e"}, slices.Collect(c.Keys()))
+		assert.Equal(t, []int{4, 30, 5}, slices.Collect(c.Values()))
+	})
+
+	t.Run("Peek", func(t *testing.T) {
+		c := orderedmap.NewLRU[string, int](2)
+		c.Set("a", 1)
+		c.Set("b", 2)
+
+		value, ok := c.Peek("a")
+		assert.True(t, ok)
+		assert.Equal(t, 1, value)
+		assert.True(t, c.Has("a"))
+		c.Set("c", 3)
+
+		assert.False(t, c.Has("a"))
+		_, ok = c.Peek("a")
+		assert.False(t, ok)
+		_, ok = c.Get("a")
+		assert.False(t, ok)
+	})
+
+	t.Run("TouchOnGet", func(t *testing.T) {
+		c := orderedmap.NewLRU[string, int](2)
+		c.SetTouchOnGet(false)
+		c.Set("a", 1)
+		c.Set("b", 2)
+		c.Get("a")
+		c.Set("c", 3)
+		assert.Equal(t, []string{"b", "c"}, slices.Collect(c.Keys()))
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		c := orderedmap.NewLRU[string, int](2)
+		c.OnEvict(func(key string, value int) {
+			t.Errorf("unexpected eviction of %s", key)
+		})
+		c.Set("a", 1)
+		assert.True(t, c.Delete("a"))
+		assert.False(t, c.Delete("a"))
+		c.Set("b", 2)
+		c.Set("c", 3)
+		assert.Equal(t, 2, c.Len())
+	})
+
+	t.Run("Resize", func(t *testing.T) {
+		c := orderedmap.NewLRU[int, int](5)
+		for i := 0; i < 5; i++ {
+			c.Set(i, i)
+		}
+
+		var evicted []int
+		c.OnEvict(func(key, value int) {
+			evicted = append(evicted, value)
+		})
+
+		assert.Equal(t, 3, c.Resize(2))
+		assert.Equal(t, 2, c.MaxLen())
+		assert.Equal(t, []int{0, 1, 2}, evicted)
+		assert.Equal(t, []int{3, 4}, slices.Collect(c.Keys()))
+
+		assert.Equal(t, 0, c.Resize(10))
+		c.Set(5, 5)
+		assert.Equal(t, []int{3, 4, 5}, slices.Collect(c.Keys()))
+	})
+
+	t.Run("Unlimited", func(t *testing.T) {
+		c := orderedmap.NewLRU[int, int](0)
+		for i := 0; i < 100; i++ {
+			c.Set(i, i)
+		}
+		c.Get(0)
+		assert.Equal(t, 100, c.Len())
+
+		var keys []int
+		for key := range c.AllFromBack() {
+			keys = append(keys, key)
+			if len(keys) == 2 {
+				break
+			}
+		}
+		assert.Equal(t, []int{0, 99}, keys)
+	})
+}
+
+func BenchmarkLRU_Get(b *testing.B) {
+	c := orderedmap.NewLRU[int, int](1000)
+	for i := 0; i < 1000; i++ {
+		c.Set(i, i)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.Get(i % 1000)
+	}
+}
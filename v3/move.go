@@ -0,0 +1,83 @@
+package orderedmap
+
+// MoveToFront moves the element for key to the front of the map. It returns
+// false if the key does not exist.
+//
+// The element is relinked rather than recreated, so a pointer returned by
+// GetElement remains valid. This applies to all of the Move methods.
+func (m *OrderedMap[K, V]) MoveToFront(key K) bool {
+	element, ok := m.kv[key]
+	if ok {
+		m.moveAfter(element, nil)
+	}
+
+	return ok
+}
+
+// MoveToBack moves the element for key to the back of the map. It returns false
+// if the key does not exist.
+func (m *OrderedMap[K, V]) MoveToBack(key K) bool {
+	element, ok := m.kv[key]
+	if ok {
+		m.moveAfter(element, m.ll.Back())
+	}
+
+	return ok
+}
+
+// MoveBefore moves the element for key to just before the element for mark. It
+// returns false if either key does not exist. If key and mark are the same the
+// map is not changed.
+func (m *OrderedMap[K, V]) MoveBefore(key, mark K) bool {
+	element, ok := m.kv[key]
+	markElement, markOk := m.kv[mark]
+	if !ok || !markOk {
+		return false
+	}
+
+	if element != markElement {
+		m.moveAfter(element, markElement.Prev())
+	}
+
+	return true
+}
+
+// MoveAfter moves the element for key to just after the element for mark. It
+// returns false if either key does not exist. If key and mark are the same the
+// map is not changed.
+func (m *OrderedMap[K, V]) MoveAfter(key, mark K) bool {
+	element, ok := m.kv[key]
+	markElement, markOk := m.kv[mark]
+	if !ok || !markOk {
+		return false
+	}
+
+	m.moveAfter(element, markElement)
+
+	return true
+}
+
+// moveAfter moves element to after mark, or to the front if mark is nil. It
+// does nothing if element is mark or is already in that position.
+func (m *OrderedMap[K, V]) moveAfter(element, mark *Element[K, V]) {
+	if element != mark && element.Prev() != mark {
+		m.relink(element, mark, element.Value)
+	}
+}
+
+// relink moves element to after mark (or to the front if mark is nil) and
+// sets its value, as a single operation in the journal. The element must not
+// already be in that position.
+func (m *OrderedMap[K, V]) relink(element, mark *Element[K, V], value V) {
+	if m.journal != nil {
+		e := journalEntry[K, V]{op: journalMove, key: element.Key, old: element.Value, value: value}
+		e.oldAfter, e.oldHasAfter = journalPosition(element)
+		if mark != nil {
+			e.after, e.hasAfter = mark.Key, true
+		}
+		m.journal.record(e)
+	}
+
+	element.Value = value
+	m.ll.MoveAfter(element, mark)
+}
@@ -0,0 +1,146 @@
+package orderedmap_test
+
+import (
+	"testing"
+
+	"github.com/elliotchance/orderedmap/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newABCD() *orderedmap.OrderedMap[string, int] {
+	m := orderedmap.NewOrderedMap[string, int]()
+	m.Set("a", 1)
+	m.Set("b", 2)
+	m.Set("c", 3)
+	m.Set("d", 4)
+
+	return m
+}
+
+func TestOrderedMap_MoveToFront(t *testing.T) {
+	m := newABCD()
+	element := m.GetElement("c")
+
+	assert.True(t, m.MoveToFront("c"))
+	assertOrderedMap(t, m, []string{"c", "a", "b", "d"}, []int{3, 1, 2, 4})
+	assert.Same(t, element, m.Front())
+	assert.Same(t, element, m.GetElement("c"))
+
+	assert.True(t, m.MoveToFront("c"))
+	assert.True(t, m.MoveToFront("d"))
+	assertOrderedMap(t, m, []string{"d", "c", "a", "b"}, []int{4, 3, 1, 2})
+	assert.Equal(t, "b", m.Back().Key)
+
+	assert.False(t, m.MoveToFront("x"))
+}
+
+func TestOrderedMap_MoveToBack(t *testing.T) {
+	m := newABCD()
+	element := m.GetElement("a")
+
+	assert.True(t, m.MoveToBack("a"))
+	assertOrderedMap(t, m, []string{"b", "c", "d", "a"}, []int{2, 3, 4, 1})
+	assert.Same(t, element, m.Back())
+	assert.Equal(t, "b", m.Front().Key)
+
+	assert.True(t, m.MoveToBack("a"))
+	assertOrderedMap(t, m, []string{"b", "c", "d", "a"}, []int{2, 3, 4, 1})
+
+	assert.False(t, m.MoveToBack("x"))
+}
+
+func TestOrderedMap_MoveBefore(t *testing.T) {
+	t.Run("Middle", func(t *testing.T) {
+		m := newABCD()
+		assert.True(t, m.MoveBefore("d", "b"))
+		assertOrderedMap(t, m, []string{"a", "d", "b", "c"}, []int{1, 4, 2, 3})
+		assert.True(t, m.MoveBefore("a", "c"))
+		assertOrderedMap(t, m, []string{"d", "b", "a", "c"}, []int{4, 2, 1, 3})
+	})
+
+	t.Run("Front", func(t *testing.T) {
+		m := newABCD()
+		assert.True(t, m.MoveBefore("c", "a"))
+		assertOrderedMap(t, m, []string{"c", "a", "b", "d"}, []int{3, 1, 2, 4})
+	})
+
+	t.Run("AlreadyBefore", func(t *testing.T) {
+		m := newABCD()
+		assert.True(t, m.MoveBefore("a", "b"))
+		assert.True(t, m.MoveBefore("b", "b"))
+		assertOrderedMap(t, m, []string{"a", "b", "c", "d"}, []int{1, 2, 3, 4})
+	})
+
+	t.Run("Missing", func(t *testing.T) {
+		m := newABCD()
+		assert.False(t, m.MoveBefore("x", "a"))
+		assert.False(t, m.MoveBefore("a", "x"))
+		assertOrderedMap(t, m, []string{"a", "b", "c", "d"}, []int{1, 2, 3, 4})
+	})
+}
+
+func TestOrderedMap_MoveAfter(t *testing.T) {
+	t.Run("Middle", func(t *testing.T) {
+		m := newABCD()
+		assert.True(t, m.MoveAfter("a", "c"))
+		assertOrderedMap(t, m, []string{"b", "c", "a", "d"}, []int{2, 3, 1, 4})
+		assert.True(t, m.MoveAfter("d", "b"))
+		assertOrderedMap(t, m, []string{"b", "d", "c", "a"}, []int{2, 4, 3, 1})
+	})
+
+	t.Run("Back", func(t *testing.T) {
+		m := newABCD()
+		assert.True(t, m.MoveAfter("b", "d"))
+		assertOrderedMap(t, m, []string{"a", "c", "d", "b"}, []int{1, 3, 4, 2})
+		assert.Equal(t, "b", m.Back().Key)
+	})
+
+	t.Run("AlreadyAfter", func(t *testing.T) {
+		m := newABCD()
+		assert.True(t, m.MoveAfter("b", "a"))
+		assert.True(t, m.MoveAfter("b", "b"))
+		assertOrderedMap(t, m, []string{"a", "b", "c", "d"}, []int{1, 2, 3, 4})
+	})
+
+	t.Run("Missing", func(t *testing.T) {
+		m := newABCD()
+		assert.False(t, m.MoveAfter("x", "a"))
+		assert.False(t, m.MoveAfter("a", "x"))
+	})
+
+	t.Run("SingleElement", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, int]()
+		m.Set("a", 1)
+		assert.True(t, m.MoveToFront("a"))
+		assert.True(t, m.MoveToBack("a"))
+		assert.True(t, m.MoveAfter("a", "a"))
+		assertOrderedMap(t, m, []string{"a"}, []int{1})
+	})
+}
+
+func TestOrderedMap_MoveUndo(t *testing.T) {
+	m := newABCD()
+	m.EnableJournal(0)
+
+	m.MoveToFront("a") // no change, not recorded
+	assert.False(t, m.CanUndo())
+
+	m.MoveToFront("c")
+	m.MoveAfter("a", "d")
+	m.MoveBefore("b", "c")
+	assertOrderedMap(t, m, []string{"b", "c", "d", "a"}, []int{2, 3, 4, 1})
+
+	require.True(t, m.Undo())
+	assertOrderedMap(t, m, []string{"c", "b", "d", "a"}, []int{3, 2, 4, 1})
+	require.True(t, m.Undo())
+	assertOrderedMap(t, m, []string{"c", "a", "b", "d"}, []int{3, 1, 2, 4})
+	require.True(t, m.Undo())
+	assertOrderedMap(t, m, []string{"a", "b", "c", "d"}, []int{1, 2, 3, 4})
+	assert.False(t, m.Undo())
+
+	require.True(t, m.Redo())
+	require.True(t, m.Redo())
+	require.True(t, m.Redo())
+	assertOrderedMap(t, m, []string{"b", "c", "d", "a"}, []int{2, 3, 4, 1})
+}
@@ -0,0 +1,332 @@
+package orderedmap
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+)
+
+// MarshalMsgpack encodes the map as a MessagePack map with the elements in the
+// same order as the map (front to back). Nested ordered maps are also written
+// in order.
+//
+// Values are encoded by their kind: booleans, integers, floats, strings, byte
+// slices, slices, arrays, maps and structs (as a map of their exported field
+// names) are supported, as well as time.Time (using the timestamp extension)
+// and types that implement encoding.TextMarshaler (as a string).
+//
+// The method name and signature match the Marshaler interface used by popular
+// MessagePack packages, so ordered maps are encoded in order by them too.
+func (m *OrderedMap[K, V]) MarshalMsgpack() ([]byte, error) {
+	w := &msgpackWriter{}
+	if err := m.encodeBinary(w); err != nil {
+		return nil, err
+	}
+
+	return w.buf.Bytes(), nil
+}
+
+// UnmarshalMsgpack decodes a MessagePack map, adding the elements in the order
+// they appear. Existing elements are kept, and a nil leaves the map unchanged.
+//
+// If V is the empty interface, nested maps are decoded into
+// *OrderedMap[string, any] (or *OrderedMap[any, any] if not all the keys are
+// strings), integers into int64 (or uint64 if they are too large) and floats
+// into float64.
+func (m *OrderedMap[K, V]) UnmarshalMsgpack(data []byte) error {
+	r := &msgpackReader{data: data}
+	tok, err := r.next()
+	if err != nil {
+		return err
+	}
+	if err := m.decodeBinary(r, tok); err != nil {
+		return err
+	}
+	if r.pos != len(r.data) {
+		return fmt.Errorf("orderedmap: %d bytes of trailing data", len(r.data)-r.pos)
+	}
+
+	return nil
+}
+
+type msgpackWriter struct {
+	buf bytes.Buffer
+}
+
+func (w *msgpackWriter) writeNil() {
+	w.buf.WriteByte(0xc0)
+}
+
+func (w *msgpackWriter) writeBool(b bool) {
+	if b {
+		w.buf.WriteByte(0xc3)
+	} else {
+		w.buf.WriteByte(0xc2)
+	}
+}
+
+func (w *msgpackWriter) writeInt(i int64) {
+	switch {
+	case i >= 0:
+		w.writeUint(uint64(i))
+	case i >= -32:
+		w.buf.WriteByte(byte(i))
+	case i >= math.MinInt8:
+		w.buf.Write([]byte{0xd0, byte(i)})
+	case i >= math.MinInt16:
+		w.buf.WriteByte(0xd1)
+		w.buf.Write(binary.BigEndian.AppendUint16(nil, uint16(i)))
+	case i >= math.MinInt32:
+		w.buf.WriteByte(0xd2)
+		w.buf.Write(binary.BigEndian.AppendUint32(nil, uint32(i)))
+	default:
+		w.buf.WriteByte(0xd3)
+		w.buf.Write(binary.BigEndian.AppendUint64(nil, uint64(i)))
+	}
+}
+
+func (w *msgpackWriter) writeUint(u uint64) {
+	switch {
+	case u <= 0x7f:
+		w.buf.WriteByte(byte(u))
+	case u <= math.MaxUint8:
+		w.buf.Write([]byte{0xcc, byte(u)})
+	case u <= math.MaxUint16:
+		w.buf.WriteByte(0xcd)
+		w.buf.Write(binary.BigEndian.AppendUint16(nil, uint16(u)))
+	case u <= math.MaxUint32:
+		w.buf.WriteByte(0xce)
+		w.buf.Write(binary.BigEndian.AppendUint32(nil, uint32(u)))
+	default:
+		w.buf.WriteByte(0xcf)
+		w.buf.Write(binary.BigEndian.AppendUint64(nil, u))
+	}
+}
+
+func (w *msgpackWriter) writeFloat32(f float32) {
+	w.buf.WriteByte(0xca)
+	w.buf.Write(binary.BigEndian.AppendUint32(nil, math.Float32bits(f)))
+}
+
+func (w *msgpackWriter) writeFloat64(f float64) {
+	w.buf.WriteByte(0xcb)
+	w.buf.Write(binary.BigEndian.AppendUint64(nil, math.Float64bits(f)))
+}
+
+// writeLength writes the header for a value with the family of formats that
+// start with fix (if there is one), 8, 16 and 32.
+func (w *msgpackWriter) writeLength(n int, fix byte, fixMax int, b8, b16, b32 byte) {
+	switch {
+	case n <= fixMax:
+		w.buf.WriteByte(fix | byte(n))
+	case b8 != 0 && n <= math.MaxUint8:
+		w.buf.Write([]byte{b8, byte(n)})
+	case n <= math.MaxUint16:
+		w.buf.WriteByte(b16)
+		w.buf.Write(binary.BigEndian.AppendUint16(nil, uint16(n)))
+	default:
+		w.buf.WriteByte(b32)
+		w.buf.Write(binary.BigEndian.AppendUint32(nil, uint32(n)))
+	}
+}
+
+func (w *msgpackWriter) writeString(s string) {
+	w.writeLength(len(s), 0xa0, 31, 0xd9, 0xda, 0xdb)
+	w.buf.WriteString(s)
+}
+
+func (w *msgpackWriter) writeBytes(b []byte) {
+	w.writeLength(len(b), 0, -1, 0xc4, 0xc5, 0xc6)
+	w.buf.Write(b)
+}
+
+// writeTime uses the smallest of the three timestamp extension formats that
+// can hold t.
+func (w *msgpackWriter) writeTime(t time.Time) {
+	sec, nsec := t.Unix(), uint64(t.Nanosecond())
+	switch {
+	case sec>>34 == 0 && nsec == 0 && sec <= math.MaxUint32:
+		w.buf.Write([]byte{0xd6, 0xff})
+		w.buf.Write(binary.BigEndian.AppendUint32(nil, uint32(sec)))
+	case sec>>34 == 0:
+		w.buf.Write([]byte{0xd7, 0xff})
+		w.buf.Write(binary.BigEndian.AppendUint64(nil, nsec<<34|uint64(sec)))
+	default:
+		w.buf.Write([]byte{0xc7, 12, 0xff})
+		w.buf.Write(binary.BigEndian.AppendUint32(nil, uint32(nsec)))
+		w.buf.Write(binary.BigEndian.AppendUint64(nil, uint64(sec)))
+	}
+}
+
+func (w *msgpackWriter) writeArrayHeader(n int) {
+	w.writeLength(n, 0x90, 15, 0, 0xdc, 0xdd)
+}
+
+func (w *msgpackWriter) writeMapHeader(n int) {
+	w.writeLength(n, 0x80, 15, 0, 0xde, 0xdf)
+}
+
+type msgpackReader struct {
+	binaryDepth
+	data []byte
+	pos  int
+}
+
+func (r *msgpackReader) read(n int) ([]byte, error) {
+	if n < 0 || n > len(r.data)-r.pos {
+		return nil, errBinaryUnexpected
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+
+	return b, nil
+}
+
+// readUint reads a big endian unsigned integer of size bytes.
+func (r *msgpackReader) readUint(size int) (uint64, error) {
+	b, err := r.read(size)
+	if err != nil {
+		return 0, err
+	}
+
+	var u uint64
+	for _, c := range b {
+		u = u<<8 | uint64(c)
+	}
+
+	return u, nil
+}
+
+// readLength reads a length of size bytes, making sure it is not larger than
+// the data that is left so that corrupt input cannot cause huge allocations.
+func (r *msgpackReader) readLength(size int) (int, error) {
+	u, err := r.readUint(size)
+	if err != nil {
+		return 0, err
+	}
+	if u > uint64(len(r.data)-r.pos) {
+		return 0, errBinaryUnexpected
+	}
+
+	return int(u), nil
+}
+
+func (r *msgpackReader) next() (binaryToken, error) {
+	b, err := r.read(1)
+	if err != nil {
+		return binaryToken{}, err
+	}
+
+	c := b[0]
+	switch {
+	case c <= 0x7f:
+		return binaryToken{kind: binaryInt, i: int64(c)}, nil
+	case c >= 0xe0:
+		return binaryToken{kind: binaryInt, i: int64(int8(c))}, nil
+	case c >= 0x80 && c <= 0x8f:
+		return binaryToken{kind: binaryMap, n: int(c & 0x0f)}, nil
+	case c >= 0x90 && c <= 0x9f:
+		return binaryToken{kind: binaryArray, n: int(c & 0x0f)}, nil
+	case c >= 0xa0 && c <= 0xbf:
+		return r.readString(binaryString, int(c&0x1f))
+	}
+
+	switch c {
+	case 0xc0:
+		return binaryToken{kind: binaryNil}, nil
+	case 0xc2, 0xc3:
+		return binaryToken{kind: binaryBool, b: c == 0xc3}, nil
+
+	case 0xc4, 0xc5, 0xc6:
+		n, err := r.readLength(1 << (c - 0xc4))
+		if err != nil {
+			return binaryToken{}, err
+		}
+		return r.readString(binaryBytes, n)
+
+	case 0xd9, 0xda, 0xdb:
+		n, err := r.readLength(1 << (c - 0xd9))
+		if err != nil {
+			return binaryToken{}, err
+		}
+		return r.readString(binaryString, n)
+
+	case 0xca:
+		u, err := r.readUint(4)
+		return binaryToken{kind: binaryFloat, f: float64(math.Float32frombits(uint32(u)))}, err
+	case 0xcb:
+		u, err := r.readUint(8)
+		return binaryToken{kind: binaryFloat, f: math.Float64frombits(u)}, err
+
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		u, err := r.readUint(1 << (c - 0xcc))
+		if u <= math.MaxInt64 {
+			return binaryToken{kind: binaryInt, i: int64(u)}, err
+		}
+		return binaryToken{kind: binaryUint, u: u}, err
+
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (c - 0xd0)
+		u, err := r.readUint(size)
+		// Sign extend from the size that was read.
+		shift := 64 - 8*size
+		return binaryToken{kind: binaryInt, i: int64(u<<shift) >> shift}, err
+
+	case 0xdc, 0xdd:
+		n, err := r.readLength(2 << (c - 0xdc))
+		return binaryToken{kind: binaryArray, n: n}, err
+	case 0xde, 0xdf:
+		n, err := r.readLength(2 << (c - 0xde))
+		return binaryToken{kind: binaryMap, n: n}, err
+
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return r.readExt(1 << (c - 0xd4))
+	case 0xc7, 0xc8, 0xc9:
+		n, err := r.readLength(1 << (c - 0xc7))
+		if err != nil {
+			return binaryToken{}, err
+		}
+		return r.readExt(n)
+	}
+
+	return binaryToken{}, fmt.Errorf("orderedmap: invalid MessagePack byte 0x%02x", c)
+}
+
+func (r *msgpackReader) readString(kind binaryKind, n int) (binaryToken, error) {
+	b, err := r.read(n)
+	return binaryToken{kind: kind, s: b}, err
+}
+
+// readExt reads an extension value with n bytes of data. Only the timestamp
+// extension (-1) is supported.
+func (r *msgpackReader) readExt(n int) (binaryToken, error) {
+	typ, err := r.read(1)
+	if err != nil {
+		return binaryToken{}, err
+	}
+	data, err := r.read(n)
+	if err != nil {
+		return binaryToken{}, err
+	}
+	if int8(typ[0]) != -1 {
+		return binaryToken{}, fmt.Errorf("orderedmap: unsupported MessagePack extension type %d", int8(typ[0]))
+	}
+
+	var t time.Time
+	switch n {
+	case 4:
+		t = time.Unix(int64(binary.BigEndian.Uint32(data)), 0)
+	case 8:
+		u := binary.BigEndian.Uint64(data)
+		t = time.Unix(int64(u&(1<<34-1)), int64(u>>34))
+	case 12:
+		t = time.Unix(int64(binary.BigEndian.Uint64(data[4:])),
+			int64(binary.BigEndian.Uint32(data)))
+	default:
+		return binaryToken{}, fmt.Errorf("orderedmap: invalid MessagePack timestamp length %d", n)
+	}
+
+	return binaryToken{kind: binaryTime, t: t}, nil
+}
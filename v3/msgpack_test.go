@@ -0,0 +1,215 @@
+package orderedmap_test
+
+import (
+	"bytes"
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/elliotchance/orderedmap/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderedMap_MarshalMsgpack(t *testing.T) {
+	t.Run("EmptyMap", func(t *testing.T) {
+		data, err := orderedmap.NewOrderedMap[string, int]().MarshalMsgpack()
+		require.NoError(t, err)
+		assert.Equal(t, []byte{0x80}, data)
+	})
+
+	t.Run("NilMap", func(t *testing.T) {
+		var m *orderedmap.OrderedMap[string, int]
+		data, err := m.MarshalMsgpack()
+		require.NoError(t, err)
+		assert.Equal(t, []byte{0xc0}, data)
+	})
+
+	t.Run("RetainsOrder", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, any]()
+		m.Set("z", 1)
+		m.Set("a", -1)
+		m.Set("m", nil)
+		data, err := m.MarshalMsgpack()
+		require.NoError(t, err)
+		assert.Equal(t, []byte{
+			0x83,
+			0xa1, 'z', 0x01,
+			0xa1, 'a', 0xff,
+			0xa1, 'm', 0xc0,
+		}, data)
+	})
+
+	t.Run("SmallestEncoding", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[int, any]()
+		m.Set(200, -100)
+		m.Set(70000, []byte{1})
+		m.Set(-40000, true)
+		data, err := m.MarshalMsgpack()
+		require.NoError(t, err)
+		assert.Equal(t, []byte{
+			0x83,
+			0xcc, 200, 0xd0, 0x9c,
+			0xce, 0x00, 0x01, 0x11, 0x70, 0xc4, 0x01, 0x01,
+			0xd2, 0xff, 0xff, 0x63, 0xc0, 0xc3,
+		}, data)
+	})
+
+	t.Run("Struct", func(t *testing.T) {
+		type point struct {
+			X, Y   int
+			hidden int
+		}
+		m := orderedmap.NewOrderedMap[string, point]()
+		m.Set("p", point{1, 2, 3})
+		data, err := m.MarshalMsgpack()
+		require.NoError(t, err)
+		assert.Equal(t, []byte{
+			0x81, 0xa1, 'p',
+			0x82, 0xa1, 'X', 0x01, 0xa1, 'Y', 0x02,
+		}, data)
+	})
+
+	t.Run("TextMarshalerKey", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[upperKey, int]()
+		m.Set("a", 1)
+		data, err := m.MarshalMsgpack()
+		require.NoError(t, err)
+		assert.Equal(t, []byte{0x81, 0xa1, 'A', 0x01}, data)
+	})
+
+	t.Run("UnsupportedValue", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, any]()
+		m.Set("f", func() {})
+		_, err := m.MarshalMsgpack()
+		assert.Error(t, err)
+	})
+}
+
+func TestOrderedMap_UnmarshalMsgpack(t *testing.T) {
+	t.Run("ZeroValue", func(t *testing.T) {
+		var m orderedmap.OrderedMap[string, int]
+		require.NoError(t, m.UnmarshalMsgpack([]byte{0x82, 0xa1, 'b', 0x01, 0xa1, 'a', 0x02}))
+		assert.Equal(t, []string{"b", "a"}, slices.Collect(m.Keys()))
+	})
+
+	t.Run("Nil", func(t *testing.T) {
+		m := orderedmap.NewOrderedMapWithElements(&orderedmap.Element[string, int]{Key: "a", Value: 1})
+		require.NoError(t, m.UnmarshalMsgpack([]byte{0xc0}))
+		assert.Equal(t, 1, m.Len())
+	})
+
+	t.Run("NestedAny", func(t *testing.T) {
+		inner := orderedmap.NewOrderedMap[string, any]()
+		inner.Set("y", uint64(math.MaxUint64))
+		inner.Set("x", []any{1.5, "s"})
+		m := orderedmap.NewOrderedMap[string, any]()
+		m.Set("inner", inner)
+		m.Set("numbers", orderedmap.NewOrderedMapWithElements(
+			&orderedmap.Element[int, any]{Key: 2, Value: nil},
+			&orderedmap.Element[int, any]{Key: 1, Value: true},
+		))
+		data, err := m.MarshalMsgpack()
+		require.NoError(t, err)
+
+		m2 := orderedmap.NewOrderedMap[string, any]()
+		require.NoError(t, m2.UnmarshalMsgpack(data))
+
+		got := m2.GetOrDefault("inner", nil).(*orderedmap.OrderedMap[string, any])
+		assert.Equal(t, []string{"y", "x"}, slices.Collect(got.Keys()))
+		assert.Equal(t, uint64(math.MaxUint64), got.GetOrDefault("y", nil))
+		assert.Equal(t, []any{1.5, "s"}, got.GetOrDefault("x", nil))
+
+		numbers := m2.GetOrDefault("numbers", nil).(*orderedmap.OrderedMap[any, any])
+		assert.Equal(t, []any{int64(2), int64(1)}, slices.Collect(numbers.Keys()))
+	})
+
+	t.Run("NestedOrderedMap", func(t *testing.T) {
+		inner := orderedmap.NewOrderedMap[string, int]()
+		inner.Set("z", 1)
+		inner.Set("a", 2)
+		m := orderedmap.NewOrderedMap[string, *orderedmap.OrderedMap[string, int]]()
+		m.Set("inner", inner)
+		data, err := m.MarshalMsgpack()
+		require.NoError(t, err)
+
+		m2 := orderedmap.NewOrderedMap[string, *orderedmap.OrderedMap[string, int]]()
+		require.NoError(t, m2.UnmarshalMsgpack(data))
+		assert.Equal(t, []string{"z", "a"}, slices.Collect(m2.GetOrDefault("inner", nil).Keys()))
+	})
+
+	t.Run("Time", func(t *testing.T) {
+		for _, tm := range []time.Time{
+			time.Unix(1700000000, 0),
+			time.Unix(1700000000, 123456789),
+			time.Unix(-1, 5),
+		} {
+			m := orderedmap.NewOrderedMap[string, time.Time]()
+			m.Set("t", tm)
+			data, err := m.MarshalMsgpack()
+			require.NoError(t, err)
+
+			m2 := orderedmap.NewOrderedMap[string, time.Time]()
+			require.NoError(t, m2.UnmarshalMsgpack(data))
+			assert.True(t, tm.Equal(m2.GetOrDefault("t", time.Time{})), tm)
+		}
+	})
+
+	t.Run("Overflow", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, int8]()
+		err := m.UnmarshalMsgpack([]byte{0x81, 0xa1, 'a', 0xcc, 200})
+		assert.ErrorContains(t, err, "overflows int8")
+	})
+
+	t.Run("TypeMismatch", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, int]()
+		err := m.UnmarshalMsgpack([]byte{0x81, 0xa1, 'a', 0xa1, 'b'})
+		assert.ErrorContains(t, err, "cannot decode string into int")
+	})
+
+	t.Run("NotAMap", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, int]()
+		assert.Error(t, m.UnmarshalMsgpack([]byte{0x90}))
+	})
+
+	t.Run("NilKey", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, any]()
+		require.NoError(t, m.UnmarshalMsgpack([]byte{0x81, 0xa1, 'a', 0x81, 0xc0, 0x01}))
+		inner := m.GetOrDefault("a", nil).(*orderedmap.OrderedMap[any, any])
+		assert.Equal(t, int64(1), inner.GetOrDefault(nil, nil))
+	})
+
+	t.Run("UnhashableKey", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[any, any]()
+		assert.ErrorContains(t, m.UnmarshalMsgpack([]byte{0x81, 0x90, 0x01}), "as a map key")
+
+		nested := orderedmap.NewOrderedMap[string, any]()
+		assert.ErrorContains(t, nested.UnmarshalMsgpack([]byte{0x81, 0xa1, 'a', 0x81, 0x90, 0x01}), "as a map key")
+	})
+
+	t.Run("Truncated", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, int]()
+		assert.ErrorContains(t, m.UnmarshalMsgpack([]byte{0x82, 0xa1, 'a', 0x01}), "unexpected end")
+		assert.ErrorContains(t, m.UnmarshalMsgpack([]byte{0xdf, 0xff, 0xff, 0xff, 0xff}), "unexpected end")
+	})
+
+	t.Run("TrailingData", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, int]()
+		assert.ErrorContains(t, m.UnmarshalMsgpack([]byte{0x80, 0x80}), "trailing data")
+	})
+
+	t.Run("MaxDepth", func(t *testing.T) {
+		nested := func(depth int) []byte {
+			data := append([]byte{0x81, 0xa1, 'a'}, bytes.Repeat([]byte{0x91}, depth)...)
+			return append(data, 0xc0)
+		}
+
+		m := orderedmap.NewOrderedMap[string, any]()
+		require.NoError(t, m.UnmarshalMsgpack(nested(100)))
+		assert.ErrorContains(t, m.UnmarshalMsgpack(nested(5_000_000)), "exceeded max depth")
+
+		var typed orderedmap.OrderedMap[string, [][][]int]
+		require.NoError(t, typed.UnmarshalMsgpack(nested(3)))
+	})
+}
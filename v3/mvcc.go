@@ -0,0 +1,265 @@
+package orderedmap
+
+import (
+	"errors"
+	"iter"
+	"runtime"
+	"sync"
+)
+
+// ErrVersionNotFound is returned by SnapshotAt when the version does not exist
+// yet or has already been reclaimed.
+var ErrVersionNotFound = errors.New("orderedmap: version not found")
+
+// MVCCOrderedMap is an ordered map that is safe for concurrent use and keeps
+// multiple versions. Every change creates a new version (numbered from 1), and
+// a snapshot of any version that is still kept can be read while writers
+// continue.
+//
+// Versions are PersistentOrderedMaps that share most of their structure, so
+// each change is O(log n) no matter how many versions are kept. The most
+// recent versions (see NewMVCCOrderedMap) are always kept. An older version is
+// kept for as long as there is a snapshot of it, and is reclaimed once all of
+// its snapshots have been released or garbage collected.
+type MVCCOrderedMap[K comparable, V any] struct {
+	mu       sync.Mutex
+	current  uint64
+	history  uint64
+	versions map[uint64]*mvccVersion[K, V]
+}
+
+type mvccVersion[K comparable, V any] struct {
+	m    *PersistentOrderedMap[K, V]
+	refs int
+}
+
+// MVCCSnapshot is a read-only view of a version of an MVCCOrderedMap. It never
+// changes and is safe for concurrent use.
+//
+// Call Release when the snapshot is no longer needed so that its version can
+// be reclaimed straight away, rather than when the snapshot is garbage
+// collected.
+type MVCCSnapshot[K comparable, V any] struct {
+	m       *PersistentOrderedMap[K, V]
+	version uint64
+	owner   *MVCCOrderedMap[K, V]
+}
+
+// NewMVCCOrderedMap creates an empty map at version 0. The most recent history
+// versions are always kept so that SnapshotAt can find them. history is at
+// least 1 (the current version).
+func NewMVCCOrderedMap[K comparable, V any](history int) *MVCCOrderedMap[K, V] {
+	return &MVCCOrderedMap[K, V]{
+		history: uint64(max(history, 1)),
+		versions: map[uint64]*mvccVersion[K, V]{
+			0: {m: NewPersistentOrderedMap[K, V]()},
+		},
+	}
+}
+
+// Version returns the current version.
+func (m *MVCCOrderedMap[K, V]) Version() uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.current
+}
+
+// KeptVersions returns the number of versions that are kept, which is the most
+// recent versions plus any older versions that still have snapshots. It can be
+// monitored to find snapshots that are never released: if it keeps growing,
+// old versions (and the memory they share) are being held on to.
+func (m *MVCCOrderedMap[K, V]) KeptVersions() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return len(m.versions)
+}
+
+// Get returns the value for a key in the current version. If the key does not
+// exist, the second return parameter will be false and the value will be nil.
+func (m *MVCCOrderedMap[K, V]) Get(key K) (V, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.versions[m.current].m.Get(key)
+}
+
+// Len returns the number of elements in the current version.
+func (m *MVCCOrderedMap[K, V]) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.versions[m.current].m.Len()
+}
+
+// Set will set (or replace) a value for a key, creating a new version. If the
+// key already exists it keeps its position, otherwise it is added to the back.
+// The new version is returned.
+func (m *MVCCOrderedMap[K, V]) Set(key K, value V) uint64 {
+	return m.Batch(func(b *PersistentOrderedMap[K, V]) *PersistentOrderedMap[K, V] {
+		return b.Set(key, value)
+	})
+}
+
+// Delete will remove a key, creating a new version. It returns the new version
+// and true if the key was removed, or the current version and false if the
+// key did not exist.
+func (m *MVCCOrderedMap[K, V]) Delete(key K) (uint64, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	current := m.versions[m.current].m
+	if !current.Has(key) {
+		return m.current, false
+	}
+
+	return m.publish(current.Delete(key)), true
+}
+
+// Batch creates a single new version with all the changes made by fn. fn is
+// called with the current version and returns the new one, for example:
+//
+//	m.Batch(func(b *PersistentOrderedMap[string, int]) *PersistentOrderedMap[string, int] {
+//		return b.Set("a", 1).Set("b", 2).Delete("c")
+//	})
+//
+// Other writers wait for fn to return, so it must not use m. The new version is
+// returned.
+func (m *MVCCOrderedMap[K, V]) Batch(fn func(b *PersistentOrderedMap[K, V]) *PersistentOrderedMap[K, V]) uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.publish(fn(m.versions[m.current].m))
+}
+
+// publish adds a new version and reclaims the version that is no longer one
+// of the most recent, unless there are snapshots of it.
+func (m *MVCCOrderedMap[K, V]) publish(next *PersistentOrderedMap[K, V]) uint64 {
+	m.current++
+	m.versions[m.current] = &mvccVersion[K, V]{m: next}
+
+	if m.current >= m.history {
+		old := m.current - m.history
+		if v, ok := m.versions[old]; ok && v.refs == 0 {
+			delete(m.versions, old)
+		}
+	}
+
+	return m.current
+}
+
+// Snapshot returns a snapshot of the current version.
+func (m *MVCCOrderedMap[K, V]) Snapshot() *MVCCSnapshot[K, V] {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.snapshot(m.current)
+}
+
+// SnapshotAt returns a snapshot of a version. It returns ErrVersionNotFound if
+// the version has been reclaimed, or has not been created yet.
+func (m *MVCCOrderedMap[K, V]) SnapshotAt(version uint64) (*MVCCSnapshot[K, V], error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.versions[version]; !ok {
+		return nil, ErrVersionNotFound
+	}
+
+	return m.snapshot(version), nil
+}
+
+func (m *MVCCOrderedMap[K, V]) snapshot(version uint64) *MVCCSnapshot[K, V] {
+	v := m.versions[version]
+	v.refs++
+
+	s := &MVCCSnapshot[K, V]{m: v.m, version: version, owner: m}
+	runtime.SetFinalizer(s, (*MVCCSnapshot[K, V]).Release)
+
+	return s
+}
+
+// release is called when a snapshot of version is released.
+func (m *MVCCOrderedMap[K, V]) release(version uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	v := m.versions[version]
+	v.refs--
+	if v.refs == 0 && version+m.history <= m.current {
+		delete(m.versions, version)
+	}
+}
+
+// Version returns the version of the map that the snapshot is of.
+func (s *MVCCSnapshot[K, V]) Version() uint64 {
+	return s.version
+}
+
+// Release allows the version to be reclaimed if there are no other snapshots
+// of it. The snapshot must not be used afterwards. Calling Release more than
+// once has no effect.
+func (s *MVCCSnapshot[K, V]) Release() {
+	if s.owner == nil {
+		return
+	}
+
+	runtime.SetFinalizer(s, nil)
+	s.owner.release(s.version)
+	s.owner = nil
+	s.m = nil
+}
+
+// Get returns the value for a key at the version of the snapshot. If the key
+// does not exist, the second return parameter will be false and the value will
+// be nil.
+func (s *MVCCSnapshot[K, V]) Get(key K) (V, bool) {
+	return s.m.Get(key)
+}
+
+// GetOrDefault returns the value for a key at the version of the snapshot. If
+// the key does not exist, returns the default value instead.
+func (s *MVCCSnapshot[K, V]) GetOrDefault(key K, defaultValue V) V {
+	return s.m.GetOrDefault(key, defaultValue)
+}
+
+// Has checks if a key existed at the version of the snapshot.
+func (s *MVCCSnapshot[K, V]) Has(key K) bool {
+	return s.m.Has(key)
+}
+
+// Len returns the number of elements at the version of the snapshot.
+func (s *MVCCSnapshot[K, V]) Len() int {
+	return s.m.Len()
+}
+
+// AllFromFront returns an iterator that yields all elements that existed at
+// the version of the snapshot, in the order they had, starting at the front.
+func (s *MVCCSnapshot[K, V]) AllFromFront() iter.Seq2[K, V] {
+	return s.m.AllFromFront()
+}
+
+// AllFromBack returns an iterator that yields all elements that existed at the
+// version of the snapshot, in the order they had, starting at the back.
+func (s *MVCCSnapshot[K, V]) AllFromBack() iter.Seq2[K, V] {
+	return s.m.AllFromBack()
+}
+
+// Keys returns an iterator that yields all the keys at the version of the
+// snapshot, in order.
+func (s *MVCCSnapshot[K, V]) Keys() iter.Seq[K] {
+	return s.m.Keys()
+}
+
+// Values returns an iterator that yields all the values at the version of the
+// snapshot, in order.
+func (s *MVCCSnapshot[K, V]) Values() iter.Seq[V] {
+	return s.m.Values()
+}
+
+// Copy returns a new OrderedMap with the elements at the version of the
+// snapshot.
+func (s *MVCCSnapshot[K, V]) Copy() *OrderedMap[K, V] {
+	return s.m.Copy()
+}
@@ -0,0 +1,184 @@
+package orderedmap_test
+
+import (
+	"math/rand"
+	"runtime"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/elliotchance/orderedmap/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMVCCOrderedMap(t *testing.T) {
+	t.Run("Versions", func(t *testing.T) {
+		m := orderedmap.NewMVCCOrderedMap[string, int](10)
+		assert.Equal(t, uint64(0), m.Version())
+		assert.Equal(t, uint64(1), m.Set("a", 1))
+		assert.Equal(t, uint64(2), m.Set("b", 2))
+		assert.Equal(t, uint64(3), m.Set("a", 3))
+
+		version, ok := m.Delete("x")
+		assert.False(t, ok)
+		assert.Equal(t, uint64(3), version)
+		version, ok = m.Delete("a")
+		assert.True(t, ok)
+		assert.Equal(t, uint64(4), version)
+
+		assert.Equal(t, uint64(4), m.Version())
+		assert.Equal(t, 1, m.Len())
+		value, ok := m.Get("b")
+		assert.True(t, ok)
+		assert.Equal(t, 2, value)
+	})
+
+	t.Run("SnapshotAt", func(t *testing.T) {
+		m := orderedmap.NewMVCCOrderedMap[string, int](10)
+		m.Set("a", 1)
+		m.Set("b", 2)
+		m.Set("a", 3)
+		m.Delete("a")
+		m.Set("a", 4)
+
+		expected := []struct {
+			keys   []string
+			values []int
+		}{
+			{nil, nil},
+			{[]string{"a"}, []int{1}},
+			{[]string{"a", "b"}, []int{1, 2}},
+			{[]string{"a", "b"}, []int{3, 2}},
+			{[]string{"b"}, []int{2}},
+			{[]string{"b", "a"}, []int{2, 4}},
+		}
+		for version, e := range expected {
+			s, err := m.SnapshotAt(uint64(version))
+			require.NoError(t, err)
+			assert.Equal(t, uint64(version), s.Version())
+			assert.Equal(t, e.keys, slices.Collect(s.Keys()))
+			assert.Equal(t, e.values, slices.Collect(s.Values()))
+			assert.Equal(t, len(e.keys), s.Len())
+			s.Release()
+		}
+
+		_, err := m.SnapshotAt(6)
+		assert.ErrorIs(t, err, orderedmap.ErrVersionNotFound)
+	})
+
+	t.Run("Batch", func(t *testing.T) {
+		m := orderedmap.NewMVCCOrderedMap[string, int](10)
+		version := m.Batch(func(b *orderedmap.PersistentOrderedMap[string, int]) *orderedmap.PersistentOrderedMap[string, int] {
+			return b.Set("a", 1).Set("b", 2).Set("c", 3).Delete("b")
+		})
+		assert.Equal(t, uint64(1), version)
+
+		s := m.Snapshot()
+		defer s.Release()
+		assert.Equal(t, []string{"a", "c"}, slices.Collect(s.Keys()))
+	})
+
+	t.Run("SnapshotDoesNotChange", func(t *testing.T) {
+		m := orderedmap.NewMVCCOrderedMap[string, int](1)
+		m.Set("a", 1)
+		s := m.Snapshot()
+		defer s.Release()
+
+		m.Set("a", 2)
+		m.Set("b", 3)
+		m.Delete("a")
+
+		assert.Equal(t, []string{"a"}, slices.Collect(s.Keys()))
+		assert.Equal(t, 1, s.GetOrDefault("a", 0))
+		assert.False(t, s.Has("b"))
+		assert.Equal(t, []string{"b"}, slices.Collect(m.Snapshot().Keys()))
+	})
+
+	t.Run("History", func(t *testing.T) {
+		m := orderedmap.NewMVCCOrderedMap[string, int](3)
+		for i := 0; i < 10; i++ {
+			m.Set(strconv.Itoa(i), i)
+		}
+
+		for version := uint64(0); version <= 10; version++ {
+			s, err := m.SnapshotAt(version)
+			if version < 8 {
+				assert.ErrorIs(t, err, orderedmap.ErrVersionNotFound, version)
+				continue
+			}
+			require.NoError(t, err)
+			assert.Equal(t, int(version), s.Len())
+			s.Release()
+		}
+		assert.Equal(t, 3, m.KeptVersions())
+	})
+
+	t.Run("SnapshotKeepsVersion", func(t *testing.T) {
+		m := orderedmap.NewMVCCOrderedMap[string, int](1)
+		m.Set("a", 1)
+		s1 := m.Snapshot()
+		s2, err := m.SnapshotAt(1)
+		require.NoError(t, err)
+
+		m.Set("b", 2)
+		m.Set("c", 3)
+		assert.Equal(t, 2, m.KeptVersions())
+
+		s1.Release()
+		s1.Release()
+		s3, err := m.SnapshotAt(1)
+		require.NoError(t, err)
+		s3.Release()
+
+		s2.Release()
+		assert.Equal(t, 1, m.KeptVersions())
+		_, err = m.SnapshotAt(1)
+		assert.ErrorIs(t, err, orderedmap.ErrVersionNotFound)
+	})
+
+	t.Run("UnreachableSnapshotIsReleased", func(t *testing.T) {
+		m := orderedmap.NewMVCCOrderedMap[string, int](1)
+		m.Set("a", 1)
+		_ = m.Snapshot()
+		m.Set("b", 2)
+
+		// Finalizers run after the collection, so wait for the count of kept
+		// versions to drop rather than taking a new snapshot of version 1.
+		for i := 0; i < 100 && m.KeptVersions() > 1; i++ {
+			runtime.GC()
+			time.Sleep(time.Millisecond)
+		}
+		assert.Equal(t, 1, m.KeptVersions())
+		_, err := m.SnapshotAt(1)
+		assert.ErrorIs(t, err, orderedmap.ErrVersionNotFound)
+	})
+
+	t.Run("Concurrent", func(t *testing.T) {
+		m := orderedmap.NewMVCCOrderedMap[int, int](5)
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r := rand.New(rand.NewSource(1))
+			for i := 0; i < 1000; i++ {
+				if r.Intn(3) == 0 {
+					m.Delete(r.Intn(50))
+				} else {
+					m.Set(r.Intn(50), i)
+				}
+			}
+		}()
+
+		for i := 0; i < 100; i++ {
+			s := m.Snapshot()
+			keys := slices.Collect(s.Keys())
+			assert.Equal(t, s.Len(), len(keys))
+			assert.Equal(t, keys, slices.Collect(s.Copy().Keys()))
+			s.Release()
+		}
+		wg.Wait()
+	})
+}
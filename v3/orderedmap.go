@@ -5,6 +5,12 @@ import "iter"
 type OrderedMap[K comparable, V any] struct {
 	kv map[K]*Element[K, V]
 	ll list[K, V]
+
+	// yaml is only allocated once YAML comments are used.
+	yaml *yamlState[K, V]
+
+	// journal is only allocated once EnableJournal is called.
+	journal *journal[K, V]
 }
 
 func NewOrderedMap[K comparable, V any]() *OrderedMap[K, V] {
@@ -21,6 +27,14 @@ func NewOrderedMapWithCapacity[K comparable, V any](capacity int) *OrderedMap[K,
 	}
 }
 
+// lazyInit allocates the internal map for an OrderedMap that was not created
+// with one of the constructors, such as the zero value created by a decoder.
+func (m *OrderedMap[K, V]) lazyInit() {
+	if m.kv == nil {
+		m.kv = make(map[K]*Element[K, V])
+	}
+}
+
 func NewOrderedMapWithElements[K comparable, V any](els ...*Element[K, V]) *OrderedMap[K, V] {
 	om := NewOrderedMapWithCapacity[K, V](len(els))
 	for _, el := range els {
@@ -44,27 +58,98 @@ func (m *OrderedMap[K, V]) Get(key K) (value V, ok bool) {
 // will be returned. The returned value will be false if the value was replaced
 // (even if the value was the same).
 func (m *OrderedMap[K, V]) Set(key K, value V) bool {
-	_, alreadyExist := m.kv[key]
-	if alreadyExist {
-		m.kv[key].Value = value
+	if element, alreadyExist := m.kv[key]; alreadyExist {
+		if m.journal != nil {
+			m.journal.record(journalEntry[K, V]{op: journalUpdate, key: key, old: element.Value, value: value})
+		}
+		element.Value = value
 		return false
 	}
 
+	if m.journal != nil {
+		e := journalEntry[K, V]{op: journalInsert, key: key, value: value}
+		if back := m.ll.Back(); back != nil {
+			e.after, e.hasAfter = back.Key, true
+		}
+		m.journal.record(e)
+	}
+
 	element := m.ll.PushBack(key, value)
 	m.kv[key] = element
 	return true
 }
 
+// SetFront will set a value for a key, and put it at the front of the map. If
+// the key already exists its value is replaced and it is moved to the front.
+// The element for the key is returned.
+func (m *OrderedMap[K, V]) SetFront(key K, value V) *Element[K, V] {
+	return m.setAfter(key, value, nil)
+}
+
+// InsertBefore will set a value for a key, and put it just before the element
+// for mark. If the key already exists its value is replaced and it is moved
+// (the element remains the same). The element for the key is returned, or nil
+// if mark does not exist, in which case the map is not changed.
+//
+// If key and mark are the same the value is replaced in place.
+func (m *OrderedMap[K, V]) InsertBefore(mark, key K, value V) *Element[K, V] {
+	markElement, ok := m.kv[mark]
+	if !ok {
+		return nil
+	}
+
+	return m.setAfter(key, value, markElement.Prev())
+}
+
+// InsertAfter will set a value for a key, and put it just after the element for
+// mark. If the key already exists its value is replaced and it is moved (the
+// element remains the same). The element for the key is returned, or nil if
+// mark does not exist, in which case the map is not changed.
+//
+// If key and mark are the same the value is replaced in place.
+func (m *OrderedMap[K, V]) InsertAfter(mark, key K, value V) *Element[K, V] {
+	markElement, ok := m.kv[mark]
+	if !ok {
+		return nil
+	}
+
+	return m.setAfter(key, value, markElement)
+}
+
+// setAfter sets the value for key and puts it after mark, or at the front if
+// mark is nil.
+func (m *OrderedMap[K, V]) setAfter(key K, value V, mark *Element[K, V]) *Element[K, V] {
+	if element, alreadyExist := m.kv[key]; alreadyExist {
+		if element == mark || element.Prev() == mark {
+			m.Set(key, value)
+		} else {
+			m.relink(element, mark, value)
+		}
+		return element
+	}
+
+	if m.journal != nil {
+		e := journalEntry[K, V]{op: journalInsert, key: key, value: value}
+		if mark != nil {
+			e.after, e.hasAfter = mark.Key, true
+		}
+		m.journal.record(e)
+	}
+
+	element := &Element[K, V]{Key: key, Value: value}
+	m.ll.InsertAfter(element, mark)
+	m.kv[key] = element
+	return element
+}
+
 // ReplaceKey replaces an existing key with a new key while preserving order of
 // the value. This function will return true if the operation was successful, or
 // false if 'originalKey' is not found OR 'newKey' already exists (which would be an overwrite).
 func (m *OrderedMap[K, V]) ReplaceKey(originalKey, newKey K) bool {
-	element, originalExists := m.kv[originalKey]
+	_, originalExists := m.kv[originalKey]
 	_, newKeyExists := m.kv[newKey]
 	if originalExists && !newKeyExists {
-		delete(m.kv, originalKey)
-		m.kv[newKey] = element
-		element.Key = newKey
+		m.renameKeys([]K{originalKey}, []K{newKey})
 		return true
 	}
 	return false
@@ -151,8 +236,16 @@ func (m *OrderedMap[K, V]) Values() iter.Seq[V] {
 func (m *OrderedMap[K, V]) Delete(key K) (didDelete bool) {
 	element, ok := m.kv[key]
 	if ok {
+		if m.journal != nil {
+			e := journalEntry[K, V]{op: journalDelete, key: key, value: element.Value}
+			e.after, e.hasAfter = journalPosition(element)
+			m.journal.record(e)
+		}
 		m.ll.Remove(element)
 		delete(m.kv, key)
+		if m.yaml != nil {
+			delete(m.yaml.elements, element)
+		}
 	}
 
 	return ok
@@ -278,6 +278,116 @@ func TestOrderedMap_Back(t *testing.T) {
 	})
 }
 
+func TestOrderedMap_SetFront(t *testing.T) {
+	t.Run("NewKey", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, int]()
+		element := m.SetFront("b", 2)
+		assert.Equal(t, "b", element.Key)
+		m.SetFront("a", 1)
+		m.Set("c", 3)
+		assertOrderedMap(t, m, []string{"a", "b", "c"}, []int{1, 2, 3})
+		assert.Same(t, element, m.GetElement("b"))
+	})
+
+	t.Run("ExistingKeyIsMoved", func(t *testing.T) {
+		m := newABCD()
+		element := m.GetElement("c")
+		assert.Same(t, element, m.SetFront("c", 30))
+		assertOrderedMap(t, m, []string{"c", "a", "b", "d"}, []int{30, 1, 2, 4})
+
+		assert.Same(t, element, m.SetFront("c", 31))
+		assertOrderedMap(t, m, []string{"c", "a", "b", "d"}, []int{31, 1, 2, 4})
+	})
+}
+
+func TestOrderedMap_InsertBefore(t *testing.T) {
+	t.Run("NewKey", func(t *testing.T) {
+		m := newABCD()
+		element := m.InsertBefore("c", "x", 9)
+		require.NotNil(t, element)
+		assert.Equal(t, 9, element.Value)
+		assert.Same(t, element, m.GetElement("x"))
+		assertOrderedMap(t, m, []string{"a", "b", "x", "c", "d"}, []int{1, 2, 9, 3, 4})
+
+		m.InsertBefore("a", "y", 8)
+		assertOrderedMap(t, m, []string{"y", "a", "b", "x", "c", "d"}, []int{8, 1, 2, 9, 3, 4})
+	})
+
+	t.Run("ExistingKeyIsMoved", func(t *testing.T) {
+		m := newABCD()
+		element := m.GetElement("d")
+		assert.Same(t, element, m.InsertBefore("b", "d", 40))
+		assertOrderedMap(t, m, []string{"a", "d", "b", "c"}, []int{1, 40, 2, 3})
+	})
+
+	t.Run("SameKeyUpdatesInPlace", func(t *testing.T) {
+		m := newABCD()
+		m.InsertBefore("b", "b", 20)
+		m.InsertBefore("c", "b", 21)
+		assertOrderedMap(t, m, []string{"a", "b", "c", "d"}, []int{1, 21, 3, 4})
+	})
+
+	t.Run("MissingMark", func(t *testing.T) {
+		m := newABCD()
+		assert.Nil(t, m.InsertBefore("x", "y", 9))
+		assert.Nil(t, m.InsertBefore("x", "a", 9))
+		assertOrderedMap(t, m, []string{"a", "b", "c", "d"}, []int{1, 2, 3, 4})
+	})
+}
+
+func TestOrderedMap_InsertAfter(t *testing.T) {
+	t.Run("NewKey", func(t *testing.T) {
+		m := newABCD()
+		m.InsertAfter("b", "x", 9)
+		assertOrderedMap(t, m, []string{"a", "b", "x", "c", "d"}, []int{1, 2, 9, 3, 4})
+
+		element := m.InsertAfter("d", "y", 8)
+		assert.Same(t, element, m.Back())
+		m.Set("z", 7)
+		assertOrderedMap(t, m, []string{"a", "b", "x", "c", "d", "y", "z"}, []int{1, 2, 9, 3, 4, 8, 7})
+	})
+
+	t.Run("ExistingKeyIsMoved", func(t *testing.T) {
+		m := newABCD()
+		element := m.GetElement("a")
+		assert.Same(t, element, m.InsertAfter("c", "a", 10))
+		assertOrderedMap(t, m, []string{"b", "c", "a", "d"}, []int{2, 3, 10, 4})
+	})
+
+	t.Run("SameKeyUpdatesInPlace", func(t *testing.T) {
+		m := newABCD()
+		m.InsertAfter("b", "b", 20)
+		m.InsertAfter("a", "b", 21)
+		assertOrderedMap(t, m, []string{"a", "b", "c", "d"}, []int{1, 21, 3, 4})
+	})
+
+	t.Run("MissingMark", func(t *testing.T) {
+		m := newABCD()
+		assert.Nil(t, m.InsertAfter("x", "y", 9))
+		assertOrderedMap(t, m, []string{"a", "b", "c", "d"}, []int{1, 2, 3, 4})
+	})
+
+	t.Run("Undo", func(t *testing.T) {
+		m := newABCD()
+		m.EnableJournal(0)
+		m.InsertAfter("a", "x", 9)
+		m.InsertAfter("c", "a", 10)
+		m.SetFront("b", 20)
+
+		require.True(t, m.Undo())
+		assertOrderedMap(t, m, []string{"x", "b", "c", "a", "d"}, []int{9, 2, 3, 10, 4})
+		require.True(t, m.Undo())
+		assertOrderedMap(t, m, []string{"a", "x", "b", "c", "d"}, []int{1, 9, 2, 3, 4})
+		require.True(t, m.Undo())
+		assertOrderedMap(t, m, []string{"a", "b", "c", "d"}, []int{1, 2, 3, 4})
+
+		require.True(t, m.Redo())
+		require.True(t, m.Redo())
+		require.True(t, m.Redo())
+		assertOrderedMap(t, m, []string{"b", "x", "c", "a", "d"}, []int{20, 9, 3, 10, 4})
+	})
+}
+
 func TestOrderedMap_Copy(t *testing.T) {
 	t.Run("ReturnsEqualButNotSame", func(t *testing.T) {
 		key, value := 1, "a value"
@@ -352,30 +462,22 @@ func TestIterations(t *testing.T) {
 }
 
 func TestIterators(t *testing.T) {
-	type Element struct {
-		Key   int
-		Value bool
-	}
-	m := orderedmap.NewOrderedMap[int, bool]()
-	expected := []Element{{5, true}, {3, false}, {1, false}, {4, true}}
-	for _, v := range expected {
-		m.Set(v.Key, v.Value)
-	}
+	m, keys, values := newIteratorsFixture()
 
 	t.Run("Iterator", func(t *testing.T) {
 		i := 0
 		for key, value := range m.AllFromFront() {
-			assert.Equal(t, expected[i].Key, key)
-			assert.Equal(t, expected[i].Value, value)
+			assert.Equal(t, keys[i], key)
+			assert.Equal(t, values[i], value)
 			i++
 		}
 	})
 
 	t.Run("ReverseIterator", func(t *testing.T) {
-		i := len(expected) - 1
+		i := len(keys) - 1
 		for key, value := range m.AllFromBack() {
-			assert.Equal(t, expected[i].Key, key)
-			assert.Equal(t, expected[i].Value, value)
+			assert.Equal(t, keys[i], key)
+			assert.Equal(t, values[i], value)
 			i--
 		}
 	})
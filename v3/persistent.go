@@ -0,0 +1,480 @@
+package orderedmap
+
+import (
+	"iter"
+	"math/bits"
+)
+
+// PersistentOrderedMap is an immutable ordered map. Set and Delete do not
+// change the map; they return a new map that shares most of its structure
+// with the old one, so keeping many versions is cheap and each of them can be
+// read concurrently without locks.
+//
+// Keys are found with a hash array mapped trie (HAMT) and the order is kept in
+// a balanced tree of the keys sorted by when they were first set, so Get, Set
+// and Delete are O(log n).
+//
+// The zero value (or a nil pointer) is an empty map.
+type PersistentOrderedMap[K comparable, V any] struct {
+	keys  *hamtNode[K]
+	order *avlNode[K, V]
+	len   int
+
+	// seq is the sequence number that will be given to the next new key.
+	seq uint64
+
+	// hash is nil to use persistentHasher.
+	hash func(K) uint64
+}
+
+// persistentHasher hashes the keys of all persistent maps. It is shared so
+// that the zero value can be used and all versions agree on the hashes.
+var persistentHasher = newKeyHasher()
+
+func NewPersistentOrderedMap[K comparable, V any]() *PersistentOrderedMap[K, V] {
+	return &PersistentOrderedMap[K, V]{}
+}
+
+// NewPersistentOrderedMapWithHash creates an empty map that uses hash to find
+// keys, instead of hashing them with hash/maphash (see NewShardedOrderedMap).
+// Keys that are equal must have the same hash. Maps returned by Set and Delete
+// use the same hash.
+func NewPersistentOrderedMapWithHash[K comparable, V any](hash func(K) uint64) *PersistentOrderedMap[K, V] {
+	return &PersistentOrderedMap[K, V]{hash: hash}
+}
+
+func (m *PersistentOrderedMap[K, V]) hashKey(key K) uint64 {
+	if m.hash != nil {
+		return m.hash(key)
+	}
+
+	return hashKey(persistentHasher, key)
+}
+
+// Get returns the value for a key. If the key does not exist, the second return
+// parameter will be false and the value will be nil.
+func (m *PersistentOrderedMap[K, V]) Get(key K) (value V, ok bool) {
+	if m == nil {
+		return
+	}
+
+	seq, ok := m.keys.get(m.hashKey(key), key, 0)
+	if !ok {
+		return
+	}
+
+	return m.order.get(seq).value, true
+}
+
+// GetOrDefault returns the value for a key. If the key does not exist, returns
+// the default value instead.
+func (m *PersistentOrderedMap[K, V]) GetOrDefault(key K, defaultValue V) V {
+	if value, ok := m.Get(key); ok {
+		return value
+	}
+
+	return defaultValue
+}
+
+// Has checks if a key exists in the map.
+func (m *PersistentOrderedMap[K, V]) Has(key K) bool {
+	if m == nil {
+		return false
+	}
+
+	_, ok := m.keys.get(m.hashKey(key), key, 0)
+
+	return ok
+}
+
+// Len returns the number of elements in the map.
+func (m *PersistentOrderedMap[K, V]) Len() int {
+	if m == nil {
+		return 0
+	}
+
+	return m.len
+}
+
+// Set returns a new map where key has value. If the key already exists, it
+// keeps its position, otherwise it is added to the back. m is not changed.
+func (m *PersistentOrderedMap[K, V]) Set(key K, value V) *PersistentOrderedMap[K, V] {
+	if m == nil {
+		m = &PersistentOrderedMap[K, V]{}
+	}
+
+	hash := m.hashKey(key)
+	if seq, ok := m.keys.get(hash, key, 0); ok {
+		return &PersistentOrderedMap[K, V]{
+			keys:  m.keys,
+			order: m.order.update(seq, value),
+			len:   m.len,
+			seq:   m.seq,
+			hash:  m.hash,
+		}
+	}
+
+	return &PersistentOrderedMap[K, V]{
+		keys:  m.keys.set(hash, key, m.seq, 0),
+		order: m.order.insert(m.seq, key, value),
+		len:   m.len + 1,
+		seq:   m.seq + 1,
+		hash:  m.hash,
+	}
+}
+
+// Delete returns a new map without key. If the key does not exist, m itself is
+// returned. m is not changed.
+func (m *PersistentOrderedMap[K, V]) Delete(key K) *PersistentOrderedMap[K, V] {
+	if m == nil {
+		return m
+	}
+
+	hash := m.hashKey(key)
+	seq, ok := m.keys.get(hash, key, 0)
+	if !ok {
+		return m
+	}
+
+	return &PersistentOrderedMap[K, V]{
+		keys:  m.keys.delete(hash, key, 0),
+		order: m.order.delete(seq),
+		len:   m.len - 1,
+		seq:   m.seq,
+		hash:  m.hash,
+	}
+}
+
+// AllFromFront returns an iterator that yields all elements in the map starting
+// at the front (oldest Set element).
+func (m *PersistentOrderedMap[K, V]) AllFromFront() iter.Seq2[K, V] {
+	return func(yield func(key K, value V) bool) {
+		if m != nil {
+			m.order.ascend(yield)
+		}
+	}
+}
+
+// AllFromBack returns an iterator that yields all elements in the map starting
+// at the back (most recent Set element).
+func (m *PersistentOrderedMap[K, V]) AllFromBack() iter.Seq2[K, V] {
+	return func(yield func(key K, value V) bool) {
+		if m != nil {
+			m.order.descend(yield)
+		}
+	}
+}
+
+// Keys returns an iterator that yields all the keys in the map starting at the
+// front (oldest Set element).
+func (m *PersistentOrderedMap[K, V]) Keys() iter.Seq[K] {
+	return func(yield func(key K) bool) {
+		for key := range m.AllFromFront() {
+			if !yield(key) {
+				return
+			}
+		}
+	}
+}
+
+// Values returns an iterator that yields all the values in the map starting at
+// the front (oldest Set element).
+func (m *PersistentOrderedMap[K, V]) Values() iter.Seq[V] {
+	return func(yield func(value V) bool) {
+		for _, value := range m.AllFromFront() {
+			if !yield(value) {
+				return
+			}
+		}
+	}
+}
+
+// Copy returns a new (mutable) OrderedMap with the same elements.
+func (m *PersistentOrderedMap[K, V]) Copy() *OrderedMap[K, V] {
+	m2 := NewOrderedMapWithCapacity[K, V](m.Len())
+	for key, value := range m.AllFromFront() {
+		m2.Set(key, value)
+	}
+
+	return m2
+}
+
+const (
+	hamtBits = 5
+	hamtMask = 1<<hamtBits - 1
+)
+
+// hamtNode maps keys to their sequence numbers. Each level uses the next 5
+// bits of the hash to pick one of 32 slots, and only the slots that are used
+// are allocated (bitmap has a bit set for each of them).
+type hamtNode[K comparable] struct {
+	bitmap uint32
+	slots  []hamtSlot[K]
+}
+
+// hamtSlot is either a child node or a leaf. Slots are kept small because
+// every change copies the slots of each node on the path.
+type hamtSlot[K comparable] struct {
+	node *hamtNode[K]
+	leaf *hamtLeaf[K]
+}
+
+// hamtLeaf holds the keys with the same hash. There is more than one only if
+// their full hashes collide.
+type hamtLeaf[K comparable] struct {
+	hash    uint64
+	entries []hamtEntry[K]
+}
+
+type hamtEntry[K comparable] struct {
+	key K
+	seq uint64
+}
+
+// slot returns the bit for the hash at shift, and the index of its slot.
+func (n *hamtNode[K]) slot(hash uint64, shift uint) (uint32, int) {
+	bit := uint32(1) << ((hash >> shift) & hamtMask)
+
+	return bit, bits.OnesCount32(n.bitmap & (bit - 1))
+}
+
+func (n *hamtNode[K]) get(hash uint64, key K, shift uint) (uint64, bool) {
+	for n != nil {
+		bit, i := n.slot(hash, shift)
+		if n.bitmap&bit == 0 {
+			return 0, false
+		}
+
+		s := n.slots[i]
+		if s.leaf != nil {
+			if s.leaf.hash == hash {
+				for _, e := range s.leaf.entries {
+					if e.key == key {
+						return e.seq, true
+					}
+				}
+			}
+			return 0, false
+		}
+
+		n = s.node
+		shift += hamtBits
+	}
+
+	return 0, false
+}
+
+// set returns a copy of n with key added. key must not already exist.
+func (n *hamtNode[K]) set(hash uint64, key K, seq uint64, shift uint) *hamtNode[K] {
+	entry := hamtEntry[K]{key: key, seq: seq}
+	if n == nil {
+		n = &hamtNode[K]{}
+	}
+
+	bit, i := n.slot(hash, shift)
+	if n.bitmap&bit == 0 {
+		slots := make([]hamtSlot[K], len(n.slots)+1)
+		copy(slots, n.slots[:i])
+		slots[i] = hamtSlot[K]{leaf: &hamtLeaf[K]{hash: hash, entries: []hamtEntry[K]{entry}}}
+		copy(slots[i+1:], n.slots[i:])
+
+		return &hamtNode[K]{bitmap: n.bitmap | bit, slots: slots}
+	}
+
+	s := n.slots[i]
+	switch {
+	case s.node != nil:
+		s.node = s.node.set(hash, key, seq, shift+hamtBits)
+
+	case s.leaf.hash == hash:
+		entries := append(s.leaf.entries[:len(s.leaf.entries):len(s.leaf.entries)], entry)
+		s.leaf = &hamtLeaf[K]{hash: hash, entries: entries}
+
+	default:
+		// Push the existing leaf down a level, which is repeated until the
+		// hashes differ.
+		child := &hamtNode[K]{}
+		child.bitmap, _ = child.slot(s.leaf.hash, shift+hamtBits)
+		child.slots = []hamtSlot[K]{s}
+		s = hamtSlot[K]{node: child.set(hash, key, seq, shift+hamtBits)}
+	}
+
+	slots := append([]hamtSlot[K](nil), n.slots...)
+	slots[i] = s
+
+	return &hamtNode[K]{bitmap: n.bitmap, slots: slots}
+}
+
+// delete returns a copy of n without key, which must exist. It returns nil if
+// the node would be empty.
+func (n *hamtNode[K]) delete(hash uint64, key K, shift uint) *hamtNode[K] {
+	bit, i := n.slot(hash, shift)
+	s := n.slots[i]
+
+	if s.node != nil {
+		s.node = s.node.delete(hash, key, shift+hamtBits)
+
+		// A child with a single leaf is replaced by the leaf itself.
+		if s.node != nil && len(s.node.slots) == 1 && s.node.slots[0].leaf != nil {
+			s = s.node.slots[0]
+		}
+	} else if len(s.leaf.entries) > 1 {
+		entries := make([]hamtEntry[K], 0, len(s.leaf.entries)-1)
+		for _, e := range s.leaf.entries {
+			if e.key != key {
+				entries = append(entries, e)
+			}
+		}
+		s.leaf = &hamtLeaf[K]{hash: hash, entries: entries}
+	} else {
+		s.leaf = nil
+	}
+
+	if s.node == nil && s.leaf == nil {
+		if len(n.slots) == 1 {
+			return nil
+		}
+
+		slots := make([]hamtSlot[K], 0, len(n.slots)-1)
+		slots = append(slots, n.slots[:i]...)
+		slots = append(slots, n.slots[i+1:]...)
+
+		return &hamtNode[K]{bitmap: n.bitmap &^ bit, slots: slots}
+	}
+
+	slots := append([]hamtSlot[K](nil), n.slots...)
+	slots[i] = s
+
+	return &hamtNode[K]{bitmap: n.bitmap, slots: slots}
+}
+
+// avlNode is a node of an AVL tree sorted by seq, which is the order of the
+// map. Nodes are never changed once they are created; every change creates
+// new nodes along the path from the root.
+type avlNode[K comparable, V any] struct {
+	left, right *avlNode[K, V]
+	height      int
+	seq         uint64
+	key         K
+	value       V
+}
+
+func (n *avlNode[K, V]) getHeight() int {
+	if n == nil {
+		return 0
+	}
+
+	return n.height
+}
+
+// with returns a copy of n with new children, rebalanced if needed.
+func (n *avlNode[K, V]) with(left, right *avlNode[K, V]) *avlNode[K, V] {
+	c := *n
+	c.left, c.right = left, right
+	c.height = max(left.getHeight(), right.getHeight()) + 1
+
+	switch balance := left.getHeight() - right.getHeight(); {
+	case balance > 1:
+		if left.left.getHeight() < left.right.getHeight() {
+			left = left.right.rotate(left, false)
+		}
+		return left.rotate(&c, true)
+
+	case balance < -1:
+		if right.right.getHeight() < right.left.getHeight() {
+			right = right.left.rotate(right, true)
+		}
+		return right.rotate(&c, false)
+	}
+
+	return &c
+}
+
+// rotate makes pivot, a child of parent, the new root of the subtree. right is
+// true if parent moves to the right of pivot.
+func (pivot *avlNode[K, V]) rotate(parent *avlNode[K, V], right bool) *avlNode[K, V] {
+	p, c := *parent, *pivot
+	if right {
+		p.left = c.right
+		c.right = &p
+	} else {
+		p.right = c.left
+		c.left = &p
+	}
+	p.height = max(p.left.getHeight(), p.right.getHeight()) + 1
+	c.height = max(c.left.getHeight(), c.right.getHeight()) + 1
+
+	return &c
+}
+
+func (n *avlNode[K, V]) get(seq uint64) *avlNode[K, V] {
+	for n != nil && n.seq != seq {
+		if seq < n.seq {
+			n = n.left
+		} else {
+			n = n.right
+		}
+	}
+
+	return n
+}
+
+// insert adds a new node, seq must be greater than every seq in the tree.
+func (n *avlNode[K, V]) insert(seq uint64, key K, value V) *avlNode[K, V] {
+	if n == nil {
+		return &avlNode[K, V]{height: 1, seq: seq, key: key, value: value}
+	}
+
+	return n.with(n.left, n.right.insert(seq, key, value))
+}
+
+func (n *avlNode[K, V]) update(seq uint64, value V) *avlNode[K, V] {
+	c := *n
+	switch {
+	case seq < n.seq:
+		c.left = n.left.update(seq, value)
+	case seq > n.seq:
+		c.right = n.right.update(seq, value)
+	default:
+		c.value = value
+	}
+
+	return &c
+}
+
+func (n *avlNode[K, V]) delete(seq uint64) *avlNode[K, V] {
+	switch {
+	case seq < n.seq:
+		return n.with(n.left.delete(seq), n.right)
+	case seq > n.seq:
+		return n.with(n.left, n.right.delete(seq))
+	case n.left == nil:
+		return n.right
+	case n.right == nil:
+		return n.left
+	}
+
+	// Replace n with the first node of its right subtree.
+	next := n.right
+	for next.left != nil {
+		next = next.left
+	}
+
+	return next.with(n.left, n.right.delete(next.seq))
+}
+
+func (n *avlNode[K, V]) ascend(yield func(K, V) bool) bool {
+	if n == nil {
+		return true
+	}
+
+	return n.left.ascend(yield) && yield(n.key, n.value) && n.right.ascend(yield)
+}
+
+func (n *avlNode[K, V]) descend(yield func(K, V) bool) bool {
+	if n == nil {
+		return true
+	}
+
+	return n.right.descend(yield) && yield(n.key, n.value) && n.left.descend(yield)
+}
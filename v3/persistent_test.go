@@ -0,0 +1,157 @@
+package orderedmap_test
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/elliotchance/orderedmap/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPersistentOrderedMap(t *testing.T) {
+	t.Run("ZeroValueIsEmpty", func(t *testing.T) {
+		var m *orderedmap.PersistentOrderedMap[string, int]
+		assert.Equal(t, 0, m.Len())
+		assert.False(t, m.Has("a"))
+		assert.Empty(t, slices.Collect(m.Keys()))
+		assert.Nil(t, m.Delete("a"))
+
+		m = m.Set("a", 1)
+		assert.Equal(t, 1, m.GetOrDefault("a", 0))
+	})
+
+	t.Run("VersionsAreIndependent", func(t *testing.T) {
+		v1 := orderedmap.NewPersistentOrderedMap[string, int]().Set("a", 1).Set("b", 2)
+		v2 := v1.Set("c", 3).Set("a", 4)
+		v3 := v2.Delete("b")
+
+		assert.Equal(t, []string{"a", "b"}, slices.Collect(v1.Keys()))
+		assert.Equal(t, []int{1, 2}, slices.Collect(v1.Values()))
+		assert.Equal(t, []string{"a", "b", "c"}, slices.Collect(v2.Keys()))
+		assert.Equal(t, []int{4, 2, 3}, slices.Collect(v2.Values()))
+		assert.Equal(t, []string{"a", "c"}, slices.Collect(v3.Keys()))
+		assert.Equal(t, 2, v3.Len())
+		assert.Same(t, v3, v3.Delete("b"))
+
+		var back []string
+		for key := range v2.AllFromBack() {
+			back = append(back, key)
+		}
+		assert.Equal(t, []string{"c", "b", "a"}, back)
+	})
+
+	t.Run("DeleteAndSetMovesToBack", func(t *testing.T) {
+		m := orderedmap.NewPersistentOrderedMap[string, int]().Set("a", 1).Set("b", 2)
+		m = m.Delete("a").Set("a", 3)
+		assert.Equal(t, []string{"b", "a"}, slices.Collect(m.Keys()))
+	})
+
+	t.Run("HashCollisions", func(t *testing.T) {
+		// int(1) and int64(1) are different keys with the same hash.
+		m := orderedmap.NewPersistentOrderedMap[any, string]().Set(1, "int").Set(int64(1), "int64")
+		assert.Equal(t, "int", m.GetOrDefault(1, ""))
+		assert.Equal(t, "int64", m.GetOrDefault(int64(1), ""))
+
+		m = m.Delete(1)
+		assert.False(t, m.Has(1))
+		assert.Equal(t, "int64", m.GetOrDefault(int64(1), ""))
+	})
+
+	t.Run("CompositeKeysWithFloats", func(t *testing.T) {
+		type point struct{ X float64 }
+		m := orderedmap.NewPersistentOrderedMap[point, int]().
+			Set(point{0}, 1).
+			Set(point{negativeZero()}, 2)
+		assert.Equal(t, 1, m.Len())
+		assert.Equal(t, []point{{0}}, slices.Collect(m.Keys()))
+		assert.Equal(t, []int{2}, slices.Collect(m.Values()))
+		assert.Equal(t, 0, m.Delete(point{negativeZero()}).Len())
+	})
+
+	t.Run("WithHash", func(t *testing.T) {
+		// Every key has the same hash, so they are all in one leaf.
+		m := orderedmap.NewPersistentOrderedMapWithHash[string, int](func(key string) uint64 {
+			return 7
+		})
+		m2 := m.Set("a", 1).Set("b", 2).Set("c", 3).Delete("b").Set("a", 4)
+		assert.Equal(t, []string{"a", "c"}, slices.Collect(m2.Keys()))
+		assert.Equal(t, []int{4, 3}, slices.Collect(m2.Values()))
+		assert.True(t, m2.Has("c"))
+		assert.False(t, m2.Has("b"))
+		assert.Equal(t, 0, m.Len())
+	})
+
+	t.Run("StopIteration", func(t *testing.T) {
+		m := orderedmap.NewPersistentOrderedMap[int, int]()
+		for i := 0; i < 10; i++ {
+			m = m.Set(i, i)
+		}
+
+		var keys []int
+		for key := range m.AllFromBack() {
+			if key < 7 {
+				break
+			}
+			keys = append(keys, key)
+		}
+		assert.Equal(t, []int{9, 8, 7}, keys)
+	})
+
+	t.Run("MatchesOrderedMap", func(t *testing.T) {
+		r := rand.New(rand.NewSource(1))
+		want := orderedmap.NewOrderedMap[int, int]()
+		m := orderedmap.NewPersistentOrderedMap[int, int]()
+
+		var versions []*orderedmap.PersistentOrderedMap[int, int]
+		var copies []*orderedmap.OrderedMap[int, int]
+		for i := 0; i < 5000; i++ {
+			key := r.Intn(500)
+			if r.Intn(3) == 0 {
+				want.Delete(key)
+				m = m.Delete(key)
+			} else {
+				want.Set(key, i)
+				m = m.Set(key, i)
+			}
+
+			if i%500 == 0 {
+				versions = append(versions, m)
+				copies = append(copies, want.Copy())
+			}
+		}
+
+		require.Equal(t, want.Len(), m.Len())
+		assert.Equal(t, slices.Collect(want.Keys()), slices.Collect(m.Keys()))
+		assert.Equal(t, slices.Collect(want.Values()), slices.Collect(m.Values()))
+		for key, value := range want.AllFromFront() {
+			assert.Equal(t, value, m.GetOrDefault(key, -1))
+		}
+
+		// Older versions were not changed.
+		for i, v := range versions {
+			assert.Equal(t, slices.Collect(copies[i].Keys()), slices.Collect(v.Keys()))
+			assert.Equal(t, slices.Collect(copies[i].Values()), slices.Collect(v.Values()))
+		}
+	})
+}
+
+func BenchmarkPersistentOrderedMap_Set(b *testing.B) {
+	m := orderedmap.NewPersistentOrderedMap[int, bool]()
+	for i := 0; i < b.N; i++ {
+		m = m.Set(i, true)
+	}
+}
+
+func BenchmarkPersistentOrderedMap_Get(b *testing.B) {
+	m := orderedmap.NewPersistentOrderedMap[int, bool]()
+	for i := 0; i < 1000; i++ {
+		m = m.Set(i, true)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.Get(i % 1000)
+	}
+}
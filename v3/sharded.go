@@ -0,0 +1,386 @@
+package orderedmap
+
+import (
+	"container/heap"
+	"hash/maphash"
+	"iter"
+	"math"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// ShardedOrderedMap is an ordered map that is safe for concurrent use and
+// spreads its keys over several shards, each with its own lock, so that writes
+// to different shards do not wait for each other.
+//
+// Every new key is given a sequence number from a single counter, so the map
+// still has one global order: the order in which keys were first set, across
+// all shards. Iterating merges the shards back into that order.
+//
+// Unlike SyncOrderedMap, there are no operations that span more than one key,
+// and the zero value is not usable; use NewShardedOrderedMap.
+type ShardedOrderedMap[K comparable, V any] struct {
+	shards []shard[K, V]
+	hash   func(K) uint64
+	seq    atomic.Uint64
+	len    atomic.Int64
+}
+
+type shard[K comparable, V any] struct {
+	mu sync.RWMutex
+	m  *OrderedMap[K, shardEntry[V]]
+}
+
+// shardEntry is a value with the sequence number of its key. Sequence numbers
+// are assigned while holding the shard lock, so they always increase from the
+// front to the back of each shard.
+type shardEntry[V any] struct {
+	seq   uint64
+	value V
+}
+
+// NewShardedOrderedMap creates a map with the specified number of shards. A
+// good number of shards is a small multiple of GOMAXPROCS.
+//
+// Keys are hashed with hash/maphash. Strings and ints are hashed directly;
+// other key types (such as structs) are hashed by walking their fields with
+// reflect, which is slower. Use NewShardedOrderedMapWithHash to provide a
+// faster hash for those.
+func NewShardedOrderedMap[K comparable, V any](shards int) *ShardedOrderedMap[K, V] {
+	h := newKeyHasher()
+
+	return NewShardedOrderedMapWithHash[K, V](shards, func(key K) uint64 {
+		return hashKey(h, key)
+	})
+}
+
+// NewShardedOrderedMapWithHash creates a map with the specified number of
+// shards that uses hash to choose the shard of each key. Keys that are equal
+// must have the same hash.
+func NewShardedOrderedMapWithHash[K comparable, V any](shards int, hash func(K) uint64) *ShardedOrderedMap[K, V] {
+	if shards < 1 {
+		panic("orderedmap: the number of shards must be at least 1")
+	}
+
+	m := &ShardedOrderedMap[K, V]{
+		shards: make([]shard[K, V], shards),
+		hash:   hash,
+	}
+	for i := range m.shards {
+		m.shards[i].m = NewOrderedMap[K, shardEntry[V]]()
+	}
+
+	return m
+}
+
+// keyHasher is the random state used to hash keys by NewShardedOrderedMap and
+// PersistentOrderedMap.
+type keyHasher struct {
+	seed maphash.Seed
+	salt uint64
+}
+
+func newKeyHasher() keyHasher {
+	seed := maphash.MakeSeed()
+
+	return keyHasher{seed: seed, salt: maphash.String(seed, "")}
+}
+
+// hashKey hashes any comparable key, see NewShardedOrderedMap.
+func hashKey[K comparable](h keyHasher, key K) uint64 {
+	switch key := any(key).(type) {
+	case string:
+		return maphash.String(h.seed, key)
+	case int:
+		return h.uint64(uint64(key))
+	}
+
+	return hashValue(h, reflect.ValueOf(key))
+}
+
+// hashValue hashes a comparable value. Composite values are hashed from their
+// parts, so that values that are == always have the same hash even when their
+// representations differ (such as a struct containing -0 and 0).
+func hashValue(h keyHasher, v reflect.Value) uint64 {
+	switch v.Kind() {
+	case reflect.String:
+		return maphash.String(h.seed, v.String())
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return h.uint64(uint64(v.Int()))
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64, reflect.Uintptr:
+		return h.uint64(v.Uint())
+
+	case reflect.Float32, reflect.Float64:
+		// Adding zero turns -0 into 0, since they are equal keys.
+		return h.uint64(math.Float64bits(v.Float() + 0))
+
+	case reflect.Complex64, reflect.Complex128:
+		c := v.Complex()
+		return h.uint64(math.Float64bits(real(c)+0) ^ h.uint64(math.Float64bits(imag(c)+0)))
+
+	case reflect.Bool:
+		if v.Bool() {
+			return h.uint64(1)
+		}
+		return h.uint64(0)
+
+	case reflect.Pointer, reflect.Chan, reflect.UnsafePointer:
+		return h.uint64(uint64(v.Pointer()))
+
+	case reflect.Interface:
+		// Interfaces are equal when their dynamic values are equal, and
+		// values of different types are allowed to have the same hash.
+		if v.IsNil() {
+			return h.uint64(0)
+		}
+		return hashValue(h, v.Elem())
+
+	case reflect.Array:
+		var n uint64
+		for i := range v.Len() {
+			n = h.uint64(n ^ hashValue(h, v.Index(i)))
+		}
+		return n
+
+	case reflect.Struct:
+		// Blank fields are ignored by ==.
+		var n uint64
+		for i := range v.NumField() {
+			if v.Type().Field(i).Name != "_" {
+				n = h.uint64(n ^ hashValue(h, v.Field(i)))
+			}
+		}
+		return n
+	}
+
+	// Only comparable values can be keys, so this is unreachable.
+	panic("orderedmap: cannot hash value of type " + v.Type().String())
+}
+
+// uint64 mixes n with the salt (this is the finalizer of SplitMix64), which is
+// much faster than maphash for a single integer.
+func (h keyHasher) uint64(n uint64) uint64 {
+	n ^= h.salt
+	n = (n ^ (n >> 30)) * 0xbf58476d1ce4e5b9
+	n = (n ^ (n >> 27)) * 0x94d049bb133111eb
+
+	return n ^ (n >> 31)
+}
+
+func (m *ShardedOrderedMap[K, V]) shard(key K) *shard[K, V] {
+	return &m.shards[m.hash(key)%uint64(len(m.shards))]
+}
+
+// Get returns the value for a key. If the key does not exist, the second return
+// parameter will be false and the value will be nil.
+func (m *ShardedOrderedMap[K, V]) Get(key K) (value V, ok bool) {
+	s := m.shard(key)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entry, ok := s.m.Get(key)
+
+	return entry.value, ok
+}
+
+// GetOrDefault returns the value for a key. If the key does not exist, returns
+// the default value instead.
+func (m *ShardedOrderedMap[K, V]) GetOrDefault(key K, defaultValue V) V {
+	if value, ok := m.Get(key); ok {
+		return value
+	}
+
+	return defaultValue
+}
+
+// Has checks if a key exists in the map.
+func (m *ShardedOrderedMap[K, V]) Has(key K) bool {
+	s := m.shard(key)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.m.Has(key)
+}
+
+// Len returns the number of elements in the map.
+func (m *ShardedOrderedMap[K, V]) Len() int {
+	return int(m.len.Load())
+}
+
+// Set will set (or replace) a value for a key. If the key was new, then true
+// will be returned. The returned value will be false if the value was replaced
+// (even if the value was the same).
+func (m *ShardedOrderedMap[K, V]) Set(key K, value V) bool {
+	s := m.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if el := s.m.GetElement(key); el != nil {
+		el.Value.value = value
+		return false
+	}
+
+	s.m.Set(key, shardEntry[V]{seq: m.seq.Add(1), value: value})
+	m.len.Add(1)
+
+	return true
+}
+
+// Delete will remove a key from the map. It will return true if the key was
+// removed (the key did exist).
+func (m *ShardedOrderedMap[K, V]) Delete(key K) (didDelete bool) {
+	s := m.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.m.Delete(key) {
+		return false
+	}
+	m.len.Add(-1)
+
+	return true
+}
+
+// Copy returns a new OrderedMap with the same elements in the same order.
+func (m *ShardedOrderedMap[K, V]) Copy() *OrderedMap[K, V] {
+	m2 := NewOrderedMapWithCapacity[K, V](m.Len())
+	for key, value := range m.AllFromFront() {
+		m2.Set(key, value)
+	}
+
+	return m2
+}
+
+// AllFromFront returns an iterator that yields all elements in the map
+// starting at the front (oldest Set element) by merging the shards.
+//
+// The shards are merged into a copy while their read locks are held, and the
+// elements are yielded after they are unlocked, so any method (including
+// changes) can be used inside the loop. The loop sees the map as it was when it
+// started.
+func (m *ShardedOrderedMap[K, V]) AllFromFront() iter.Seq2[K, V] {
+	return func(yield func(key K, value V) bool) {
+		for _, el := range m.merged() {
+			if !yield(el.Key, el.Value) {
+				return
+			}
+		}
+	}
+}
+
+// AllFromBack returns an iterator that yields all elements in the map starting
+// at the back (most recent Set element). See AllFromFront.
+func (m *ShardedOrderedMap[K, V]) AllFromBack() iter.Seq2[K, V] {
+	return func(yield func(key K, value V) bool) {
+		elements := m.merged()
+		for i := len(elements) - 1; i >= 0; i-- {
+			if !yield(elements[i].Key, elements[i].Value) {
+				return
+			}
+		}
+	}
+}
+
+// merged returns a copy of the elements of every shard, from the front.
+func (m *ShardedOrderedMap[K, V]) merged() []Element[K, V] {
+	m.rlockAll()
+	defer m.runlockAll()
+
+	h := &shardHeap[K, V]{}
+	for i := range m.shards {
+		if el := m.shards[i].m.Front(); el != nil {
+			h.elements = append(h.elements, el)
+		}
+	}
+
+	return h.merge(make([]Element[K, V], 0, m.Len()))
+}
+
+// Keys returns an iterator that yields all the keys in the map starting at the
+// front (oldest Set element). See AllFromFront.
+func (m *ShardedOrderedMap[K, V]) Keys() iter.Seq[K] {
+	return func(yield func(key K) bool) {
+		for key := range m.AllFromFront() {
+			if !yield(key) {
+				return
+			}
+		}
+	}
+}
+
+// Values returns an iterator that yields all the values in the map starting at
+// the front (oldest Set element). See AllFromFront.
+func (m *ShardedOrderedMap[K, V]) Values() iter.Seq[V] {
+	return func(yield func(value V) bool) {
+		for _, value := range m.AllFromFront() {
+			if !yield(value) {
+				return
+			}
+		}
+	}
+}
+
+// rlockAll locks every shard for reading. Writers only ever hold one shard
+// lock, so taking them in order cannot deadlock.
+func (m *ShardedOrderedMap[K, V]) rlockAll() {
+	for i := range m.shards {
+		m.shards[i].mu.RLock()
+	}
+}
+
+func (m *ShardedOrderedMap[K, V]) runlockAll() {
+	for i := range m.shards {
+		m.shards[i].mu.RUnlock()
+	}
+}
+
+// shardHeap is a heap of the next element of each shard, ordered by sequence
+// number, used to merge the shards (a k-way merge).
+type shardHeap[K comparable, V any] struct {
+	elements []*Element[K, shardEntry[V]]
+}
+
+func (h *shardHeap[K, V]) Len() int {
+	return len(h.elements)
+}
+
+func (h *shardHeap[K, V]) Less(i, j int) bool {
+	return h.elements[i].Value.seq < h.elements[j].Value.seq
+}
+
+func (h *shardHeap[K, V]) Swap(i, j int) {
+	h.elements[i], h.elements[j] = h.elements[j], h.elements[i]
+}
+
+func (h *shardHeap[K, V]) Push(x any) {
+	h.elements = append(h.elements, x.(*Element[K, shardEntry[V]]))
+}
+
+func (h *shardHeap[K, V]) Pop() any {
+	el := h.elements[len(h.elements)-1]
+	h.elements = h.elements[:len(h.elements)-1]
+
+	return el
+}
+
+// merge appends the elements to merged in order and returns it.
+func (h *shardHeap[K, V]) merge(merged []Element[K, V]) []Element[K, V] {
+	heap.Init(h)
+	for h.Len() > 0 {
+		el := h.elements[0]
+		merged = append(merged, Element[K, V]{Key: el.Key, Value: el.Value.value})
+
+		if n := el.Next(); n != nil {
+			h.elements[0] = n
+			heap.Fix(h, 0)
+		} else {
+			heap.Pop(h)
+		}
+	}
+
+	return merged
+}
@@ -0,0 +1,261 @@
+package orderedmap_test
+
+import (
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/elliotchance/orderedmap/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShardedOrderedMap(t *testing.T) {
+	t.Run("GlobalOrder", func(t *testing.T) {
+		m := orderedmap.NewShardedOrderedMap[int, string](4)
+		for i := 0; i < 100; i++ {
+			assert.True(t, m.Set(i, "v"))
+		}
+		assert.False(t, m.Set(50, "w"))
+		assert.True(t, m.Delete(10))
+		assert.False(t, m.Delete(10))
+
+		var want []int
+		for i := 0; i < 100; i++ {
+			if i != 10 {
+				want = append(want, i)
+			}
+		}
+		assert.Equal(t, want, slices.Collect(m.Keys()))
+		assert.Equal(t, 99, m.Len())
+		assert.Equal(t, "w", m.GetOrDefault(50, ""))
+		assert.False(t, m.Has(10))
+
+		var back []int
+		for key := range m.AllFromBack() {
+			back = append(back, key)
+		}
+		slices.Reverse(want)
+		assert.Equal(t, want, back)
+	})
+
+	t.Run("StopIteration", func(t *testing.T) {
+		m := orderedmap.NewShardedOrderedMap[string, int](3)
+		m.Set("c", 1)
+		m.Set("a", 2)
+		m.Set("b", 3)
+
+		var keys []string
+		for key := range m.AllFromFront() {
+			keys = append(keys, key)
+			if key == "a" {
+				break
+			}
+		}
+		assert.Equal(t, []string{"c", "a"}, keys)
+		assert.Equal(t, []int{1, 2, 3}, slices.Collect(m.Values()))
+	})
+
+	t.Run("UseInsideLoop", func(t *testing.T) {
+		m := orderedmap.NewShardedOrderedMap[string, int](4)
+		m.Set("a", 1)
+		m.Set("b", 2)
+
+		var keys []string
+		for key, value := range m.AllFromFront() {
+			// A writer waiting for a shard lock would block this Get if the
+			// iterator still held the read locks.
+			written := make(chan struct{})
+			go func() {
+				m.Set(key+key, value)
+				close(written)
+			}()
+			select {
+			case <-written:
+			case <-time.After(time.Second):
+				t.Fatal("Set is blocked by the iterator")
+			}
+
+			assert.Equal(t, value, m.GetOrDefault(key+key, 0))
+			keys = append(keys, key)
+		}
+		assert.Equal(t, []string{"a", "b"}, keys)
+		assert.Equal(t, []string{"a", "b", "aa", "bb"}, slices.Collect(m.Keys()))
+
+		for key := range m.AllFromBack() {
+			m.Delete(key)
+		}
+		assert.Equal(t, 0, m.Len())
+	})
+
+	t.Run("Copy", func(t *testing.T) {
+		m := orderedmap.NewShardedOrderedMap[string, int](2)
+		m.Set("b", 1)
+		m.Set("a", 2)
+
+		c := m.Copy()
+		assert.Equal(t, []string{"b", "a"}, slices.Collect(c.Keys()))
+	})
+
+	t.Run("KeyTypes", func(t *testing.T) {
+		type point struct{ x, y int }
+		m := orderedmap.NewShardedOrderedMap[point, int](8)
+		m.Set(point{1, 2}, 1)
+		m.Set(point{3, 4}, 2)
+		assert.Equal(t, 1, m.GetOrDefault(point{1, 2}, 0))
+
+		f := orderedmap.NewShardedOrderedMap[float64, int](8)
+		f.Set(0, 1)
+		assert.True(t, f.Has(negativeZero()))
+	})
+
+	t.Run("CompositeKeysWithFloats", func(t *testing.T) {
+		type point struct {
+			X float64
+			_ int
+			C complex128
+			A [2]any
+		}
+		negative := point{X: negativeZero(), C: complex(negativeZero(), 1), A: [2]any{negativeZero(), "a"}}
+		positive := point{X: 0, C: complex(0, 1), A: [2]any{0.0, "a"}}
+		require.True(t, negative == positive)
+
+		for i := 0; i < 50; i++ {
+			m := orderedmap.NewShardedOrderedMap[point, int](64)
+			m.Set(positive, 1)
+			m.Set(negative, 2)
+			require.Equal(t, 1, m.Len())
+			require.Equal(t, 2, m.GetOrDefault(positive, 0))
+		}
+	})
+
+	t.Run("CustomHash", func(t *testing.T) {
+		m := orderedmap.NewShardedOrderedMapWithHash[string, int](4, func(key string) uint64 {
+			return uint64(len(key))
+		})
+		m.Set("aa", 1)
+		m.Set("b", 2)
+		assert.Equal(t, []string{"aa", "b"}, slices.Collect(m.Keys()))
+	})
+
+	t.Run("Concurrent", func(t *testing.T) {
+		m := orderedmap.NewShardedOrderedMap[int, int](8)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 1000; j++ {
+					m.Set(i*1000+j, j)
+				}
+			}()
+		}
+		for i := 0; i < 2; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 20; j++ {
+					// Keys set by the same goroutine must stay in order.
+					last := map[int]int{}
+					for key := range m.AllFromFront() {
+						if prev, ok := last[key/1000]; ok {
+							assert.Less(t, prev, key)
+						}
+						last[key/1000] = key
+					}
+				}
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, 8000, m.Len())
+	})
+}
+
+func negativeZero() float64 {
+	zero := 0.0
+	return -zero
+}
+
+// The following benchmarks compare the concurrent maps under parallel load.
+
+func BenchmarkSyncOrderedMap_SetParallel(b *testing.B) {
+	m := orderedmap.NewSyncOrderedMap[int, bool]()
+	b.RunParallel(func(pb *testing.PB) {
+		for i := 0; pb.Next(); i++ {
+			m.Set(i%1000, true)
+		}
+	})
+}
+
+func BenchmarkShardedOrderedMap_SetParallel(b *testing.B) {
+	m := orderedmap.NewShardedOrderedMap[int, bool](32)
+	b.RunParallel(func(pb *testing.PB) {
+		for i := 0; pb.Next(); i++ {
+			m.Set(i%1000, true)
+		}
+	})
+}
+
+func BenchmarkSyncOrderedMap_GetParallel(b *testing.B) {
+	m := orderedmap.NewSyncOrderedMap[int, bool]()
+	for i := 0; i < 1000; i++ {
+		m.Set(i, true)
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for i := 0; pb.Next(); i++ {
+			m.Get(i % 1000)
+		}
+	})
+}
+
+func BenchmarkShardedOrderedMap_GetParallel(b *testing.B) {
+	m := orderedmap.NewShardedOrderedMap[int, bool](32)
+	for i := 0; i < 1000; i++ {
+		m.Set(i, true)
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for i := 0; pb.Next(); i++ {
+			m.Get(i % 1000)
+		}
+	})
+}
+
+func BenchmarkSyncOrderedMap_SetDeleteParallel(b *testing.B) {
+	m := orderedmap.NewSyncOrderedMap[int, bool]()
+	b.RunParallel(func(pb *testing.PB) {
+		for i := 0; pb.Next(); i++ {
+			m.Set(i%1000, true)
+			m.Delete((i + 500) % 1000)
+		}
+	})
+}
+
+func BenchmarkShardedOrderedMap_SetDeleteParallel(b *testing.B) {
+	m := orderedmap.NewShardedOrderedMap[int, bool](32)
+	b.RunParallel(func(pb *testing.PB) {
+		for i := 0; pb.Next(); i++ {
+			m.Set(i%1000, true)
+			m.Delete((i + 500) % 1000)
+		}
+	})
+}
+
+func BenchmarkShardedOrderedMap_Iterate(b *testing.B) {
+	m := orderedmap.NewShardedOrderedMap[int, bool](32)
+	for i := 0; i < 1000; i++ {
+		m.Set(i, true)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for range m.AllFromFront() {
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package orderedmap
+
+import (
+	"cmp"
+	"slices"
+)
+
+// SortFunc sorts the elements of the map in the order defined by cmp, which
+// returns a negative number if a should be before b, a positive number if a
+// should be after b, and zero if their order does not matter. The sort is not
+// guaranteed to be stable.
+//
+// The elements are relinked rather than recreated, so a pointer returned by
+// GetElement remains valid. The sort takes O(n log n) time and does not
+// allocate (unless the journal or index are enabled).
+func (m *OrderedMap[K, V]) SortFunc(cmp func(a, b *Element[K, V]) int) {
+	m.sort(cmp)
+}
+
+// SortStableFunc sorts the elements of the map in the order defined by cmp,
+// like SortFunc, while keeping the original order of elements for which cmp
+// returns zero.
+func (m *OrderedMap[K, V]) SortStableFunc(cmp func(a, b *Element[K, V]) int) {
+	m.sort(cmp)
+}
+
+// SortByKey sorts the elements of the map in ascending order of their keys.
+func SortByKey[K cmp.Ordered, V any](m *OrderedMap[K, V]) {
+	m.sort(func(a, b *Element[K, V]) int {
+		return cmp.Compare(a.Key, b.Key)
+	})
+}
+
+func (m *OrderedMap[K, V]) sort(cmp func(a, b *Element[K, V]) int) {
+	if m.journal == nil {
+		m.ll.Sort(cmp)
+		return
+	}
+
+	from := slices.Collect(m.Keys())
+	m.ll.Sort(cmp)
+	if to := slices.Collect(m.Keys()); !slices.Equal(from, to) {
+		m.journal.record(journalEntry[K, V]{op: journalReorder, from: from, to: to})
+	}
+}
@@ -0,0 +1,122 @@
+package orderedmap_test
+
+import (
+	"cmp"
+	"math/rand"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/elliotchance/orderedmap/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderedMap_SortFunc(t *testing.T) {
+	t.Run("Empty", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, int]()
+		m.SortFunc(func(a, b *orderedmap.Element[string, int]) int {
+			return cmp.Compare(a.Value, b.Value)
+		})
+		assertOrderedMap[string, int](t, m, nil, nil)
+	})
+
+	t.Run("ByValue", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, int]()
+		m.Set("a", 3)
+		m.Set("b", 1)
+		m.Set("c", 4)
+		m.Set("d", 2)
+		element := m.GetElement("c")
+
+		m.SortFunc(func(a, b *orderedmap.Element[string, int]) int {
+			return cmp.Compare(b.Value, a.Value)
+		})
+		assertOrderedMap(t, m, []string{"c", "a", "d", "b"}, []int{4, 3, 2, 1})
+		assert.Same(t, element, m.Front())
+		assert.Same(t, element, m.GetElement("c"))
+		assert.Equal(t, "b", m.Back().Key)
+
+		var keysFromBack []string
+		for key := range m.AllFromBack() {
+			keysFromBack = append(keysFromBack, key)
+		}
+		assert.Equal(t, []string{"b", "d", "a", "c"}, keysFromBack)
+
+		m.Set("e", 0)
+		assertOrderedMap(t, m, []string{"c", "a", "d", "b", "e"}, []int{4, 3, 2, 1, 0})
+	})
+
+	t.Run("Random", func(t *testing.T) {
+		r := rand.New(rand.NewSource(1))
+		for _, n := range []int{1, 2, 3, 7, 64, 100, 1000} {
+			m := orderedmap.NewOrderedMap[int, int]()
+			m.EnableIndex()
+			for i := 0; i < n; i++ {
+				m.Set(i, r.Intn(n))
+			}
+
+			m.SortFunc(func(a, b *orderedmap.Element[int, int]) int {
+				return cmp.Compare(a.Value, b.Value)
+			})
+
+			values := slices.Collect(m.Values())
+			assert.Len(t, values, n)
+			assert.True(t, slices.IsSorted(values))
+			for i, key := range slices.Collect(m.Keys()) {
+				require.Equal(t, i, m.IndexOf(key))
+			}
+		}
+	})
+}
+
+func TestOrderedMap_SortStableFunc(t *testing.T) {
+	m := orderedmap.NewOrderedMap[int, string]()
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 500; i++ {
+		m.Set(i, strconv.Itoa(r.Intn(10)))
+	}
+
+	m.SortStableFunc(func(a, b *orderedmap.Element[int, string]) int {
+		return cmp.Compare(a.Value, b.Value)
+	})
+
+	var prev *orderedmap.Element[int, string]
+	for el := m.Front(); el != nil; el = el.Next() {
+		if prev != nil {
+			require.LessOrEqual(t, prev.Value, el.Value)
+			if prev.Value == el.Value {
+				require.Less(t, prev.Key, el.Key)
+			}
+		}
+		prev = el
+	}
+}
+
+func TestSortByKey(t *testing.T) {
+	m := orderedmap.NewOrderedMap[string, int]()
+	m.Set("c", 3)
+	m.Set("a", 1)
+	m.Set("d", 4)
+	m.Set("b", 2)
+
+	orderedmap.SortByKey(m)
+	assertOrderedMap(t, m, []string{"a", "b", "c", "d"}, []int{1, 2, 3, 4})
+
+	t.Run("Undo", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, int]()
+		m.Set("c", 3)
+		m.Set("a", 1)
+		m.Set("b", 2)
+		m.EnableJournal(0)
+
+		orderedmap.SortByKey(m)
+		orderedmap.SortByKey(m) // already sorted, not recorded
+		require.True(t, m.Undo())
+		assertOrderedMap(t, m, []string{"c", "a", "b"}, []int{3, 1, 2})
+		assert.False(t, m.Undo())
+
+		require.True(t, m.Redo())
+		assertOrderedMap(t, m, []string{"a", "b", "c"}, []int{1, 2, 3})
+	})
+}
@@ -0,0 +1,275 @@
+package orderedmap
+
+import (
+	"cmp"
+	"iter"
+	"math/bits"
+)
+
+// sortedMaxLevel is enough levels for 4^32 elements.
+const sortedMaxLevel = 32
+
+// SortedMap is a map that keeps its elements sorted by key, rather than in
+// insertion order. It has the same methods as OrderedMap (where they make
+// sense), and also supports finding the nearest keys with Floor and Ceiling,
+// and iterating over a range of keys with Range.
+//
+// A SortedMap is a skip list, so Get, Set and Delete are O(log n). The bottom
+// level of the skip list is a linked list of the elements, so they can be
+// iterated with Next and Prev in the same way as an OrderedMap.
+type SortedMap[K comparable, V any] struct {
+	cmp func(a, b K) int
+
+	// head is the first node of every level. Its element is not used.
+	head  sortedNode[K, V]
+	level int
+	ll    list[K, V]
+	len   int
+
+	levels keyHasher
+	seq    uint64
+}
+
+type sortedNode[K comparable, V any] struct {
+	Element[K, V]
+
+	// next is the next node on each level that this node is part of.
+	next []*sortedNode[K, V]
+}
+
+// NewSortedMap creates a map that is sorted by key in ascending order.
+func NewSortedMap[K cmp.Ordered, V any]() *SortedMap[K, V] {
+	return NewSortedMapWithCompare[K, V](cmp.Compare[K])
+}
+
+// NewSortedMapWithCompare creates a map that is sorted by key in the order
+// defined by compare, which returns a negative number if a is before b, a
+// positive number if a is after b, and zero if they are the same key.
+func NewSortedMapWithCompare[K comparable, V any](compare func(a, b K) int) *SortedMap[K, V] {
+	return &SortedMap[K, V]{
+		cmp:    compare,
+		head:   sortedNode[K, V]{next: make([]*sortedNode[K, V], sortedMaxLevel)},
+		level:  1,
+		levels: newKeyHasher(),
+	}
+}
+
+// search returns the last node before key on each level. The node after the
+// first of these is the node for key, if it exists.
+func (m *SortedMap[K, V]) search(key K) (before [sortedMaxLevel]*sortedNode[K, V]) {
+	n := &m.head
+	for i := m.level - 1; i >= 0; i-- {
+		for n.next[i] != nil && m.cmp(n.next[i].Key, key) < 0 {
+			n = n.next[i]
+		}
+		before[i] = n
+	}
+
+	return
+}
+
+// find returns the node for key, or nil.
+func (m *SortedMap[K, V]) find(key K) *sortedNode[K, V] {
+	before := m.search(key)
+	if n := before[0].next[0]; n != nil && m.cmp(n.Key, key) == 0 {
+		return n
+	}
+
+	return nil
+}
+
+// element returns the element of n, or nil for the head or a nil node.
+func (m *SortedMap[K, V]) element(n *sortedNode[K, V]) *Element[K, V] {
+	if n == nil || n == &m.head {
+		return nil
+	}
+
+	return &n.Element
+}
+
+// Get returns the value for a key. If the key does not exist, the second return
+// parameter will be false and the value will be nil.
+func (m *SortedMap[K, V]) Get(key K) (value V, ok bool) {
+	if n := m.find(key); n != nil {
+		return n.Value, true
+	}
+
+	return
+}
+
+// GetOrDefault returns the value for a key. If the key does not exist, returns
+// the default value instead.
+func (m *SortedMap[K, V]) GetOrDefault(key K, defaultValue V) V {
+	if n := m.find(key); n != nil {
+		return n.Value
+	}
+
+	return defaultValue
+}
+
+// GetElement returns the element for a key. If the key does not exist, the
+// pointer will be nil.
+func (m *SortedMap[K, V]) GetElement(key K) *Element[K, V] {
+	return m.element(m.find(key))
+}
+
+// Has checks if a key exists in the map.
+func (m *SortedMap[K, V]) Has(key K) bool {
+	return m.find(key) != nil
+}
+
+// Len returns the number of elements in the map.
+func (m *SortedMap[K, V]) Len() int {
+	return m.len
+}
+
+// Set will set (or replace) a value for a key. If the key was new, then true
+// will be returned. The returned value will be false if the value was replaced
+// (even if the value was the same).
+func (m *SortedMap[K, V]) Set(key K, value V) bool {
+	before := m.search(key)
+	if n := before[0].next[0]; n != nil && m.cmp(n.Key, key) == 0 {
+		n.Value = value
+		return false
+	}
+
+	// Each node is in the next level up with a probability of 1/4.
+	m.seq++
+	level := min(1+bits.TrailingZeros64(m.levels.uint64(m.seq))/2, sortedMaxLevel)
+	for ; m.level < level; m.level++ {
+		before[m.level] = &m.head
+	}
+
+	n := &sortedNode[K, V]{
+		Element: Element[K, V]{Key: key, Value: value},
+		next:    make([]*sortedNode[K, V], level),
+	}
+	for i := range n.next {
+		n.next[i] = before[i].next[i]
+		before[i].next[i] = n
+	}
+	m.ll.InsertAfter(&n.Element, m.element(before[0]))
+	m.len++
+
+	return true
+}
+
+// Delete will remove a key from the map. It will return true if the key was
+// removed (the key did exist).
+func (m *SortedMap[K, V]) Delete(key K) (didDelete bool) {
+	before := m.search(key)
+	n := before[0].next[0]
+	if n == nil || m.cmp(n.Key, key) != 0 {
+		return false
+	}
+
+	for i := range n.next {
+		before[i].next[i] = n.next[i]
+	}
+	for m.level > 1 && m.head.next[m.level-1] == nil {
+		m.level--
+	}
+	m.ll.Remove(&n.Element)
+	m.len--
+
+	return true
+}
+
+// Front will return the element with the lowest key. If there are no elements
+// this will return nil.
+func (m *SortedMap[K, V]) Front() *Element[K, V] {
+	return m.ll.Front()
+}
+
+// Back will return the element with the highest key. If there are no elements
+// this will return nil.
+func (m *SortedMap[K, V]) Back() *Element[K, V] {
+	return m.ll.Back()
+}
+
+// Floor returns the element with the highest key that is less than or equal to
+// key. If there is no such element this will return nil.
+func (m *SortedMap[K, V]) Floor(key K) *Element[K, V] {
+	before := m.search(key)
+	if n := before[0].next[0]; n != nil && m.cmp(n.Key, key) == 0 {
+		return &n.Element
+	}
+
+	return m.element(before[0])
+}
+
+// Ceiling returns the element with the lowest key that is greater than or equal
+// to key. If there is no such element this will return nil.
+func (m *SortedMap[K, V]) Ceiling(key K) *Element[K, V] {
+	before := m.search(key)
+
+	return m.element(before[0].next[0])
+}
+
+// Range returns an iterator that yields the elements with keys from lo up to
+// (but not including) hi, in order.
+func (m *SortedMap[K, V]) Range(lo, hi K) iter.Seq2[K, V] {
+	return func(yield func(key K, value V) bool) {
+		for el := m.Ceiling(lo); el != nil && m.cmp(el.Key, hi) < 0; el = el.Next() {
+			if !yield(el.Key, el.Value) {
+				return
+			}
+		}
+	}
+}
+
+// AllFromFront returns an iterator that yields all elements in the map starting
+// at the front (lowest key).
+func (m *SortedMap[K, V]) AllFromFront() iter.Seq2[K, V] {
+	return func(yield func(key K, value V) bool) {
+		for el := m.Front(); el != nil; el = el.Next() {
+			if !yield(el.Key, el.Value) {
+				return
+			}
+		}
+	}
+}
+
+// AllFromBack returns an iterator that yields all elements in the map starting
+// at the back (highest key).
+func (m *SortedMap[K, V]) AllFromBack() iter.Seq2[K, V] {
+	return func(yield func(key K, value V) bool) {
+		for el := m.Back(); el != nil; el = el.Prev() {
+			if !yield(el.Key, el.Value) {
+				return
+			}
+		}
+	}
+}
+
+// Keys returns an iterator that yields all the keys in the map in order.
+func (m *SortedMap[K, V]) Keys() iter.Seq[K] {
+	return func(yield func(key K) bool) {
+		for el := m.Front(); el != nil; el = el.Next() {
+			if !yield(el.Key) {
+				return
+			}
+		}
+	}
+}
+
+// Values returns an iterator that yields all the values in the map in the
+// order of their keys.
+func (m *SortedMap[K, V]) Values() iter.Seq[V] {
+	return func(yield func(value V) bool) {
+		for el := m.Front(); el != nil; el = el.Next() {
+			if !yield(el.Value) {
+				return
+			}
+		}
+	}
+}
+
+// Copy returns a new SortedMap with the same elements and comparison function.
+func (m *SortedMap[K, V]) Copy() *SortedMap[K, V] {
+	m2 := NewSortedMapWithCompare[K, V](m.cmp)
+	for el := m.Front(); el != nil; el = el.Next() {
+		m2.Set(el.Key, el.Value)
+	}
+	return m2
+}
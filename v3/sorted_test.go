@@ -0,0 +1,188 @@
+package orderedmap_test
+
+import (
+	"cmp"
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/elliotchance/orderedmap/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSortedMap() *orderedmap.SortedMap[int, string] {
+	m := orderedmap.NewSortedMap[int, string]()
+	for _, key := range []int{30, 10, 50, 20, 40} {
+		m.Set(key, string(rune('a'+key/10-1)))
+	}
+
+	return m
+}
+
+func TestSortedMap(t *testing.T) {
+	t.Run("Empty", func(t *testing.T) {
+		m := orderedmap.NewSortedMap[int, string]()
+		assert.Equal(t, 0, m.Len())
+		assert.Nil(t, m.Front())
+		assert.Nil(t, m.Back())
+		assert.Nil(t, m.Floor(1))
+		assert.Nil(t, m.Ceiling(1))
+		assert.False(t, m.Delete(1))
+		assert.Empty(t, slices.Collect(m.Keys()))
+	})
+
+	t.Run("SetAndGet", func(t *testing.T) {
+		m := newSortedMap()
+		assert.Equal(t, 5, m.Len())
+		assert.Equal(t, []int{10, 20, 30, 40, 50}, slices.Collect(m.Keys()))
+		assert.Equal(t, []string{"a", "b", "c", "d", "e"}, slices.Collect(m.Values()))
+
+		assert.False(t, m.Set(30, "C"))
+		value, ok := m.Get(30)
+		assert.True(t, ok)
+		assert.Equal(t, "C", value)
+		assert.Equal(t, 5, m.Len())
+
+		_, ok = m.Get(35)
+		assert.False(t, ok)
+		assert.Equal(t, "x", m.GetOrDefault(35, "x"))
+		assert.True(t, m.Has(10))
+		assert.False(t, m.Has(11))
+		assert.Equal(t, 40, m.GetElement(40).Key)
+		assert.Nil(t, m.GetElement(41))
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		m := newSortedMap()
+		assert.True(t, m.Delete(10))
+		assert.True(t, m.Delete(30))
+		assert.False(t, m.Delete(30))
+		assert.Equal(t, 3, m.Len())
+		assert.Equal(t, []int{20, 40, 50}, slices.Collect(m.Keys()))
+		assert.Equal(t, 20, m.Front().Key)
+
+		assert.True(t, m.Delete(50))
+		assert.Equal(t, 40, m.Back().Key)
+	})
+
+	t.Run("Iterate", func(t *testing.T) {
+		m := newSortedMap()
+		assert.Equal(t, 10, m.Front().Key)
+		assert.Equal(t, 50, m.Back().Key)
+		assert.Equal(t, 20, m.Front().Next().Key)
+		assert.Equal(t, 40, m.Back().Prev().Key)
+
+		var keys []int
+		for key := range m.AllFromBack() {
+			keys = append(keys, key)
+		}
+		assert.Equal(t, []int{50, 40, 30, 20, 10}, keys)
+
+		keys = nil
+		for key, value := range m.AllFromFront() {
+			keys = append(keys, key)
+			assert.Equal(t, m.GetOrDefault(key, ""), value)
+			if key == 20 {
+				break
+			}
+		}
+		assert.Equal(t, []int{10, 20}, keys)
+	})
+
+	t.Run("FloorAndCeiling", func(t *testing.T) {
+		m := newSortedMap()
+		assert.Nil(t, m.Floor(9))
+		assert.Equal(t, 10, m.Floor(10).Key)
+		assert.Equal(t, 30, m.Floor(35).Key)
+		assert.Equal(t, 50, m.Floor(100).Key)
+
+		assert.Equal(t, 10, m.Ceiling(0).Key)
+		assert.Equal(t, 30, m.Ceiling(30).Key)
+		assert.Equal(t, 40, m.Ceiling(31).Key)
+		assert.Nil(t, m.Ceiling(51))
+	})
+
+	t.Run("Range", func(t *testing.T) {
+		m := newSortedMap()
+		collect := func(lo, hi int) []int {
+			var keys []int
+			for key := range m.Range(lo, hi) {
+				keys = append(keys, key)
+			}
+			return keys
+		}
+
+		assert.Equal(t, []int{20, 30}, collect(20, 40))
+		assert.Equal(t, []int{20, 30, 40}, collect(15, 45))
+		assert.Equal(t, []int{10, 20, 30, 40, 50}, collect(0, 100))
+		assert.Nil(t, collect(31, 39))
+		assert.Nil(t, collect(40, 20))
+	})
+
+	t.Run("Compare", func(t *testing.T) {
+		m := orderedmap.NewSortedMapWithCompare[string, int](func(a, b string) int {
+			return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+		})
+		m.Set("banana", 1)
+		m.Set("Apple", 2)
+		m.Set("cherry", 3)
+		assert.False(t, m.Set("APPLE", 4))
+
+		assert.Equal(t, []string{"Apple", "banana", "cherry"}, slices.Collect(m.Keys()))
+		assert.Equal(t, 4, m.GetOrDefault("apple", 0))
+		assert.Equal(t, "banana", m.Floor("BZ").Key)
+	})
+
+	t.Run("Copy", func(t *testing.T) {
+		m := newSortedMap()
+		m2 := m.Copy()
+		m2.Set(25, "z")
+		m2.Delete(10)
+		assert.Equal(t, []int{10, 20, 30, 40, 50}, slices.Collect(m.Keys()))
+		assert.Equal(t, []int{20, 25, 30, 40, 50}, slices.Collect(m2.Keys()))
+	})
+
+	t.Run("Random", func(t *testing.T) {
+		r := rand.New(rand.NewSource(1))
+		m := orderedmap.NewSortedMapWithCompare[int, int](func(a, b int) int {
+			return cmp.Compare(b, a)
+		})
+		expected := map[int]int{}
+
+		for i := 0; i < 5000; i++ {
+			key := r.Intn(500)
+			if r.Intn(3) == 0 {
+				_, ok := expected[key]
+				require.Equal(t, ok, m.Delete(key))
+				delete(expected, key)
+			} else {
+				_, ok := expected[key]
+				require.Equal(t, !ok, m.Set(key, i))
+				expected[key] = i
+			}
+		}
+
+		keys := make([]int, 0, len(expected))
+		for key := range expected {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		slices.Reverse(keys)
+
+		assert.Equal(t, len(keys), m.Len())
+		assert.Equal(t, keys, slices.Collect(m.Keys()))
+		for _, key := range keys {
+			assert.Equal(t, expected[key], m.GetOrDefault(key, -1))
+		}
+	})
+}
+
+func BenchmarkSortedMap_Set(b *testing.B) {
+	m := orderedmap.NewSortedMap[int, int]()
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < b.N; i++ {
+		m.Set(r.Int(), i)
+	}
+}
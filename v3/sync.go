@@ -0,0 +1,292 @@
+package orderedmap
+
+import (
+	"iter"
+	"sync"
+)
+
+// SyncOrderedMap is an OrderedMap that is safe for concurrent use. Reads share
+// a read lock and writes take the write lock, so each method is atomic.
+// GetOrSet, CompareAndSwap, CompareAndDelete and Update combine a read and a
+// write into one atomic operation.
+//
+// Elements are not exposed (there is no Front, Back or GetElement) because
+// they could be read or changed after the lock has been released.
+//
+// The zero value is an empty map ready to use. A SyncOrderedMap must not be
+// copied after first use.
+type SyncOrderedMap[K comparable, V any] struct {
+	mu sync.RWMutex
+	m  OrderedMap[K, V]
+}
+
+func NewSyncOrderedMap[K comparable, V any]() *SyncOrderedMap[K, V] {
+	return &SyncOrderedMap[K, V]{}
+}
+
+// NewSyncOrderedMapWithCapacity creates a map with enough pre-allocated space
+// to hold the specified number of elements.
+func NewSyncOrderedMapWithCapacity[K comparable, V any](capacity int) *SyncOrderedMap[K, V] {
+	return &SyncOrderedMap[K, V]{
+		m: OrderedMap[K, V]{kv: make(map[K]*Element[K, V], capacity)},
+	}
+}
+
+// Get returns the value for a key. If the key does not exist, the second return
+// parameter will be false and the value will be nil.
+func (m *SyncOrderedMap[K, V]) Get(key K) (value V, ok bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.m.Get(key)
+}
+
+// GetOrDefault returns the value for a key. If the key does not exist, returns
+// the default value instead.
+func (m *SyncOrderedMap[K, V]) GetOrDefault(key K, defaultValue V) V {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.m.GetOrDefault(key, defaultValue)
+}
+
+// Has checks if a key exists in the map.
+func (m *SyncOrderedMap[K, V]) Has(key K) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.m.Has(key)
+}
+
+// Len returns the number of elements in the map.
+func (m *SyncOrderedMap[K, V]) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.m.Len()
+}
+
+// Set will set (or replace) a value for a key. If the key was new, then true
+// will be returned. The returned value will be false if the value was replaced
+// (even if the value was the same).
+func (m *SyncOrderedMap[K, V]) Set(key K, value V) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.m.lazyInit()
+	return m.m.Set(key, value)
+}
+
+// ReplaceKey replaces an existing key with a new key while preserving order of
+// the value. See OrderedMap.ReplaceKey.
+func (m *SyncOrderedMap[K, V]) ReplaceKey(originalKey, newKey K) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.m.lazyInit()
+	return m.m.ReplaceKey(originalKey, newKey)
+}
+
+// Delete will remove a key from the map. It will return true if the key was
+// removed (the key did exist).
+func (m *SyncOrderedMap[K, V]) Delete(key K) (didDelete bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.m.Delete(key)
+}
+
+// GetOrSet returns the existing value for a key if it exists, and loaded will
+// be true. Otherwise, it sets the key to value and returns value, and loaded
+// will be false.
+func (m *SyncOrderedMap[K, V]) GetOrSet(key K, value V) (actual V, loaded bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if actual, loaded = m.m.Get(key); loaded {
+		return actual, true
+	}
+
+	m.m.lazyInit()
+	m.m.Set(key, value)
+
+	return value, false
+}
+
+// CompareAndSwap replaces the value for a key with new if the key exists and
+// its current value is equal to old. The key keeps its position.
+//
+// Like sync.Map, the values are compared with ==, so V must hold comparable
+// values or CompareAndSwap will panic.
+func (m *SyncOrderedMap[K, V]) CompareAndSwap(key K, old, new V) (swapped bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	element, ok := m.m.kv[key]
+	if !ok || any(element.Value) != any(old) {
+		return false
+	}
+	element.Value = new
+
+	return true
+}
+
+// CompareAndDelete deletes a key if it exists and its current value is equal
+// to old. Values are compared the same way as CompareAndSwap.
+func (m *SyncOrderedMap[K, V]) CompareAndDelete(key K, old V) (deleted bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	element, ok := m.m.kv[key]
+	if !ok || any(element.Value) != any(old) {
+		return false
+	}
+
+	return m.m.Delete(key)
+}
+
+// Update atomically changes the value for a key. fn is called with the current
+// value and whether the key exists, and returns the new value and whether the
+// key should exist. Returning false deletes the key (if it existed). An
+// existing key keeps its position and a new key is added to the back.
+//
+// Update returns the new value and whether the key exists. fn is called with
+// the lock held so it must not use the map.
+func (m *SyncOrderedMap[K, V]) Update(key K, fn func(old V, ok bool) (V, bool)) (V, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	old, ok := m.m.Get(key)
+	value, keep := fn(old, ok)
+	if !keep {
+		m.m.Delete(key)
+
+		var zero V
+		return zero, false
+	}
+
+	m.m.lazyInit()
+	m.m.Set(key, value)
+
+	return value, true
+}
+
+// Copy returns a new OrderedMap with the same elements. This is a consistent
+// snapshot of the map that can be read or iterated without holding a lock.
+func (m *SyncOrderedMap[K, V]) Copy() *OrderedMap[K, V] {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.m.Copy()
+}
+
+// AllFromFront returns an iterator that yields all elements in the map
+// starting at the front (oldest Set element).
+//
+// The read lock is held for the whole iteration, so writers wait until the
+// loop finishes. No method of m may be called inside the loop: a change would
+// deadlock, and so can a read (such as Get or Len), since a read lock cannot be
+// taken again while a writer is waiting. Use SnapshotFromFront to iterate
+// without holding the lock.
+func (m *SyncOrderedMap[K, V]) AllFromFront() iter.Seq2[K, V] {
+	return func(yield func(key K, value V) bool) {
+		m.mu.RLock()
+		defer m.mu.RUnlock()
+
+		m.m.AllFromFront()(yield)
+	}
+}
+
+// AllFromBack returns an iterator that yields all elements in the map starting
+// at the back (most recent Set element). The read lock is held for the whole
+// iteration, see AllFromFront.
+func (m *SyncOrderedMap[K, V]) AllFromBack() iter.Seq2[K, V] {
+	return func(yield func(key K, value V) bool) {
+		m.mu.RLock()
+		defer m.mu.RUnlock()
+
+		m.m.AllFromBack()(yield)
+	}
+}
+
+// Keys returns an iterator that yields all the keys in the map starting at the
+// front (oldest Set element). The read lock is held for the whole iteration,
+// see AllFromFront.
+func (m *SyncOrderedMap[K, V]) Keys() iter.Seq[K] {
+	return func(yield func(key K) bool) {
+		m.mu.RLock()
+		defer m.mu.RUnlock()
+
+		m.m.Keys()(yield)
+	}
+}
+
+// Values returns an iterator that yields all the values in the map starting at
+// the front (oldest Set element). The read lock is held for the whole
+// iteration, see AllFromFront.
+func (m *SyncOrderedMap[K, V]) Values() iter.Seq[V] {
+	return func(yield func(value V) bool) {
+		m.mu.RLock()
+		defer m.mu.RUnlock()
+
+		m.m.Values()(yield)
+	}
+}
+
+// SnapshotFromFront returns an iterator that yields all elements in the map
+// starting at the front (oldest Set element), as they were when the loop
+// started. The elements are copied while holding the read lock, which is
+// released before the first element is yielded, so any method (including
+// changes) can be used inside the loop.
+func (m *SyncOrderedMap[K, V]) SnapshotFromFront() iter.Seq2[K, V] {
+	return func(yield func(key K, value V) bool) {
+		for _, el := range m.snapshot() {
+			if !yield(el.Key, el.Value) {
+				return
+			}
+		}
+	}
+}
+
+// SnapshotFromBack returns an iterator that yields all elements in the map
+// starting at the back (most recent Set element), as they were when the loop
+// started. See SnapshotFromFront.
+func (m *SyncOrderedMap[K, V]) SnapshotFromBack() iter.Seq2[K, V] {
+	return func(yield func(key K, value V) bool) {
+		elements := m.snapshot()
+		for i := len(elements) - 1; i >= 0; i-- {
+			if !yield(elements[i].Key, elements[i].Value) {
+				return
+			}
+		}
+	}
+}
+
+// snapshot returns a copy of the keys and values, from the front.
+func (m *SyncOrderedMap[K, V]) snapshot() []Element[K, V] {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	elements := make([]Element[K, V], 0, m.m.Len())
+	for el := m.m.Front(); el != nil; el = el.Next() {
+		elements = append(elements, Element[K, V]{Key: el.Key, Value: el.Value})
+	}
+
+	return elements
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (m *SyncOrderedMap[K, V]) MarshalJSON() ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.m.MarshalJSON()
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (m *SyncOrderedMap[K, V]) UnmarshalJSON(data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.m.UnmarshalJSON(data)
+}
@@ -0,0 +1,219 @@
+package orderedmap_test
+
+import (
+	"encoding/json"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/elliotchance/orderedmap/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncOrderedMap(t *testing.T) {
+	t.Run("ZeroValueIsUsable", func(t *testing.T) {
+		var m orderedmap.SyncOrderedMap[string, int]
+		assert.True(t, m.Set("a", 1))
+		assert.False(t, m.Set("a", 2))
+		assert.Equal(t, 2, m.GetOrDefault("a", 0))
+		assert.Equal(t, 1, m.Len())
+	})
+
+	t.Run("KeepsOrder", func(t *testing.T) {
+		m := orderedmap.NewSyncOrderedMapWithCapacity[string, int](3)
+		m.Set("c", 1)
+		m.Set("a", 2)
+		m.Set("b", 3)
+		m.Set("c", 4)
+		assert.True(t, m.ReplaceKey("a", "z"))
+		assert.True(t, m.Delete("b"))
+
+		assert.Equal(t, []string{"c", "z"}, slices.Collect(m.Keys()))
+		assert.Equal(t, []int{4, 2}, slices.Collect(m.Values()))
+
+		var keys []string
+		for key := range m.AllFromBack() {
+			keys = append(keys, key)
+		}
+		assert.Equal(t, []string{"z", "c"}, keys)
+	})
+
+	t.Run("CopyIsASnapshot", func(t *testing.T) {
+		m := orderedmap.NewSyncOrderedMap[string, int]()
+		m.Set("a", 1)
+		m.Set("b", 2)
+
+		var keys []string
+		for key := range m.Copy().AllFromFront() {
+			// Writing while iterating a snapshot does not deadlock.
+			m.Set(key+key, 0)
+			keys = append(keys, key)
+		}
+		assert.Equal(t, []string{"a", "b"}, keys)
+		assert.Equal(t, []string{"a", "b", "aa", "bb"}, slices.Collect(m.Keys()))
+	})
+
+	t.Run("SnapshotIterators", func(t *testing.T) {
+		m := orderedmap.NewSyncOrderedMap[string, int]()
+		m.Set("a", 1)
+		m.Set("b", 2)
+
+		var keys []string
+		for key, value := range m.SnapshotFromFront() {
+			// A writer waiting for the lock would block this Get if the
+			// iterator still held a read lock.
+			written := make(chan struct{})
+			go func() {
+				m.Set(key+key, value)
+				close(written)
+			}()
+			select {
+			case <-written:
+			case <-time.After(time.Second):
+				t.Fatal("Set is blocked by the iterator")
+			}
+
+			assert.Equal(t, value, m.GetOrDefault(key+key, 0))
+			keys = append(keys, key)
+		}
+		assert.Equal(t, []string{"a", "b"}, keys)
+		assert.Equal(t, []string{"a", "b", "aa", "bb"}, slices.Collect(m.Keys()))
+
+		keys = nil
+		for key := range m.SnapshotFromBack() {
+			m.Delete(key)
+			keys = append(keys, key)
+		}
+		assert.Equal(t, []string{"bb", "aa", "b", "a"}, keys)
+		assert.Equal(t, 0, m.Len())
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		m := orderedmap.NewSyncOrderedMap[string, int]()
+		require.NoError(t, json.Unmarshal([]byte(`{"b":1,"a":2}`), m))
+
+		data, err := json.Marshal(m)
+		require.NoError(t, err)
+		assert.Equal(t, `{"b":1,"a":2}`, string(data))
+	})
+}
+
+func TestSyncOrderedMap_GetOrSet(t *testing.T) {
+	m := orderedmap.NewSyncOrderedMap[string, int]()
+
+	actual, loaded := m.GetOrSet("a", 1)
+	assert.Equal(t, 1, actual)
+	assert.False(t, loaded)
+
+	actual, loaded = m.GetOrSet("a", 2)
+	assert.Equal(t, 1, actual)
+	assert.True(t, loaded)
+}
+
+func TestSyncOrderedMap_CompareAndSwap(t *testing.T) {
+	m := orderedmap.NewSyncOrderedMap[string, int]()
+	m.Set("a", 1)
+	m.Set("b", 2)
+
+	assert.False(t, m.CompareAndSwap("a", 2, 3))
+	assert.False(t, m.CompareAndSwap("c", 0, 3))
+	assert.True(t, m.CompareAndSwap("a", 1, 3))
+	assert.Equal(t, []int{3, 2}, slices.Collect(m.Values()))
+	assert.False(t, m.Has("c"))
+}
+
+func TestSyncOrderedMap_CompareAndDelete(t *testing.T) {
+	m := orderedmap.NewSyncOrderedMap[string, int]()
+	m.Set("a", 1)
+
+	assert.False(t, m.CompareAndDelete("a", 2))
+	assert.False(t, m.CompareAndDelete("b", 0))
+	assert.True(t, m.CompareAndDelete("a", 1))
+	assert.Equal(t, 0, m.Len())
+}
+
+func TestSyncOrderedMap_Update(t *testing.T) {
+	m := orderedmap.NewSyncOrderedMap[string, int]()
+	m.Set("a", 1)
+	m.Set("b", 2)
+
+	increment := func(old int, ok bool) (int, bool) {
+		return old + 1, true
+	}
+
+	t.Run("ExistingKeyKeepsPosition", func(t *testing.T) {
+		value, ok := m.Update("a", increment)
+		assert.Equal(t, 2, value)
+		assert.True(t, ok)
+		assert.Equal(t, []string{"a", "b"}, slices.Collect(m.Keys()))
+	})
+
+	t.Run("NewKeyIsAddedToBack", func(t *testing.T) {
+		value, ok := m.Update("c", func(old int, ok bool) (int, bool) {
+			assert.False(t, ok)
+			return 10, true
+		})
+		assert.Equal(t, 10, value)
+		assert.True(t, ok)
+		assert.Equal(t, []string{"a", "b", "c"}, slices.Collect(m.Keys()))
+	})
+
+	t.Run("ReturningFalseDeletes", func(t *testing.T) {
+		_, ok := m.Update("b", func(old int, ok bool) (int, bool) {
+			return 0, false
+		})
+		assert.False(t, ok)
+		assert.Equal(t, []string{"a", "c"}, slices.Collect(m.Keys()))
+	})
+
+	t.Run("IsAtomic", func(t *testing.T) {
+		m := orderedmap.NewSyncOrderedMap[string, int]()
+
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 1000; j++ {
+					m.Update("n", increment)
+				}
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, 8000, m.GetOrDefault("n", 0))
+	})
+}
+
+func TestSyncOrderedMap_Concurrent(t *testing.T) {
+	m := orderedmap.NewSyncOrderedMap[int, string]()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 500; j++ {
+				m.Set(i*1000+j, strconv.Itoa(j))
+				if j%3 == 0 {
+					m.Delete(i*1000 + j)
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				for key, value := range m.AllFromFront() {
+					assert.Equal(t, strconv.Itoa(key%1000), value)
+				}
+				m.Copy()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 4*333, m.Len())
+}
@@ -0,0 +1,997 @@
+package toml
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/elliotchance/orderedmap/v3"
+)
+
+// Decoder reads a TOML document from an input stream.
+type Decoder struct {
+	r io.Reader
+}
+
+// NewDecoder returns a new decoder that reads from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: r}
+}
+
+// Decode reads the whole input stream and decodes it as a single TOML
+// document.
+func (d *Decoder) Decode() (*orderedmap.OrderedMap[string, any], error) {
+	data, err := io.ReadAll(d.r)
+	if err != nil {
+		return nil, err
+	}
+
+	return Unmarshal(data)
+}
+
+// Unmarshal decodes a TOML document. See the package documentation for the
+// types that are used for each kind of value.
+func Unmarshal(data []byte) (*orderedmap.OrderedMap[string, any], error) {
+	if !utf8.Valid(data) {
+		return nil, &ParseError{Line: 1, Message: "document is not valid UTF-8"}
+	}
+
+	p := &parser{
+		data:  data,
+		line:  1,
+		root:  orderedmap.NewOrderedMap[string, any](),
+		kinds: make(map[*orderedmap.OrderedMap[string, any]]tableKind),
+	}
+	p.current = p.root
+
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+
+	finish(p.root)
+
+	return p.root, nil
+}
+
+// tableKind records how a table was created, which decides what is allowed to
+// add keys to it later.
+type tableKind int
+
+const (
+	// kindImplicit tables were created as the parent of a table header, and
+	// can still be defined by their own header later.
+	kindImplicit tableKind = iota
+
+	// kindHeader tables were defined by their own [header].
+	kindHeader
+
+	// kindDotted tables were created by a dotted key, and can only be extended
+	// with more dotted keys from the same table or sub-table headers.
+	kindDotted
+
+	// kindInline tables (and everything inside them) cannot be changed once
+	// they have been closed.
+	kindInline
+)
+
+// tableArray holds an array of tables while parsing so that it can be told
+// apart from a static array, which cannot be appended to. It is replaced by a
+// []any once parsing is complete.
+type tableArray struct {
+	tables []any
+}
+
+// maxDepth is the deepest that arrays and inline tables can be nested, so that
+// a malicious document cannot overflow the stack.
+const maxDepth = 10000
+
+type parser struct {
+	data  []byte
+	pos   int
+	line  int
+	depth int
+
+	root    *orderedmap.OrderedMap[string, any]
+	current *orderedmap.OrderedMap[string, any]
+	kinds   map[*orderedmap.OrderedMap[string, any]]tableKind
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return &ParseError{Line: p.line, Message: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.data)
+}
+
+func (p *parser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+
+	return p.data[p.pos]
+}
+
+func (p *parser) hasPrefix(s string) bool {
+	return bytes.HasPrefix(p.data[p.pos:], []byte(s))
+}
+
+func (p *parser) skipWhitespace() {
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.pos++
+	}
+}
+
+// skipComment skips a comment (if there is one) up to, but not including, the
+// end of the line.
+func (p *parser) skipComment() error {
+	if p.peek() != '#' {
+		return nil
+	}
+
+	for !p.eof() && p.peek() != '\n' && !p.hasPrefix("\r\n") {
+		if c := p.peek(); (c < 0x20 && c != '\t') || c == 0x7f {
+			return p.errorf("control character in comment")
+		}
+		p.pos++
+	}
+
+	return nil
+}
+
+// newline consumes a line ending, returning false if there is not one.
+func (p *parser) newline() bool {
+	switch {
+	case p.peek() == '\n':
+		p.pos++
+
+	case p.hasPrefix("\r\n"):
+		p.pos += 2
+
+	default:
+		return false
+	}
+
+	p.line++
+
+	return true
+}
+
+// endOfLine expects only whitespace or a comment before the end of the line.
+func (p *parser) endOfLine() error {
+	p.skipWhitespace()
+	if err := p.skipComment(); err != nil {
+		return err
+	}
+	if !p.eof() && !p.newline() {
+		return p.errorf("expected end of line but found %q", p.peek())
+	}
+
+	return nil
+}
+
+func (p *parser) parse() error {
+	for !p.eof() {
+		p.skipWhitespace()
+		if err := p.skipComment(); err != nil {
+			return err
+		}
+		if p.eof() || p.newline() {
+			continue
+		}
+
+		var err error
+		switch {
+		case p.hasPrefix("[["):
+			err = p.parseArrayTableHeader()
+
+		case p.peek() == '[':
+			err = p.parseTableHeader()
+
+		default:
+			err = p.parseKeyValue(p.current)
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := p.endOfLine(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *parser) parseTableHeader() error {
+	p.pos++
+	p.skipWhitespace()
+	keys, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+	if p.peek() != ']' {
+		return p.errorf("expected ']' at end of table header")
+	}
+	p.pos++
+
+	parent, err := p.descend(keys[:len(keys)-1])
+	if err != nil {
+		return err
+	}
+
+	key := keys[len(keys)-1]
+	existing, ok := parent.Get(key)
+	if !ok {
+		table := p.newTable(kindHeader)
+		parent.Set(key, table)
+		p.current = table
+
+		return nil
+	}
+
+	table, isTable := existing.(*orderedmap.OrderedMap[string, any])
+	if !isTable || p.kinds[table] != kindImplicit {
+		return p.errorf("cannot redefine %s", joinKey(keys))
+	}
+
+	p.kinds[table] = kindHeader
+	p.current = table
+
+	return nil
+}
+
+func (p *parser) parseArrayTableHeader() error {
+	p.pos += 2
+	p.skipWhitespace()
+	keys, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+	if !p.hasPrefix("]]") {
+		return p.errorf("expected ']]' at end of array of tables header")
+	}
+	p.pos += 2
+
+	parent, err := p.descend(keys[:len(keys)-1])
+	if err != nil {
+		return err
+	}
+
+	key := keys[len(keys)-1]
+	table := p.newTable(kindHeader)
+	existing, ok := parent.Get(key)
+	if !ok {
+		parent.Set(key, &tableArray{tables: []any{table}})
+		p.current = table
+
+		return nil
+	}
+
+	array, ok := existing.(*tableArray)
+	if !ok {
+		return p.errorf("cannot redefine %s as an array of tables", joinKey(keys))
+	}
+
+	array.tables = append(array.tables, table)
+	p.current = table
+
+	return nil
+}
+
+// descend finds (or creates) the table for the keys of a header, not including
+// the last key.
+func (p *parser) descend(keys []string) (*orderedmap.OrderedMap[string, any], error) {
+	table := p.root
+	for i, key := range keys {
+		existing, ok := table.Get(key)
+		if !ok {
+			next := p.newTable(kindImplicit)
+			table.Set(key, next)
+			table = next
+			continue
+		}
+
+		switch existing := existing.(type) {
+		case *orderedmap.OrderedMap[string, any]:
+			if p.kinds[existing] == kindInline {
+				return nil, p.errorf("cannot extend inline table %s", joinKey(keys[:i+1]))
+			}
+			table = existing
+
+		case *tableArray:
+			table = existing.tables[len(existing.tables)-1].(*orderedmap.OrderedMap[string, any])
+
+		default:
+			return nil, p.errorf("%s is not a table", joinKey(keys[:i+1]))
+		}
+	}
+
+	return table, nil
+}
+
+func (p *parser) newTable(kind tableKind) *orderedmap.OrderedMap[string, any] {
+	table := orderedmap.NewOrderedMap[string, any]()
+	p.kinds[table] = kind
+
+	return table
+}
+
+// parseKeyValue parses a key/value pair into table. The pair may use a dotted
+// key to create nested tables.
+func (p *parser) parseKeyValue(table *orderedmap.OrderedMap[string, any]) error {
+	keys, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+	if p.peek() != '=' {
+		return p.errorf("expected '=' after key %s", joinKey(keys))
+	}
+	p.pos++
+	p.skipWhitespace()
+
+	for i, key := range keys[:len(keys)-1] {
+		existing, ok := table.Get(key)
+		if !ok {
+			next := p.newTable(kindDotted)
+			table.Set(key, next)
+			table = next
+			continue
+		}
+
+		next, ok := existing.(*orderedmap.OrderedMap[string, any])
+		if !ok || p.kinds[next] != kindDotted {
+			return p.errorf("cannot add keys to %s", joinKey(keys[:i+1]))
+		}
+		table = next
+	}
+
+	key := keys[len(keys)-1]
+	if table.Has(key) {
+		return p.errorf("key %s is already defined", joinKey(keys))
+	}
+
+	value, err := p.parseValue()
+	if err != nil {
+		return err
+	}
+	table.Set(key, value)
+
+	return nil
+}
+
+// parseKey parses a (possibly dotted) key and any whitespace that follows it.
+func (p *parser) parseKey() ([]string, error) {
+	var keys []string
+	for {
+		var key string
+		switch c := p.peek(); {
+		case c == '"':
+			if p.hasPrefix(`"""`) {
+				return nil, p.errorf("multi-line strings cannot be used as keys")
+			}
+			s, err := p.parseBasicString()
+			if err != nil {
+				return nil, err
+			}
+			key = s
+
+		case c == '\'':
+			if p.hasPrefix(`'''`) {
+				return nil, p.errorf("multi-line strings cannot be used as keys")
+			}
+			s, err := p.parseLiteralString()
+			if err != nil {
+				return nil, err
+			}
+			key = s
+
+		default:
+			start := p.pos
+			for !p.eof() && isBareKeyChar(rune(p.peek())) {
+				p.pos++
+			}
+			if start == p.pos {
+				if p.eof() {
+					return nil, p.errorf("expected key but found end of document")
+				}
+				return nil, p.errorf("invalid character %q in key", p.peek())
+			}
+			key = string(p.data[start:p.pos])
+		}
+
+		keys = append(keys, key)
+		p.skipWhitespace()
+		if p.peek() != '.' {
+			return keys, nil
+		}
+		p.pos++
+		p.skipWhitespace()
+	}
+}
+
+func isBareKeyChar(c rune) bool {
+	return (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') ||
+		(c >= '0' && c <= '9') || c == '_' || c == '-'
+}
+
+func (p *parser) parseValue() (any, error) {
+	switch c := p.peek(); {
+	case p.eof():
+		return nil, p.errorf("expected value but found end of document")
+
+	case p.hasPrefix(`"""`):
+		return p.parseMultiLineBasicString()
+
+	case c == '"':
+		return p.parseBasicString()
+
+	case p.hasPrefix(`'''`):
+		return p.parseMultiLineLiteralString()
+
+	case c == '\'':
+		return p.parseLiteralString()
+
+	case c == '[' || c == '{':
+		if p.depth >= maxDepth {
+			return nil, p.errorf("exceeded max depth of %d", maxDepth)
+		}
+		p.depth++
+		defer func() { p.depth-- }()
+
+		if c == '[' {
+			return p.parseArray()
+		}
+		return p.parseInlineTable()
+
+	case p.hasPrefix("true"):
+		p.pos += 4
+		return true, nil
+
+	case p.hasPrefix("false"):
+		p.pos += 5
+		return false, nil
+	}
+
+	return p.parseNumberOrDate()
+}
+
+func (p *parser) parseArray() (any, error) {
+	p.pos++
+	values := []any{}
+	for {
+		if err := p.skipArrayWhitespace(); err != nil {
+			return nil, err
+		}
+		if p.peek() == ']' {
+			p.pos++
+			return values, nil
+		}
+
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+
+		if err := p.skipArrayWhitespace(); err != nil {
+			return nil, err
+		}
+		switch p.peek() {
+		case ',':
+			p.pos++
+
+		case ']':
+			p.pos++
+			return values, nil
+
+		default:
+			return nil, p.errorf("expected ',' or ']' in array")
+		}
+	}
+}
+
+// skipArrayWhitespace skips whitespace, comments and newlines, which are all
+// allowed between the values of an array.
+func (p *parser) skipArrayWhitespace() error {
+	for {
+		p.skipWhitespace()
+		if err := p.skipComment(); err != nil {
+			return err
+		}
+		if p.eof() {
+			return p.errorf("unterminated array")
+		}
+		if !p.newline() {
+			return nil
+		}
+	}
+}
+
+func (p *parser) parseInlineTable() (any, error) {
+	p.pos++
+	table := p.newTable(kindDotted)
+	p.skipWhitespace()
+	if p.peek() == '}' {
+		p.pos++
+		p.closeInlineTable(table)
+
+		return table, nil
+	}
+
+	for {
+		if err := p.parseKeyValue(table); err != nil {
+			return nil, err
+		}
+		p.skipWhitespace()
+
+		switch p.peek() {
+		case ',':
+			p.pos++
+			p.skipWhitespace()
+
+		case '}':
+			p.pos++
+			p.closeInlineTable(table)
+
+			return table, nil
+
+		default:
+			return nil, p.errorf("expected ',' or '}' in inline table")
+		}
+	}
+}
+
+// closeInlineTable stops the inline table, and any tables created inside it
+// with dotted keys, from being changed.
+func (p *parser) closeInlineTable(table *orderedmap.OrderedMap[string, any]) {
+	p.kinds[table] = kindInline
+	for value := range table.Values() {
+		if child, ok := value.(*orderedmap.OrderedMap[string, any]); ok {
+			p.closeInlineTable(child)
+		}
+	}
+}
+
+func (p *parser) parseBasicString() (string, error) {
+	p.pos++
+	var b strings.Builder
+	for {
+		if p.eof() || p.peek() == '\n' || p.hasPrefix("\r\n") {
+			return "", p.errorf("unterminated string")
+		}
+
+		c := p.peek()
+		switch {
+		case c == '"':
+			p.pos++
+			return b.String(), nil
+
+		case c == '\\':
+			if err := p.parseEscape(&b); err != nil {
+				return "", err
+			}
+
+		case (c < 0x20 && c != '\t') || c == 0x7f:
+			return "", p.errorf("control character in string")
+
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+}
+
+func (p *parser) parseMultiLineBasicString() (string, error) {
+	p.pos += 3
+	p.newline()
+
+	var b strings.Builder
+	for {
+		if p.eof() {
+			return "", p.errorf("unterminated string")
+		}
+
+		if p.hasPrefix(`"""`) {
+			// Up to two quotes are allowed right before the closing quotes.
+			p.pos += 3
+			for i := 0; i < 2 && p.peek() == '"'; i++ {
+				b.WriteByte('"')
+				p.pos++
+			}
+
+			return b.String(), nil
+		}
+
+		c := p.peek()
+		switch {
+		case c == '\\':
+			if p.skipLineEndingBackslash() {
+				continue
+			}
+			if err := p.parseEscape(&b); err != nil {
+				return "", err
+			}
+
+		case c == '\n' || p.hasPrefix("\r\n"):
+			p.newline()
+			b.WriteByte('\n')
+
+		case (c < 0x20 && c != '\t') || c == 0x7f:
+			return "", p.errorf("control character in string")
+
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+}
+
+// skipLineEndingBackslash trims a backslash at the end of a line, and all the
+// whitespace and newlines after it.
+func (p *parser) skipLineEndingBackslash() bool {
+	pos := p.pos + 1
+	for pos < len(p.data) && (p.data[pos] == ' ' || p.data[pos] == '\t') {
+		pos++
+	}
+	if pos < len(p.data) && p.data[pos] != '\n' && p.data[pos] != '\r' {
+		return false
+	}
+
+	p.pos = pos
+	for {
+		p.skipWhitespace()
+		if !p.newline() {
+			return true
+		}
+	}
+}
+
+func (p *parser) parseEscape(b *strings.Builder) error {
+	p.pos++
+	if p.eof() {
+		return p.errorf("unterminated string")
+	}
+
+	c := p.peek()
+	p.pos++
+	switch c {
+	case 'b':
+		b.WriteByte('\b')
+	case 't':
+		b.WriteByte('\t')
+	case 'n':
+		b.WriteByte('\n')
+	case 'f':
+		b.WriteByte('\f')
+	case 'r':
+		b.WriteByte('\r')
+	case '"':
+		b.WriteByte('"')
+	case '\\':
+		b.WriteByte('\\')
+
+	case 'u', 'U':
+		size := 4
+		if c == 'U' {
+			size = 8
+		}
+		if p.pos+size > len(p.data) {
+			return p.errorf("invalid unicode escape")
+		}
+		n, err := strconv.ParseUint(string(p.data[p.pos:p.pos+size]), 16, 32)
+		if err != nil || !utf8.ValidRune(rune(n)) {
+			return p.errorf("invalid unicode escape")
+		}
+		b.WriteRune(rune(n))
+		p.pos += size
+
+	default:
+		return p.errorf("invalid escape sequence \\%c", c)
+	}
+
+	return nil
+}
+
+func (p *parser) parseLiteralString() (string, error) {
+	p.pos++
+	start := p.pos
+	for {
+		if p.eof() || p.peek() == '\n' || p.hasPrefix("\r\n") {
+			return "", p.errorf("unterminated string")
+		}
+
+		c := p.peek()
+		switch {
+		case c == '\'':
+			s := string(p.data[start:p.pos])
+			p.pos++
+			return s, nil
+
+		case (c < 0x20 && c != '\t') || c == 0x7f:
+			return "", p.errorf("control character in string")
+		}
+		p.pos++
+	}
+}
+
+func (p *parser) parseMultiLineLiteralString() (string, error) {
+	p.pos += 3
+	p.newline()
+
+	var b strings.Builder
+	for {
+		if p.eof() {
+			return "", p.errorf("unterminated string")
+		}
+
+		if p.hasPrefix(`'''`) {
+			p.pos += 3
+			for i := 0; i < 2 && p.peek() == '\''; i++ {
+				b.WriteByte('\'')
+				p.pos++
+			}
+
+			return b.String(), nil
+		}
+
+		c := p.peek()
+		switch {
+		case c == '\n' || p.hasPrefix("\r\n"):
+			p.newline()
+			b.WriteByte('\n')
+
+		case (c < 0x20 && c != '\t') || c == 0x7f:
+			return "", p.errorf("control character in string")
+
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+}
+
+func (p *parser) parseNumberOrDate() (any, error) {
+	start := p.pos
+	for !p.eof() && isValueChar(p.peek()) {
+		p.pos++
+	}
+
+	// A date and time may be separated by a space instead of a "T".
+	if p.pos-start == 10 && p.data[start+4] == '-' && p.peek() == ' ' &&
+		p.pos+1 < len(p.data) && isDigit(p.data[p.pos+1]) {
+		p.pos++
+		for !p.eof() && isValueChar(p.peek()) {
+			p.pos++
+		}
+	}
+
+	s := string(p.data[start:p.pos])
+	if s == "" {
+		return nil, p.errorf("invalid value starting with %q", p.peek())
+	}
+
+	switch {
+	case len(s) >= 8 && s[2] == ':':
+		t, ok := parseLocalTime(s)
+		if !ok {
+			return nil, p.errorf("invalid time %q", s)
+		}
+		return t, nil
+
+	case len(s) >= 10 && s[4] == '-' && isDigit(s[0]):
+		return p.parseDate(s)
+	}
+
+	if v, ok := parseInteger(s); ok {
+		return v, nil
+	}
+	if v, ok := parseFloat(s); ok {
+		return v, nil
+	}
+
+	return nil, p.errorf("invalid value %q", s)
+}
+
+func (p *parser) parseDate(s string) (any, error) {
+	date, ok := parseLocalDate(s[:10])
+	if !ok {
+		return nil, p.errorf("invalid date %q", s)
+	}
+	if len(s) == 10 {
+		return date, nil
+	}
+
+	if c := s[10]; c != 'T' && c != 't' && c != ' ' {
+		return nil, p.errorf("invalid date-time %q", s)
+	}
+
+	rest := s[11:]
+	offset := strings.IndexAny(rest, "Zz+-")
+	if offset < 0 {
+		t, ok := parseLocalTime(rest)
+		if !ok {
+			return nil, p.errorf("invalid date-time %q", s)
+		}
+
+		return LocalDateTime{LocalDate: date, LocalTime: t}, nil
+	}
+
+	if _, ok := parseLocalTime(rest[:offset]); !ok {
+		return nil, p.errorf("invalid date-time %q", s)
+	}
+	normalized := s[:10] + "T" + rest[:offset] + strings.ToUpper(rest[offset:])
+	t, err := time.Parse(time.RFC3339Nano, normalized)
+	if err != nil {
+		return nil, p.errorf("invalid date-time %q", s)
+	}
+
+	return t, nil
+}
+
+func parseLocalDate(s string) (LocalDate, bool) {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return LocalDate{}, false
+	}
+
+	return LocalDate{Year: t.Year(), Month: t.Month(), Day: t.Day()}, true
+}
+
+func parseLocalTime(s string) (LocalTime, bool) {
+	if len(s) < 8 || s[2] != ':' || s[5] != ':' || (len(s) > 8 && s[8] != '.') ||
+		len(s) == 9 {
+		return LocalTime{}, false
+	}
+
+	t, err := time.Parse("15:04:05.999999999", s)
+	if err != nil {
+		return LocalTime{}, false
+	}
+
+	return LocalTime{
+		Hour:       t.Hour(),
+		Minute:     t.Minute(),
+		Second:     t.Second(),
+		Nanosecond: t.Nanosecond(),
+	}, true
+}
+
+func parseInteger(s string) (int64, bool) {
+	base := 10
+	digits := s
+	switch {
+	case strings.HasPrefix(s, "0x"):
+		base, digits = 16, s[2:]
+	case strings.HasPrefix(s, "0o"):
+		base, digits = 8, s[2:]
+	case strings.HasPrefix(s, "0b"):
+		base, digits = 2, s[2:]
+	}
+
+	unsigned := digits
+	if base == 10 && (strings.HasPrefix(s, "+") || strings.HasPrefix(s, "-")) {
+		unsigned = s[1:]
+	}
+	if !validDigits(unsigned, base) {
+		return 0, false
+	}
+	if base == 10 && len(unsigned) > 1 && unsigned[0] == '0' {
+		return 0, false
+	}
+
+	n, err := strconv.ParseInt(strings.ReplaceAll(digits, "_", ""), base, 64)
+
+	return n, err == nil
+}
+
+func parseFloat(s string) (float64, bool) {
+	unsigned := strings.TrimLeft(s, "+-")
+	if len(s)-len(unsigned) > 1 {
+		return 0, false
+	}
+
+	switch unsigned {
+	case "inf":
+		if s[0] == '-' {
+			return math.Inf(-1), true
+		}
+		return math.Inf(1), true
+
+	case "nan":
+		return math.NaN(), true
+	}
+
+	mantissa, exponent, hasExponent := strings.Cut(strings.ToLower(unsigned), "e")
+	integer, fraction, hasFraction := strings.Cut(mantissa, ".")
+	if !hasFraction && !hasExponent {
+		return 0, false
+	}
+	if !validDigits(integer, 10) || (len(integer) > 1 && integer[0] == '0') {
+		return 0, false
+	}
+	if hasFraction && !validDigits(fraction, 10) {
+		return 0, false
+	}
+	if hasExponent && !validDigits(strings.TrimLeft(exponent, "+-"), 10) {
+		return 0, false
+	}
+	if hasExponent && len(exponent)-len(strings.TrimLeft(exponent, "+-")) > 1 {
+		return 0, false
+	}
+
+	f, err := strconv.ParseFloat(strings.ReplaceAll(s, "_", ""), 64)
+
+	return f, err == nil
+}
+
+// validDigits checks that s is not empty, only contains digits for the base,
+// and that each underscore is between two digits.
+func validDigits(s string, base int) bool {
+	if s == "" || s[0] == '_' || s[len(s)-1] == '_' {
+		return false
+	}
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '_' {
+			if s[i+1] == '_' {
+				return false
+			}
+			continue
+		}
+
+		var digit int
+		switch {
+		case c >= '0' && c <= '9':
+			digit = int(c - '0')
+		case c >= 'a' && c <= 'f':
+			digit = int(c-'a') + 10
+		case c >= 'A' && c <= 'F':
+			digit = int(c-'A') + 10
+		default:
+			return false
+		}
+		if digit >= base {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isValueChar(c byte) bool {
+	return isBareKeyChar(rune(c)) || c == '+' || c == '.' || c == ':'
+}
+
+// finish replaces the placeholders used for arrays of tables while parsing.
+func finish(m *orderedmap.OrderedMap[string, any]) {
+	for el := m.Front(); el != nil; el = el.Next() {
+		switch value := el.Value.(type) {
+		case *tableArray:
+			el.Value = value.tables
+			for _, table := range value.tables {
+				finish(table.(*orderedmap.OrderedMap[string, any]))
+			}
+
+		case *orderedmap.OrderedMap[string, any]:
+			finish(value)
+
+		case []any:
+			for _, item := range value {
+				if table, ok := item.(*orderedmap.OrderedMap[string, any]); ok {
+					finish(table)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package toml_test
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/elliotchance/orderedmap/v3"
+	"github.com/elliotchance/orderedmap/v3/toml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func table(t *testing.T, m *orderedmap.OrderedMap[string, any], key string) *orderedmap.OrderedMap[string, any] {
+	t.Helper()
+	value, ok := m.Get(key)
+	require.True(t, ok, "missing key %q", key)
+	table, ok := value.(*orderedmap.OrderedMap[string, any])
+	require.True(t, ok, "%q is %T", key, value)
+
+	return table
+}
+
+func TestUnmarshal(t *testing.T) {
+	t.Run("KeysAndTablesRetainOrder", func(t *testing.T) {
+		m, err := toml.Unmarshal([]byte(`
+title = "example"
+zebra = 1
+apple = 2
+
+[servers.beta]
+ip = "10.0.0.2"
+
+[servers.alpha]
+ip = "10.0.0.1"
+
+[database]
+ports = [8000, 8001]
+`))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"title", "zebra", "apple", "servers", "database"},
+			slices.Collect(m.Keys()))
+
+		servers := table(t, m, "servers")
+		assert.Equal(t, []string{"beta", "alpha"}, slices.Collect(servers.Keys()))
+		assert.Equal(t, "10.0.0.1", table(t, servers, "alpha").GetOrDefault("ip", nil))
+		assert.Equal(t, []any{int64(8000), int64(8001)},
+			table(t, m, "database").GetOrDefault("ports", nil))
+	})
+
+	t.Run("ArrayOfTables", func(t *testing.T) {
+		m, err := toml.Unmarshal([]byte(`
+[[products]]
+name = "Hammer"
+sku = 738594937
+
+[[products]]
+
+[[products]]
+name = "Nail"
+color = "gray"
+
+[[products.parts]]
+id = 1
+`))
+		require.NoError(t, err)
+		products := m.GetOrDefault("products", nil).([]any)
+		require.Len(t, products, 3)
+
+		assert.Equal(t, []string{"name", "sku"},
+			slices.Collect(products[0].(*orderedmap.OrderedMap[string, any]).Keys()))
+		assert.Equal(t, 0, products[1].(*orderedmap.OrderedMap[string, any]).Len())
+
+		nail := products[2].(*orderedmap.OrderedMap[string, any])
+		assert.Equal(t, []string{"name", "color", "parts"}, slices.Collect(nail.Keys()))
+		assert.Len(t, nail.GetOrDefault("parts", nil), 1)
+	})
+
+	t.Run("DottedKeysAndInlineTables", func(t *testing.T) {
+		m, err := toml.Unmarshal([]byte(`
+fruit.apple.color = "red"
+fruit.apple.taste.sweet = true
+point = { y = 2, x = 1, z.w = 3 }
+
+[fruit.apple.texture]
+smooth = true
+`))
+		require.NoError(t, err)
+		apple := table(t, table(t, m, "fruit"), "apple")
+		assert.Equal(t, []string{"color", "taste", "texture"}, slices.Collect(apple.Keys()))
+
+		point := table(t, m, "point")
+		assert.Equal(t, []string{"y", "x", "z"}, slices.Collect(point.Keys()))
+		assert.Equal(t, int64(3), table(t, point, "z").GetOrDefault("w", nil))
+	})
+
+	t.Run("Values", func(t *testing.T) {
+		m, err := toml.Unmarshal([]byte(`
+basic = "tab\tquote\" \u00e9 \U0001F600"
+literal = 'C:\Users\nodejs'
+multi = """
+Roses are red
+Violets are blue"""
+trimmed = """\
+    The quick \
+    brown fox."""
+quotes = """Here are two quotation marks: "". Simple enough.""""
+multiLiteral = '''
+The first newline is
+trimmed in raw strings.'''
+"quoted key" = 1
+'literal key' = 2
+ints = [+99, 42, 0, -17, 1_000, 0xDEAD_beef, 0o755, 0b1101]
+floats = [+1.0, 3.1415, -0.01, 5e+22, 1e06, -2E-2, 6.626e-34, 224_617.445_991]
+special = [inf, +inf, -inf]
+bools = [true, false]
+odt = 1979-05-27T07:32:00Z
+odtSpace = 1979-05-27 00:32:00.999999-07:00
+ldt = 1979-05-27T07:32:00.5
+ld = 1979-05-27
+lt = 00:32:00.999999
+nested = [ [ 1, 2 ], ["a", 'b'], [ { x = 1 } ], ]
+multiLineArray = [
+  1, # one
+  2,
+]
+`))
+		require.NoError(t, err)
+
+		get := func(key string) any {
+			v, ok := m.Get(key)
+			require.True(t, ok, key)
+			return v
+		}
+		assert.Equal(t, "tab\tquote\" \u00e9 \U0001F600", get("basic"))
+		assert.Equal(t, `C:\Users\nodejs`, get("literal"))
+		assert.Equal(t, "Roses are red\nViolets are blue", get("multi"))
+		assert.Equal(t, "The quick brown fox.", get("trimmed"))
+		assert.Equal(t, `Here are two quotation marks: "". Simple enough."`, get("quotes"))
+		assert.Equal(t, "The first newline is\ntrimmed in raw strings.", get("multiLiteral"))
+		assert.Equal(t, int64(1), get("quoted key"))
+		assert.Equal(t, int64(2), get("literal key"))
+		assert.Equal(t, []any{int64(99), int64(42), int64(0), int64(-17),
+			int64(1000), int64(0xdeadbeef), int64(0o755), int64(0b1101)}, get("ints"))
+		assert.Equal(t, []any{1.0, 3.1415, -0.01, 5e+22, 1e06, -2e-2, 6.626e-34,
+			224617.445991}, get("floats"))
+		assert.Equal(t, []any{math.Inf(1), math.Inf(1), math.Inf(-1)}, get("special"))
+		assert.Equal(t, []any{true, false}, get("bools"))
+
+		assert.True(t, time.Date(1979, 5, 27, 7, 32, 0, 0, time.UTC).Equal(get("odt").(time.Time)))
+		assert.True(t, time.Date(1979, 5, 27, 7, 32, 0, 999999000, time.UTC).Equal(get("odtSpace").(time.Time)))
+		assert.Equal(t, toml.LocalDateTime{
+			LocalDate: toml.LocalDate{Year: 1979, Month: 5, Day: 27},
+			LocalTime: toml.LocalTime{Hour: 7, Minute: 32, Nanosecond: 500000000},
+		}, get("ldt"))
+		assert.Equal(t, toml.LocalDate{Year: 1979, Month: 5, Day: 27}, get("ld"))
+		assert.Equal(t, toml.LocalTime{Minute: 32, Nanosecond: 999999000}, get("lt"))
+
+		nested := get("nested").([]any)
+		assert.Equal(t, []any{int64(1), int64(2)}, nested[0])
+		assert.Equal(t, []any{"a", "b"}, nested[1])
+		assert.Equal(t, []any{int64(1), int64(2)}, get("multiLineArray"))
+	})
+
+	t.Run("NaN", func(t *testing.T) {
+		m, err := toml.Unmarshal([]byte("a = nan\nb = -nan\n"))
+		require.NoError(t, err)
+		assert.True(t, math.IsNaN(m.GetOrDefault("a", nil).(float64)))
+		assert.True(t, math.IsNaN(m.GetOrDefault("b", nil).(float64)))
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		for name, doc := range map[string]string{
+			"DuplicateKey":           "a = 1\na = 2",
+			"DuplicateTable":         "[a]\n[a]",
+			"TableAfterDottedKey":    "a.b = 1\n[a]",
+			"DottedKeyIntoHeader":    "[a.b.c]\nz = 1\n[a]\nb.c.t = 1",
+			"ExtendInlineTable":      "a = {b = 1}\n[a.c]",
+			"DottedIntoInlineTable":  "a = {b = 1}\na.c = 2",
+			"AppendStaticArray":      "a = []\n[[a]]",
+			"TableOverArrayOfTables": "[[a]]\n[a]",
+			"ArrayOfTablesOverTable": "[a]\n[[a]]",
+			"KeyWithoutValue":        "a =",
+			"MissingEquals":          "a 1",
+			"TwoValuesOnLine":        "a = 1 b = 2",
+			"UnterminatedString":     "a = \"foo",
+			"UnterminatedArray":      "a = [1, 2",
+			"InvalidEscape":          `a = "\q"`,
+			"LeadingZero":            "a = 012",
+			"DoubleUnderscore":       "a = 1__0",
+			"TrailingUnderscore":     "a = 10_",
+			"FloatWithoutDigits":     "a = 1.",
+			"FloatLeadingDot":        "a = .5",
+			"InvalidDate":            "a = 1979-13-27",
+			"InvalidTime":            "a = 25:00:00",
+			"MissingTableClose":      "[a\nb = 1",
+			"NewlineInInlineTable":   "a = {\nb = 1 }",
+			"TrailingCommaInline":    "a = {b = 1,}",
+			"BareKeyInvalidChar":     "a! = 1",
+			"ControlCharInString":    "a = \"\x01\"",
+			"IntegerOverflow":        "a = 9223372036854775808",
+			"SignedHex":              "a = +0x10",
+			"InvalidUTF8":            "a = \"\xff\"",
+		} {
+			t.Run(name, func(t *testing.T) {
+				_, err := toml.Unmarshal([]byte(doc))
+				var parseErr *toml.ParseError
+				assert.ErrorAs(t, err, &parseErr)
+			})
+		}
+	})
+
+	t.Run("MaxDepth", func(t *testing.T) {
+		m, err := toml.Unmarshal([]byte("a = " + strings.Repeat("[{b = ", 50) + "1" + strings.Repeat("}]", 50)))
+		require.NoError(t, err)
+		assert.Len(t, m.GetOrDefault("a", nil), 1)
+
+		_, err = toml.Unmarshal([]byte("a = " + strings.Repeat("[", 5000000)))
+		var parseErr *toml.ParseError
+		require.ErrorAs(t, err, &parseErr)
+		assert.Contains(t, parseErr.Message, "exceeded max depth")
+	})
+
+	t.Run("ErrorLine", func(t *testing.T) {
+		_, err := toml.Unmarshal([]byte("a = 1\n\n# comment\nb = \n"))
+		var parseErr *toml.ParseError
+		require.ErrorAs(t, err, &parseErr)
+		assert.Equal(t, 4, parseErr.Line)
+	})
+}
+
+func TestDecoder(t *testing.T) {
+	m, err := toml.NewDecoder(strings.NewReader("b = 1\na = 2\n")).Decode()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b", "a"}, slices.Collect(m.Keys()))
+}
@@ -0,0 +1,371 @@
+package toml
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/elliotchance/orderedmap/v3"
+)
+
+// Encoder writes TOML documents to an output stream.
+type Encoder struct {
+	w *bufio.Writer
+
+	// wroteLine is used to put a blank line before each table header, except
+	// at the start of the document.
+	wroteLine bool
+}
+
+// NewEncoder returns a new encoder that writes to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{
+		w: bufio.NewWriter(w),
+	}
+}
+
+// Marshal returns the TOML encoding of m.
+func Marshal(m *orderedmap.OrderedMap[string, any]) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := NewEncoder(&buf).Encode(m); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Encode writes m as a TOML document.
+//
+// Keys are written in the same order as the map. However, TOML requires that
+// all the key/value pairs of a table come before any sub-tables, so within
+// each table the plain values are written first (in order) followed by the
+// tables and arrays of tables (in order).
+//
+// Values inside arrays are written inline, so an ordered map in an array that
+// also contains other kinds of values is written as an inline table. A nil
+// value cannot be represented in TOML and is an error.
+func (e *Encoder) Encode(m *orderedmap.OrderedMap[string, any]) error {
+	if m == nil {
+		return nil
+	}
+
+	if err := e.table(nil, m, false); err != nil {
+		return err
+	}
+
+	return e.w.Flush()
+}
+
+func (e *Encoder) table(path []string, m *orderedmap.OrderedMap[string, any], isArray bool) error {
+	type member struct {
+		key   string
+		value any
+	}
+
+	var values, tables []member
+	for key, value := range m.AllFromFront() {
+		value, err := normalize(value)
+		if err != nil {
+			return fmt.Errorf("toml: %s: %w", joinKey(append(path, key)), err)
+		}
+
+		if isTable(value) || isArrayOfTables(value) {
+			tables = append(tables, member{key, value})
+		} else {
+			values = append(values, member{key, value})
+		}
+	}
+
+	// A table that only contains other tables is implicitly created by their
+	// headers, so it does not need its own.
+	if path != nil && (isArray || len(values) > 0 || len(tables) == 0) {
+		if e.wroteLine {
+			e.w.WriteByte('\n')
+		}
+		if isArray {
+			fmt.Fprintf(e.w, "[[%s]]\n", joinKey(path))
+		} else {
+			fmt.Fprintf(e.w, "[%s]\n", joinKey(path))
+		}
+		e.wroteLine = true
+	}
+
+	for _, el := range values {
+		e.w.WriteString(formatKey(el.key))
+		e.w.WriteString(" = ")
+		if err := e.value(el.value); err != nil {
+			return fmt.Errorf("toml: %s: %w", joinKey(append(path, el.key)), err)
+		}
+		e.w.WriteByte('\n')
+		e.wroteLine = true
+	}
+
+	for _, el := range tables {
+		childPath := append(slices.Clip(path), el.key)
+		if table, ok := el.value.(*orderedmap.OrderedMap[string, any]); ok {
+			if err := e.table(childPath, table, false); err != nil {
+				return err
+			}
+			continue
+		}
+
+		for _, table := range el.value.([]any) {
+			err := e.table(childPath, table.(*orderedmap.OrderedMap[string, any]), true)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// value writes an inline value. v must have already been normalized.
+func (e *Encoder) value(v any) error {
+	switch v := v.(type) {
+	case nil:
+		return fmt.Errorf("cannot encode nil value")
+
+	case string:
+		e.w.WriteString(quote(v))
+
+	case bool:
+		e.w.WriteString(strconv.FormatBool(v))
+
+	case int64:
+		e.w.WriteString(strconv.FormatInt(v, 10))
+
+	case float64:
+		e.w.WriteString(formatFloat(v))
+
+	case time.Time:
+		e.w.WriteString(v.Format(time.RFC3339Nano))
+
+	case LocalDateTime, LocalDate, LocalTime:
+		e.w.WriteString(v.(fmt.Stringer).String())
+
+	case []any:
+		e.w.WriteByte('[')
+		for i, item := range v {
+			if i > 0 {
+				e.w.WriteString(", ")
+			}
+			if err := e.value(item); err != nil {
+				return err
+			}
+		}
+		e.w.WriteByte(']')
+
+	case *orderedmap.OrderedMap[string, any]:
+		e.w.WriteByte('{')
+		for el := v.Front(); el != nil; el = el.Next() {
+			if el != v.Front() {
+				e.w.WriteByte(',')
+			}
+			e.w.WriteByte(' ')
+			e.w.WriteString(formatKey(el.Key))
+			e.w.WriteString(" = ")
+			value, err := normalize(el.Value)
+			if err != nil {
+				return err
+			}
+			if err := e.value(value); err != nil {
+				return err
+			}
+		}
+		if v.Len() > 0 {
+			e.w.WriteByte(' ')
+		}
+		e.w.WriteByte('}')
+
+	default:
+		return fmt.Errorf("cannot encode value of type %T", v)
+	}
+
+	return nil
+}
+
+// normalize converts the many Go types that can be encoded into the smaller
+// set of types that are produced by the decoder. The values of ordered maps are
+// normalized as each map is written.
+func normalize(v any) (any, error) {
+	switch v := v.(type) {
+	case nil, string, bool, int64, float64, time.Time, LocalDateTime, LocalDate,
+		LocalTime:
+		return v, nil
+
+	case *orderedmap.OrderedMap[string, any]:
+		if v == nil {
+			return nil, nil
+		}
+
+		return v, nil
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64, reflect.Uintptr:
+		if rv.Uint() > math.MaxInt64 {
+			return nil, fmt.Errorf("integer %d overflows int64", rv.Uint())
+		}
+		return int64(rv.Uint()), nil
+
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), nil
+
+	case reflect.String:
+		return rv.String(), nil
+
+	case reflect.Bool:
+		return rv.Bool(), nil
+
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			return nil, nil
+		}
+		values := make([]any, rv.Len())
+		for i := range values {
+			value, err := normalize(rv.Index(i).Interface())
+			if err != nil {
+				return nil, err
+			}
+			values[i] = value
+		}
+
+		return values, nil
+
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			break
+		}
+		keys := rv.MapKeys()
+		slices.SortFunc(keys, func(a, b reflect.Value) int {
+			return strings.Compare(a.String(), b.String())
+		})
+		m := orderedmap.NewOrderedMapWithCapacity[string, any](len(keys))
+		for _, key := range keys {
+			value, err := normalize(rv.MapIndex(key).Interface())
+			if err != nil {
+				return nil, err
+			}
+			m.Set(key.String(), value)
+		}
+
+		return m, nil
+
+	case reflect.Pointer:
+		if !rv.IsNil() {
+			return normalize(rv.Elem().Interface())
+		}
+		return nil, nil
+	}
+
+	return nil, fmt.Errorf("cannot encode value of type %T", v)
+}
+
+func isTable(v any) bool {
+	_, ok := v.(*orderedmap.OrderedMap[string, any])
+	return ok
+}
+
+func isArrayOfTables(v any) bool {
+	values, ok := v.([]any)
+	if !ok || len(values) == 0 {
+		return false
+	}
+
+	for _, value := range values {
+		if !isTable(value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "inf"
+
+	case math.IsInf(f, -1):
+		return "-inf"
+
+	case math.IsNaN(f):
+		return "nan"
+	}
+
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".e") {
+		s += ".0"
+	}
+
+	return s
+}
+
+func formatKey(key string) string {
+	if key == "" {
+		return `""`
+	}
+
+	for _, c := range key {
+		if !isBareKeyChar(c) {
+			return quote(key)
+		}
+	}
+
+	return key
+}
+
+func joinKey(path []string) string {
+	keys := make([]string, len(path))
+	for i, key := range path {
+		keys[i] = formatKey(key)
+	}
+
+	return strings.Join(keys, ".")
+}
+
+// quote returns s as a TOML basic string.
+func quote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, c := range s {
+		switch c {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			if c < 0x20 || c == 0x7f {
+				fmt.Fprintf(&b, `\u%04X`, c)
+			} else {
+				b.WriteRune(c)
+			}
+		}
+	}
+	b.WriteByte('"')
+
+	return b.String()
+}
@@ -0,0 +1,152 @@
+package toml_test
+
+import (
+	"bytes"
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/elliotchance/orderedmap/v3"
+	"github.com/elliotchance/orderedmap/v3/toml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshal(t *testing.T) {
+	t.Run("TablesAndKeysRetainOrder", func(t *testing.T) {
+		alpha := orderedmap.NewOrderedMap[string, any]()
+		alpha.Set("ip", "10.0.0.1")
+		beta := orderedmap.NewOrderedMap[string, any]()
+		beta.Set("ip", "10.0.0.2")
+		beta.Set("role", "backup")
+
+		servers := orderedmap.NewOrderedMap[string, any]()
+		servers.Set("beta", beta)
+		servers.Set("alpha", alpha)
+
+		m := orderedmap.NewOrderedMap[string, any]()
+		m.Set("zebra", 1)
+		m.Set("servers", servers)
+		m.Set("apple", "two")
+
+		data, err := toml.Marshal(m)
+		require.NoError(t, err)
+		assert.Equal(t, `zebra = 1
+apple = "two"
+
+[servers.beta]
+ip = "10.0.0.2"
+role = "backup"
+
+[servers.alpha]
+ip = "10.0.0.1"
+`, string(data))
+	})
+
+	t.Run("ArrayOfTables", func(t *testing.T) {
+		hammer := orderedmap.NewOrderedMap[string, any]()
+		hammer.Set("name", "Hammer")
+		nail := orderedmap.NewOrderedMap[string, any]()
+		nail.Set("name", "Nail")
+
+		m := orderedmap.NewOrderedMap[string, any]()
+		m.Set("products", []*orderedmap.OrderedMap[string, any]{hammer, nail})
+		m.Set("empty", orderedmap.NewOrderedMap[string, any]())
+
+		data, err := toml.Marshal(m)
+		require.NoError(t, err)
+		assert.Equal(t, `[[products]]
+name = "Hammer"
+
+[[products]]
+name = "Nail"
+
+[empty]
+`, string(data))
+	})
+
+	t.Run("Values", func(t *testing.T) {
+		inline := orderedmap.NewOrderedMap[string, any]()
+		inline.Set("y", 2)
+		inline.Set("x", 1)
+
+		m := orderedmap.NewOrderedMap[string, any]()
+		m.Set("string", "a \"quote\"\n\x01")
+		m.Set("int", uint8(7))
+		m.Set("float", 3.0)
+		m.Set("big", 1e22)
+		m.Set("inf", math.Inf(-1))
+		m.Set("bool", true)
+		m.Set("time", time.Date(1979, 5, 27, 7, 32, 0, 0, time.UTC))
+		m.Set("date", toml.LocalDate{Year: 1979, Month: 5, Day: 27})
+		m.Set("ints", []int{1, 2})
+		m.Set("mixed", []any{inline, "a"})
+		m.Set("plain", map[string]int{"b": 2, "a": 1})
+		m.Set("key with spaces", "ok")
+
+		data, err := toml.Marshal(m)
+		require.NoError(t, err)
+		assert.Equal(t, `string = "a \"quote\"\n\u0001"
+int = 7
+float = 3.0
+big = 1e+22
+inf = -inf
+bool = true
+time = 1979-05-27T07:32:00Z
+date = 1979-05-27
+ints = [1, 2]
+mixed = [{ y = 2, x = 1 }, "a"]
+"key with spaces" = "ok"
+
+[plain]
+a = 1
+b = 2
+`, string(data))
+	})
+
+	t.Run("NilValue", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, any]()
+		m.Set("a", nil)
+		_, err := toml.Marshal(m)
+		assert.Error(t, err)
+	})
+
+	t.Run("UnsupportedValue", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, any]()
+		m.Set("a", func() {})
+		_, err := toml.Marshal(m)
+		assert.Error(t, err)
+	})
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		src := `title = "TOML"
+ratio = 0.5
+when = 1979-05-27T07:32:00.5
+list = [[1, 2], [{ a = 1 }]]
+
+[owner]
+name = "Tom"
+
+[database.connection]
+ports = [8000, 8001]
+
+[[fruits]]
+name = "apple"
+
+[fruits.physical]
+color = "red"
+
+[[fruits]]
+name = "banana"
+`
+		m, err := toml.Unmarshal([]byte(src))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"title", "ratio", "when", "list", "owner",
+			"database", "fruits"}, slices.Collect(m.Keys()))
+
+		var buf bytes.Buffer
+		require.NoError(t, toml.NewEncoder(&buf).Encode(m))
+		assert.Equal(t, src, buf.String())
+	})
+}
@@ -0,0 +1,91 @@
+// Package toml encodes and decodes TOML documents to and from trees of
+// *orderedmap.OrderedMap[string, any], keeping the order of keys and tables.
+//
+// When decoding, each table (including inline tables and each table in an
+// array of tables) becomes an *orderedmap.OrderedMap[string, any] with the keys
+// in the order they first appear in the document. TOML values are decoded into
+// the following types:
+//
+//	String            string
+//	Integer           int64
+//	Float             float64
+//	Boolean           bool
+//	Offset Date-Time  time.Time
+//	Local Date-Time   LocalDateTime
+//	Local Date        LocalDate
+//	Local Time        LocalTime
+//	Array             []any
+//	Array of Tables   []any (containing only ordered maps)
+//	Table             *orderedmap.OrderedMap[string, any]
+//
+// When encoding, the same types are accepted as well as any other integer,
+// float or slice type, and plain maps with string keys (which are written with
+// their keys sorted).
+package toml
+
+import (
+	"fmt"
+	"time"
+)
+
+// LocalDate is a TOML Local Date, which is a date without a time or offset.
+type LocalDate struct {
+	Year  int
+	Month time.Month
+	Day   int
+}
+
+// String returns the date in the format YYYY-MM-DD.
+func (d LocalDate) String() string {
+	return fmt.Sprintf("%04d-%02d-%02d", d.Year, d.Month, d.Day)
+}
+
+// LocalTime is a TOML Local Time, which is a time of day without a date or
+// offset.
+type LocalTime struct {
+	Hour       int
+	Minute     int
+	Second     int
+	Nanosecond int
+}
+
+// String returns the time in the format HH:MM:SS with as many fractional
+// digits as are needed.
+func (t LocalTime) String() string {
+	s := fmt.Sprintf("%02d:%02d:%02d", t.Hour, t.Minute, t.Second)
+	if t.Nanosecond == 0 {
+		return s
+	}
+
+	frac := fmt.Sprintf("%09d", t.Nanosecond)
+	for frac[len(frac)-1] == '0' {
+		frac = frac[:len(frac)-1]
+	}
+
+	return s + "." + frac
+}
+
+// LocalDateTime is a TOML Local Date-Time, which is a date and time without an
+// offset.
+type LocalDateTime struct {
+	LocalDate
+	LocalTime
+}
+
+// String returns the date and time in the format YYYY-MM-DDTHH:MM:SS.
+func (dt LocalDateTime) String() string {
+	return dt.LocalDate.String() + "T" + dt.LocalTime.String()
+}
+
+// ParseError is returned when a document is not valid TOML.
+type ParseError struct {
+	// Line is the line number (starting at 1) where the error was found.
+	Line int
+
+	// Message describes the problem.
+	Message string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("toml: line %d: %s", e.Line, e.Message)
+}
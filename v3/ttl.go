@@ -0,0 +1,287 @@
+package orderedmap
+
+import (
+	"iter"
+	"sync"
+	"time"
+)
+
+// TTLOrderedMap is an ordered map where each element expires after a duration.
+// Expired elements are treated as missing, and are removed by Sweep (which can
+// be called periodically by StartJanitor). It is safe for concurrent use.
+//
+// The elements are ordered by the time they expire, so that Sweep only needs to
+// look at the expired elements at the front. When every element has the same
+// duration this is also the order in which they were last set. Setting an
+// element looks for its position from the back, so it is O(1) in that case.
+// Elements that never expire are kept at the back.
+type TTLOrderedMap[K comparable, V any] struct {
+	mu  sync.RWMutex
+	m   OrderedMap[K, ttlEntry[V]]
+	ttl time.Duration
+	now func() time.Time
+}
+
+type ttlEntry[V any] struct {
+	value V
+
+	// expires is zero if the element never expires.
+	expires time.Time
+}
+
+// NewTTLOrderedMap creates a map where elements set with Set expire after ttl.
+// If ttl is zero or less they never expire.
+func NewTTLOrderedMap[K comparable, V any](ttl time.Duration) *TTLOrderedMap[K, V] {
+	return NewTTLOrderedMapWithClock[K, V](ttl, time.Now)
+}
+
+// NewTTLOrderedMapWithClock creates a map like NewTTLOrderedMap that gets the
+// current time from now rather than time.Now, so that tests can control when
+// elements expire.
+func NewTTLOrderedMapWithClock[K comparable, V any](ttl time.Duration, now func() time.Time) *TTLOrderedMap[K, V] {
+	return &TTLOrderedMap[K, V]{
+		m:   OrderedMap[K, ttlEntry[V]]{kv: make(map[K]*Element[K, ttlEntry[V]])},
+		ttl: ttl,
+		now: now,
+	}
+}
+
+// expiresBefore returns true if a expires before b.
+func expiresBefore(a, b time.Time) bool {
+	return !a.IsZero() && (b.IsZero() || a.Before(b))
+}
+
+// expired returns true if e has expired at now.
+func (e ttlEntry[V]) expired(now time.Time) bool {
+	return !e.expires.IsZero() && !now.Before(e.expires)
+}
+
+// firstLive returns the first element that has not expired at now.
+func (m *TTLOrderedMap[K, V]) firstLive(now time.Time) *Element[K, ttlEntry[V]] {
+	el := m.m.Front()
+	for el != nil && el.Value.expired(now) {
+		el = el.Next()
+	}
+
+	return el
+}
+
+// Get returns the value for a key. If the key does not exist or has expired,
+// the second return parameter will be false and the value will be nil.
+func (m *TTLOrderedMap[K, V]) Get(key K) (value V, ok bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	entry, ok := m.m.Get(key)
+	if !ok || entry.expired(m.now()) {
+		return value, false
+	}
+
+	return entry.value, true
+}
+
+// GetOrDefault returns the value for a key. If the key does not exist or has
+// expired, returns the default value instead.
+func (m *TTLOrderedMap[K, V]) GetOrDefault(key K, defaultValue V) V {
+	if value, ok := m.Get(key); ok {
+		return value
+	}
+
+	return defaultValue
+}
+
+// Has checks if a key exists in the map and has not expired.
+func (m *TTLOrderedMap[K, V]) Has(key K) bool {
+	_, ok := m.Get(key)
+
+	return ok
+}
+
+// Expires returns the time that the element for key expires. If the key does
+// not exist or has expired, the second return parameter will be false. If the
+// element never expires the time will be zero.
+func (m *TTLOrderedMap[K, V]) Expires(key K) (time.Time, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	entry, ok := m.m.Get(key)
+	if !ok || entry.expired(m.now()) {
+		return time.Time{}, false
+	}
+
+	return entry.expires, true
+}
+
+// Len returns the number of elements in the map that have not expired. It
+// takes O(expired), since the elements that have expired but have not been
+// swept yet are skipped.
+func (m *TTLOrderedMap[K, V]) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	n := m.m.Len()
+	now := m.now()
+	for el := m.m.Front(); el != nil && el.Value.expired(now); el = el.Next() {
+		n--
+	}
+
+	return n
+}
+
+// Set will set (or replace) a value for a key, which will expire after the
+// duration given to the constructor. If the key was new (or had expired), then
+// true will be returned.
+func (m *TTLOrderedMap[K, V]) Set(key K, value V) bool {
+	return m.SetWithTTL(key, value, m.ttl)
+}
+
+// SetWithTTL will set (or replace) a value for a key, which will expire after
+// ttl. If ttl is zero or less it never expires. If the key was new (or had
+// expired), then true will be returned.
+func (m *TTLOrderedMap[K, V]) SetWithTTL(key K, value V, ttl time.Duration) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	entry := ttlEntry[V]{value: value}
+	if ttl > 0 {
+		entry.expires = now.Add(ttl)
+	}
+
+	existing := m.m.GetElement(key)
+	isNew := existing == nil || existing.Value.expired(now)
+
+	mark := m.m.Back()
+	for mark != nil && (mark == existing || expiresBefore(entry.expires, mark.Value.expires)) {
+		mark = mark.Prev()
+	}
+	m.m.setAfter(key, entry, mark)
+
+	return isNew
+}
+
+// Delete will remove a key from the map. It will return true if the key was
+// removed (the key did exist and had not expired).
+func (m *TTLOrderedMap[K, V]) Delete(key K) (didDelete bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.m.Get(key)
+	if !ok {
+		return false
+	}
+	m.m.Delete(key)
+
+	return !entry.expired(m.now())
+}
+
+// Sweep removes the expired elements and returns the number of elements that
+// were removed. It takes O(expired).
+func (m *TTLOrderedMap[K, V]) Sweep() (removed int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	for el := m.m.Front(); el != nil && el.Value.expired(now); el = m.m.Front() {
+		m.m.Delete(el.Key)
+		removed++
+	}
+
+	return
+}
+
+// StartJanitor starts a goroutine that calls Sweep every interval. The returned
+// function stops the goroutine, and waits for it to finish.
+func (m *TTLOrderedMap[K, V]) StartJanitor(interval time.Duration) (stop func()) {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case <-ticker.C:
+				m.Sweep()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			ticker.Stop()
+			close(done)
+			<-stopped
+		})
+	}
+}
+
+// AllFromFront returns an iterator that yields all elements that have not
+// expired, starting at the front (the element that expires first).
+//
+// The elements are copied while the map is locked, and yielded after it is
+// unlocked, so any method (including changes) can be used inside the loop. The
+// loop sees the map as it was when it started.
+func (m *TTLOrderedMap[K, V]) AllFromFront() iter.Seq2[K, V] {
+	return func(yield func(key K, value V) bool) {
+		for _, el := range m.live() {
+			if !yield(el.Key, el.Value) {
+				return
+			}
+		}
+	}
+}
+
+// AllFromBack returns an iterator that yields all elements that have not
+// expired, starting at the back (the element that expires last). See
+// AllFromFront.
+func (m *TTLOrderedMap[K, V]) AllFromBack() iter.Seq2[K, V] {
+	return func(yield func(key K, value V) bool) {
+		elements := m.live()
+		for i := len(elements) - 1; i >= 0; i-- {
+			if !yield(elements[i].Key, elements[i].Value) {
+				return
+			}
+		}
+	}
+}
+
+// live returns a copy of the elements that have not expired, from the front.
+func (m *TTLOrderedMap[K, V]) live() []Element[K, V] {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var elements []Element[K, V]
+	for el := m.firstLive(m.now()); el != nil; el = el.Next() {
+		elements = append(elements, Element[K, V]{Key: el.Key, Value: el.Value.value})
+	}
+
+	return elements
+}
+
+// Keys returns an iterator that yields all the keys that have not expired,
+// starting at the front. See AllFromFront.
+func (m *TTLOrderedMap[K, V]) Keys() iter.Seq[K] {
+	return func(yield func(key K) bool) {
+		for key := range m.AllFromFront() {
+			if !yield(key) {
+				return
+			}
+		}
+	}
+}
+
+// Values returns an iterator that yields all the values that have not expired,
+// starting at the front. See AllFromFront.
+func (m *TTLOrderedMap[K, V]) Values() iter.Seq[V] {
+	return func(yield func(value V) bool) {
+		for _, value := range m.AllFromFront() {
+			if !yield(value) {
+				return
+			}
+		}
+	}
+}
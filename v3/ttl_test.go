@@ -0,0 +1,188 @@
+package orderedmap_test
+
+import (
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/elliotchance/orderedmap/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+func newTTLOrderedMap(ttl time.Duration) (*orderedmap.TTLOrderedMap[string, int], *fakeClock) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	return orderedmap.NewTTLOrderedMapWithClock[string, int](ttl, clock.Now), clock
+}
+
+func TestTTLOrderedMap(t *testing.T) {
+	t.Run("Expiry", func(t *testing.T) {
+		m, clock := newTTLOrderedMap(time.Minute)
+		assert.True(t, m.Set("a", 1))
+		clock.Advance(30 * time.Second)
+		assert.True(t, m.Set("b", 2))
+
+		value, ok := m.Get("a")
+		assert.True(t, ok)
+		assert.Equal(t, 1, value)
+		assert.Equal(t, 2, m.Len())
+
+		clock.Advance(30 * time.Second)
+		_, ok = m.Get("a")
+		assert.False(t, ok)
+		assert.False(t, m.Has("a"))
+		assert.Equal(t, -1, m.GetOrDefault("a", -1))
+		assert.Equal(t, 2, m.GetOrDefault("b", -1))
+		assert.Equal(t, 1, m.Len())
+		assert.Equal(t, []string{"b"}, slices.Collect(m.Keys()))
+
+		// Setting an expired key is the same as setting a new key.
+		assert.True(t, m.Set("a", 3))
+		assert.False(t, m.Set("a", 4))
+		assert.Equal(t, []string{"b", "a"}, slices.Collect(m.Keys()))
+		assert.Equal(t, []int{2, 4}, slices.Collect(m.Values()))
+	})
+
+	t.Run("SetRefreshesExpiry", func(t *testing.T) {
+		m, clock := newTTLOrderedMap(time.Minute)
+		m.Set("a", 1)
+		m.Set("b", 2)
+		clock.Advance(30 * time.Second)
+		m.Set("a", 10)
+
+		clock.Advance(45 * time.Second)
+		assert.Equal(t, []string{"a"}, slices.Collect(m.Keys()))
+
+		expires, ok := m.Expires("a")
+		assert.True(t, ok)
+		assert.Equal(t, clock.Now().Add(15*time.Second), expires)
+		_, ok = m.Expires("b")
+		assert.False(t, ok)
+	})
+
+	t.Run("SetWithTTL", func(t *testing.T) {
+		m, clock := newTTLOrderedMap(time.Minute)
+		m.Set("a", 1)
+		m.SetWithTTL("forever", 0, 0)
+		m.SetWithTTL("short", 2, time.Second)
+		m.SetWithTTL("long", 3, time.Hour)
+		m.Set("b", 4)
+		assert.Equal(t, []string{"short", "a", "b", "long", "forever"}, slices.Collect(m.Keys()))
+
+		expires, ok := m.Expires("forever")
+		assert.True(t, ok)
+		assert.True(t, expires.IsZero())
+
+		clock.Advance(time.Minute)
+		assert.Equal(t, []string{"long", "forever"}, slices.Collect(m.Keys()))
+
+		var keys []string
+		for key := range m.AllFromBack() {
+			keys = append(keys, key)
+		}
+		assert.Equal(t, []string{"forever", "long"}, keys)
+
+		clock.Advance(24 * time.Hour)
+		assert.Equal(t, []string{"forever"}, slices.Collect(m.Keys()))
+		assert.Equal(t, 4, m.Sweep())
+	})
+
+	t.Run("Sweep", func(t *testing.T) {
+		m, clock := newTTLOrderedMap(time.Minute)
+		m.Set("a", 1)
+		m.Set("b", 2)
+		clock.Advance(time.Second)
+		m.Set("c", 3)
+		assert.Equal(t, 0, m.Sweep())
+
+		clock.Advance(time.Minute - time.Second)
+		assert.Equal(t, 2, m.Sweep())
+		assert.Equal(t, 0, m.Sweep())
+		assert.Equal(t, 1, m.Len())
+		assert.True(t, m.Set("a", 4))
+		assert.Equal(t, []string{"c", "a"}, slices.Collect(m.Keys()))
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		m, clock := newTTLOrderedMap(time.Minute)
+		m.Set("a", 1)
+		m.Set("b", 2)
+		assert.True(t, m.Delete("a"))
+		assert.False(t, m.Delete("a"))
+
+		clock.Advance(time.Minute)
+		assert.False(t, m.Delete("b"))
+		assert.Equal(t, 0, m.Sweep())
+	})
+
+	t.Run("UseInsideLoop", func(t *testing.T) {
+		m, clock := newTTLOrderedMap(time.Minute)
+		m.Set("a", 1)
+		m.Set("b", 2)
+		clock.Advance(time.Second)
+		m.Set("c", 3)
+		clock.Advance(time.Minute - time.Second)
+
+		var keys []string
+		for key := range m.AllFromFront() {
+			// A writer waiting for the lock would block this Get if the
+			// iterator still held a read lock.
+			swept := make(chan struct{})
+			go func() {
+				m.Sweep()
+				close(swept)
+			}()
+			select {
+			case <-swept:
+			case <-time.After(time.Second):
+				t.Fatal("Sweep is blocked by the iterator")
+			}
+
+			assert.True(t, m.Has(key))
+			m.Set("d", 4)
+			keys = append(keys, key)
+		}
+		assert.Equal(t, []string{"c"}, keys)
+		assert.Equal(t, []string{"c", "d"}, slices.Collect(m.Keys()))
+
+		for key := range m.AllFromBack() {
+			m.Delete(key)
+		}
+		assert.Equal(t, 0, m.Len())
+	})
+
+	t.Run("Janitor", func(t *testing.T) {
+		m, clock := newTTLOrderedMap(time.Minute)
+		m.Set("a", 1)
+		clock.Advance(time.Minute)
+		m.SetWithTTL("b", 2, 0)
+
+		stop := m.StartJanitor(time.Millisecond)
+		time.Sleep(50 * time.Millisecond)
+		stop()
+		stop()
+
+		assert.Equal(t, 0, m.Sweep())
+		assert.Equal(t, []string{"b"}, slices.Collect(m.Keys()))
+	})
+}
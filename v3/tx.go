@@ -0,0 +1,293 @@
+package orderedmap
+
+import (
+	"errors"
+	"iter"
+)
+
+// ErrTxDone is returned by Commit and Rollback when the transaction has already
+// been committed or rolled back.
+var ErrTxDone = errors.New("orderedmap: transaction has already been committed or rolled back")
+
+// Tx is a transaction started with OrderedMap.Begin. Changes made through the
+// Tx are kept in a private overlay and are only applied to the map, all
+// together, by Commit. Reads through the Tx see its own changes, and iterate in
+// the order the map will have after Commit.
+//
+// The map must not be changed directly while a transaction is open, and a Tx
+// must not be used after Commit or Rollback. A Tx is not safe for concurrent
+// use.
+type Tx[K comparable, V any] struct {
+	m *OrderedMap[K, V]
+
+	// slots are the changes to elements that exist in m, by their key in m.
+	// The element may have been deleted, or renamed to slot.key.
+	slots map[K]*txSlot[K, V]
+
+	// renamed maps the new key of each renamed element of m to its key in m.
+	renamed map[K]K
+
+	// deleted is the number of elements of m that have been deleted.
+	deleted int
+
+	// added are the new keys, in the order they will be added to the back of
+	// the map.
+	added *OrderedMap[K, V]
+
+	done bool
+}
+
+type txSlot[K comparable, V any] struct {
+	key     K
+	value   V
+	updated bool
+	deleted bool
+}
+
+// Begin starts a transaction. See Tx.
+func (m *OrderedMap[K, V]) Begin() *Tx[K, V] {
+	return &Tx[K, V]{
+		m:       m,
+		slots:   map[K]*txSlot[K, V]{},
+		renamed: map[K]K{},
+		added:   NewOrderedMap[K, V](),
+	}
+}
+
+// baseKey returns the key in m of the element that has key in the
+// transaction. The second return value is false if key is not an element of m
+// in the transaction (although it may be a new key).
+func (tx *Tx[K, V]) baseKey(key K) (K, bool) {
+	if original, ok := tx.renamed[key]; ok {
+		return original, true
+	}
+	if s, ok := tx.slots[key]; ok {
+		return key, !s.deleted && s.key == key
+	}
+
+	return key, tx.m.Has(key)
+}
+
+// slot returns the slot for an element of m, creating it if needed.
+func (tx *Tx[K, V]) slot(original K) *txSlot[K, V] {
+	s, ok := tx.slots[original]
+	if !ok {
+		value, _ := tx.m.Get(original)
+		s = &txSlot[K, V]{key: original, value: value}
+		tx.slots[original] = s
+	}
+
+	return s
+}
+
+// Get returns the value for a key in the transaction. If the key does not
+// exist, the second return parameter will be false and the value will be nil.
+func (tx *Tx[K, V]) Get(key K) (value V, ok bool) {
+	if value, ok := tx.added.Get(key); ok {
+		return value, true
+	}
+
+	original, ok := tx.baseKey(key)
+	if !ok {
+		return
+	}
+	if s, ok := tx.slots[original]; ok {
+		return s.value, true
+	}
+
+	return tx.m.Get(original)
+}
+
+// GetOrDefault returns the value for a key in the transaction. If the key does
+// not exist, returns the default value instead.
+func (tx *Tx[K, V]) GetOrDefault(key K, defaultValue V) V {
+	if value, ok := tx.Get(key); ok {
+		return value
+	}
+
+	return defaultValue
+}
+
+// Has checks if a key exists in the transaction.
+func (tx *Tx[K, V]) Has(key K) bool {
+	_, ok := tx.baseKey(key)
+
+	return ok || tx.added.Has(key)
+}
+
+// Len returns the number of elements the map will have after Commit.
+func (tx *Tx[K, V]) Len() int {
+	return tx.m.Len() - tx.deleted + tx.added.Len()
+}
+
+// Set will set (or replace) a value for a key. If the key was new, then true
+// will be returned. A new key will be added to the back of the map when the
+// transaction is committed.
+func (tx *Tx[K, V]) Set(key K, value V) bool {
+	if original, ok := tx.baseKey(key); ok {
+		s := tx.slot(original)
+		s.value, s.updated = value, true
+		return false
+	}
+
+	return tx.added.Set(key, value)
+}
+
+// Delete will remove a key. It will return true if the key was removed (the
+// key did exist).
+func (tx *Tx[K, V]) Delete(key K) (didDelete bool) {
+	if tx.added.Delete(key) {
+		return true
+	}
+
+	original, ok := tx.baseKey(key)
+	if !ok {
+		return false
+	}
+
+	tx.slot(original).deleted = true
+	delete(tx.renamed, key)
+	tx.deleted++
+
+	return true
+}
+
+// ReplaceKey replaces an existing key with a new key while preserving order of
+// the value. See OrderedMap.ReplaceKey.
+func (tx *Tx[K, V]) ReplaceKey(originalKey, newKey K) bool {
+	if !tx.Has(originalKey) || tx.Has(newKey) {
+		return false
+	}
+	if tx.added.Has(originalKey) {
+		return tx.added.ReplaceKey(originalKey, newKey)
+	}
+
+	original, _ := tx.baseKey(originalKey)
+	tx.slot(original).key = newKey
+	delete(tx.renamed, originalKey)
+	if newKey != original {
+		tx.renamed[newKey] = original
+	}
+
+	return true
+}
+
+// AllFromFront returns an iterator that yields all elements in the order the
+// map will have after Commit, starting at the front.
+func (tx *Tx[K, V]) AllFromFront() iter.Seq2[K, V] {
+	return func(yield func(key K, value V) bool) {
+		for el := tx.m.Front(); el != nil; el = el.Next() {
+			if !tx.yieldElement(el, yield) {
+				return
+			}
+		}
+		tx.added.AllFromFront()(yield)
+	}
+}
+
+// AllFromBack returns an iterator that yields all elements in the order the map
+// will have after Commit, starting at the back.
+func (tx *Tx[K, V]) AllFromBack() iter.Seq2[K, V] {
+	return func(yield func(key K, value V) bool) {
+		for key, value := range tx.added.AllFromBack() {
+			if !yield(key, value) {
+				return
+			}
+		}
+		for el := tx.m.Back(); el != nil; el = el.Prev() {
+			if !tx.yieldElement(el, yield) {
+				return
+			}
+		}
+	}
+}
+
+// yieldElement yields an element of m as it is in the transaction, unless it
+// has been deleted.
+func (tx *Tx[K, V]) yieldElement(el *Element[K, V], yield func(K, V) bool) bool {
+	s, ok := tx.slots[el.Key]
+	switch {
+	case !ok:
+		return yield(el.Key, el.Value)
+	case s.deleted:
+		return true
+	}
+
+	return yield(s.key, s.value)
+}
+
+// Keys returns an iterator that yields all the keys in the order the map will
+// have after Commit.
+func (tx *Tx[K, V]) Keys() iter.Seq[K] {
+	return func(yield func(key K) bool) {
+		for key := range tx.AllFromFront() {
+			if !yield(key) {
+				return
+			}
+		}
+	}
+}
+
+// Values returns an iterator that yields all the values in the order the map
+// will have after Commit.
+func (tx *Tx[K, V]) Values() iter.Seq[V] {
+	return func(yield func(value V) bool) {
+		for _, value := range tx.AllFromFront() {
+			if !yield(value) {
+				return
+			}
+		}
+	}
+}
+
+// Commit applies all the changes to the map. Changed and renamed elements keep
+// their position, and new keys are added to the back in the order they were
+// first set.
+func (tx *Tx[K, V]) Commit() error {
+	if tx.done {
+		return ErrTxDone
+	}
+	tx.done = true
+
+	m := tx.m
+	m.lazyInit()
+
+	// Deleted elements are removed first, so that their keys can be reused by
+	// renamed elements. Elements can also swap keys, so they are all renamed
+	// at once.
+	var from, to []K
+	for original, s := range tx.slots {
+		switch {
+		case s.deleted:
+			m.Delete(original)
+		case s.key != original:
+			from = append(from, original)
+			to = append(to, s.key)
+		}
+	}
+	if len(from) > 0 {
+		m.renameKeys(from, to)
+	}
+
+	for _, s := range tx.slots {
+		if s.updated && !s.deleted {
+			m.Set(s.key, s.value)
+		}
+	}
+
+	for key, value := range tx.added.AllFromFront() {
+		m.Set(key, value)
+	}
+
+	return nil
+}
+
+// Rollback discards all the changes. The map is left unchanged.
+func (tx *Tx[K, V]) Rollback() error {
+	if tx.done {
+		return ErrTxDone
+	}
+	tx.done = true
+
+	return nil
+}
@@ -0,0 +1,142 @@
+package orderedmap_test
+
+import (
+	"math/rand"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/elliotchance/orderedmap/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTx(t *testing.T) {
+	newMap := func() *orderedmap.OrderedMap[string, int] {
+		m := orderedmap.NewOrderedMap[string, int]()
+		m.Set("a", 1)
+		m.Set("b", 2)
+		m.Set("c", 3)
+		return m
+	}
+
+	t.Run("ReadsOwnWrites", func(t *testing.T) {
+		m := newMap()
+		tx := m.Begin()
+		assert.False(t, tx.Set("b", 20))
+		assert.True(t, tx.Set("d", 4))
+		assert.True(t, tx.Delete("a"))
+		assert.False(t, tx.Delete("a"))
+
+		assert.Equal(t, 20, tx.GetOrDefault("b", 0))
+		assert.Equal(t, 4, tx.GetOrDefault("d", 0))
+		assert.False(t, tx.Has("a"))
+		assert.Equal(t, 3, tx.Len())
+		assert.Equal(t, []string{"b", "c", "d"}, slices.Collect(tx.Keys()))
+		assert.Equal(t, []int{20, 3, 4}, slices.Collect(tx.Values()))
+
+		var back []string
+		for key := range tx.AllFromBack() {
+			back = append(back, key)
+		}
+		assert.Equal(t, []string{"d", "c", "b"}, back)
+
+		// The map is not changed until Commit.
+		assert.Equal(t, []string{"a", "b", "c"}, slices.Collect(m.Keys()))
+		assert.Equal(t, []int{1, 2, 3}, slices.Collect(m.Values()))
+	})
+
+	t.Run("Commit", func(t *testing.T) {
+		m := newMap()
+		tx := m.Begin()
+		tx.Set("b", 20)
+		tx.Set("d", 4)
+		tx.Delete("a")
+		tx.Set("a", 10)
+		require.NoError(t, tx.Commit())
+
+		assert.Equal(t, []string{"b", "c", "d", "a"}, slices.Collect(m.Keys()))
+		assert.Equal(t, []int{20, 3, 4, 10}, slices.Collect(m.Values()))
+		assert.ErrorIs(t, tx.Commit(), orderedmap.ErrTxDone)
+		assert.ErrorIs(t, tx.Rollback(), orderedmap.ErrTxDone)
+	})
+
+	t.Run("Rollback", func(t *testing.T) {
+		m := newMap()
+		tx := m.Begin()
+		tx.Set("b", 20)
+		tx.Delete("c")
+		tx.ReplaceKey("a", "z")
+		require.NoError(t, tx.Rollback())
+
+		assert.Equal(t, []string{"a", "b", "c"}, slices.Collect(m.Keys()))
+		assert.Equal(t, []int{1, 2, 3}, slices.Collect(m.Values()))
+		assert.ErrorIs(t, tx.Commit(), orderedmap.ErrTxDone)
+	})
+
+	t.Run("ReplaceKey", func(t *testing.T) {
+		m := newMap()
+		tx := m.Begin()
+		assert.False(t, tx.ReplaceKey("a", "b"))
+		assert.False(t, tx.ReplaceKey("x", "y"))
+
+		// Swap the keys of a and c.
+		assert.True(t, tx.ReplaceKey("a", "tmp"))
+		assert.True(t, tx.ReplaceKey("c", "a"))
+		assert.True(t, tx.ReplaceKey("tmp", "c"))
+		assert.False(t, tx.Has("tmp"))
+		assert.Equal(t, 3, tx.GetOrDefault("a", 0))
+		assert.Equal(t, []string{"c", "b", "a"}, slices.Collect(tx.Keys()))
+
+		require.NoError(t, tx.Commit())
+		assert.Equal(t, []string{"c", "b", "a"}, slices.Collect(m.Keys()))
+		assert.Equal(t, []int{1, 2, 3}, slices.Collect(m.Values()))
+		assert.Equal(t, 1, m.GetOrDefault("c", 0))
+	})
+
+	t.Run("ReplaceKeyWithDeletedKey", func(t *testing.T) {
+		m := newMap()
+		tx := m.Begin()
+		tx.Delete("b")
+		assert.True(t, tx.ReplaceKey("a", "b"))
+		require.NoError(t, tx.Commit())
+
+		assert.Equal(t, []string{"b", "c"}, slices.Collect(m.Keys()))
+		assert.Equal(t, []int{1, 3}, slices.Collect(m.Values()))
+	})
+
+	t.Run("MatchesOrderedMap", func(t *testing.T) {
+		r := rand.New(rand.NewSource(1))
+		for i := 0; i < 100; i++ {
+			m := orderedmap.NewOrderedMap[string, int]()
+			for j := 0; j < 10; j++ {
+				m.Set(strconv.Itoa(r.Intn(15)), j)
+			}
+			want := m.Copy()
+
+			tx := m.Begin()
+			for j := 0; j < 20; j++ {
+				key, other := strconv.Itoa(r.Intn(15)), strconv.Itoa(r.Intn(15))
+				switch r.Intn(3) {
+				case 0:
+					require.Equal(t, want.Set(key, j), tx.Set(key, j))
+				case 1:
+					require.Equal(t, want.Delete(key), tx.Delete(key))
+				case 2:
+					require.Equal(t, want.ReplaceKey(key, other), tx.ReplaceKey(key, other))
+				}
+
+				require.Equal(t, slices.Collect(want.Keys()), slices.Collect(tx.Keys()))
+				require.Equal(t, slices.Collect(want.Values()), slices.Collect(tx.Values()))
+				require.Equal(t, want.Len(), tx.Len())
+			}
+
+			require.NoError(t, tx.Commit())
+			require.Equal(t, slices.Collect(want.Keys()), slices.Collect(m.Keys()))
+			require.Equal(t, slices.Collect(want.Values()), slices.Collect(m.Values()))
+			for key, value := range want.AllFromFront() {
+				require.Equal(t, value, m.GetOrDefault(key, -1))
+			}
+		}
+	})
+}
@@ -0,0 +1,466 @@
+package orderedmap
+
+import (
+	"encoding"
+	"encoding/xml"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// maxXMLDepth is the deepest that elements can be nested when decoding values
+// of type any, so that a malicious document cannot overflow the stack.
+const maxXMLDepth = 10000
+
+// XMLOptions controls how an XMLMap encodes and decodes its OrderedMap.
+type XMLOptions struct {
+	// Name is the name of the wrapper element. If it is empty, the name chosen
+	// by encoding/xml is used (such as the name of the struct field), or "map"
+	// if encoding/xml would have used the name of the OrderedMap type.
+	Name string
+
+	// Attributes lists the keys that are written as attributes of the wrapper
+	// element rather than as child elements.
+	Attributes []string
+
+	// AttributePrefix, if not empty, causes any key that starts with it to be
+	// written as an attribute (without the prefix). When decoding, attributes
+	// that are not in Attributes are given keys with this prefix, so that an
+	// attribute and a child element with the same name do not collide.
+	AttributePrefix string
+
+	// TextKey is the key for the text inside an element that also has
+	// attributes or child elements, so that the text is not lost. If it is
+	// empty, "#text" is used. When encoding, the value for this key is written
+	// as text rather than as a child element.
+	TextKey string
+}
+
+func (opts XMLOptions) textKey() string {
+	if opts.TextKey == "" {
+		return "#text"
+	}
+
+	return opts.TextKey
+}
+
+// XMLMap encodes and decodes an OrderedMap with XMLOptions. It implements
+// xml.Marshaler and xml.Unmarshaler, so it can be passed to xml.Marshal and
+// xml.Unmarshal or used as a struct field in place of the OrderedMap:
+//
+//	data, err := xml.Marshal(orderedmap.WithXMLOptions(m, orderedmap.XMLOptions{Name: "record"}))
+type XMLMap[K comparable, V any] struct {
+	// Map is the map that is encoded, or decoded into. If it is nil when
+	// decoding, a new map is created.
+	Map *OrderedMap[K, V]
+
+	Options XMLOptions
+}
+
+// WithXMLOptions returns an XMLMap that encodes and decodes m with opts.
+// Ordered maps created while decoding values of type any use the same options.
+func WithXMLOptions[K comparable, V any](m *OrderedMap[K, V], opts XMLOptions) *XMLMap[K, V] {
+	return &XMLMap[K, V]{Map: m, Options: opts}
+}
+
+// MarshalXML implements xml.Marshaler. See OrderedMap.MarshalXML.
+func (x *XMLMap[K, V]) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return x.Map.encodeXML(e, xmlStart[XMLMap[K, V]](start, x.Options), x.Options)
+}
+
+// UnmarshalXML implements xml.Unmarshaler. See OrderedMap.UnmarshalXML.
+func (x *XMLMap[K, V]) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	if x.Map == nil {
+		x.Map = NewOrderedMap[K, V]()
+	}
+
+	_, err := x.Map.decodeXML(d, start, x.Options, 0)
+	return err
+}
+
+// xmlStart returns start with the name given by opts, or "map" if encoding/xml
+// would have used the name of the type T.
+func xmlStart[T any](start xml.StartElement, opts XMLOptions) xml.StartElement {
+	if opts.Name != "" {
+		start.Name = xml.Name{Local: opts.Name}
+	} else if start.Name.Space == "" && start.Name.Local == reflect.TypeFor[T]().Name() {
+		start.Name = xml.Name{Local: "map"}
+	}
+
+	return start
+}
+
+// xmlAttribute returns the attribute name for key, or false if the key is
+// written as a child element.
+func (opts XMLOptions) xmlAttribute(key string) (string, bool) {
+	if slices.Contains(opts.Attributes, key) {
+		return key, true
+	}
+	if opts.AttributePrefix != "" && strings.HasPrefix(key, opts.AttributePrefix) {
+		return strings.TrimPrefix(key, opts.AttributePrefix), true
+	}
+
+	return "", false
+}
+
+// MarshalXML implements xml.Marshaler. Each element is written as a child
+// element named by its key, in the same order as the map (front to back).
+// Values are encoded with the rules of encoding/xml, so a slice is written as a
+// repeated element and a nil value is omitted. Keys must be strings (or
+// implement encoding.TextMarshaler) and must be valid XML names.
+//
+// The value for the key "#text" is written as text instead, in its place among
+// the child elements. Use WithXMLOptions to choose the name of the wrapper
+// element or the text key, or to write some keys as attributes. The values of
+// attributes and text must be a string, bool or number, or implement
+// xml.MarshalerAttr or encoding.TextMarshaler.
+func (m *OrderedMap[K, V]) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return m.encodeXML(e, xmlStart[OrderedMap[K, V]](start, XMLOptions{}), XMLOptions{})
+}
+
+func (m *OrderedMap[K, V]) encodeXML(e *xml.Encoder, start xml.StartElement, opts XMLOptions) error {
+	if m == nil || m.kv == nil {
+		return e.EncodeElement("", start)
+	}
+
+	var children []*Element[K, V]
+	for el := m.Front(); el != nil; el = el.Next() {
+		key, err := xmlKey(el.Key)
+		if err != nil {
+			return err
+		}
+
+		name, ok := opts.xmlAttribute(key)
+		if !ok {
+			children = append(children, el)
+			continue
+		}
+
+		attr, err := encodeXMLAttr(xml.Name{Local: name}, el.Value)
+		if err != nil {
+			return fmt.Errorf("orderedmap: attribute %s: %w", name, err)
+		}
+		if attr.Name.Local != "" {
+			start.Attr = append(start.Attr, attr)
+		}
+	}
+
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+
+	for _, el := range children {
+		key, _ := xmlKey(el.Key)
+		if key == opts.textKey() {
+			if err := encodeXMLText(e, el.Value); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := e.EncodeElement(el.Value, xml.StartElement{Name: xml.Name{Local: key}}); err != nil {
+			return err
+		}
+	}
+
+	return e.EncodeToken(start.End())
+}
+
+// UnmarshalXML implements xml.Unmarshaler. Each child element is added with its
+// name as the key, in the order they appear. Existing elements are kept.
+//
+// Attributes of the element are also added (before the child elements), with
+// their names as keys (see WithXMLOptions to change this). If the same element
+// name appears more than once, the values are decoded into the same value, so a
+// slice collects all of them. If V is the empty interface, an element that only
+// contains text is decoded as a string, an element with child elements or
+// attributes is decoded as an *OrderedMap[string, any], and a repeated element
+// is decoded as a []any.
+//
+// If the map is not empty, any text directly inside the element (that is not
+// only whitespace) is added last with the key "#text", which can be changed
+// with WithXMLOptions.
+func (m *OrderedMap[K, V]) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	_, err := m.decodeXML(d, start, XMLOptions{}, 0)
+	return err
+}
+
+// decodeXML decodes the attributes and child elements of start, returning the
+// text that was found directly inside it. depth is the number of elements that
+// start is nested inside of.
+func (m *OrderedMap[K, V]) decodeXML(d *xml.Decoder, start xml.StartElement, opts XMLOptions, depth int) (string, error) {
+	if depth >= maxXMLDepth {
+		return "", fmt.Errorf("orderedmap: exceeded max depth of %d", maxXMLDepth)
+	}
+	m.lazyInit()
+	isAny := reflect.TypeFor[V]().Kind() == reflect.Interface &&
+		reflect.TypeFor[V]().NumMethod() == 0
+
+	for _, attr := range start.Attr {
+		if attr.Name.Space == "xmlns" || attr.Name.Local == "xmlns" {
+			continue
+		}
+
+		name := attr.Name.Local
+		if !slices.Contains(opts.Attributes, name) {
+			name = opts.AttributePrefix + name
+		}
+		key, err := decodeXMLKey[K](name)
+		if err != nil {
+			return "", err
+		}
+
+		var value V
+		if err := decodeXMLAttr(attr, reflect.ValueOf(&value).Elem()); err != nil {
+			return "", fmt.Errorf("orderedmap: attribute %s: %w", attr.Name.Local, err)
+		}
+		m.Set(key, value)
+	}
+
+	// seen records the elements decoded by this call, so that a repeated
+	// element is decoded into the same value rather than replacing it.
+	seen := map[K]bool{}
+	var text strings.Builder
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return "", err
+		}
+
+		switch tok := tok.(type) {
+		case xml.CharData:
+			text.Write(tok)
+
+		case xml.EndElement:
+			if m.Len() > 0 && strings.TrimSpace(text.String()) != "" {
+				if err := m.setXMLText(text.String(), opts); err != nil {
+					return "", err
+				}
+			}
+
+			return text.String(), nil
+
+		case xml.StartElement:
+			key, err := decodeXMLKey[K](tok.Name.Local)
+			if err != nil {
+				return "", err
+			}
+
+			var value V
+			if isAny {
+				v, err := decodeXMLAny(d, tok, opts, depth+1)
+				if err != nil {
+					return "", err
+				}
+				if seen[key] {
+					v = appendXMLAny(m.kv[key].Value, v)
+				}
+				value = v.(V)
+			} else {
+				if seen[key] {
+					value = m.kv[key].Value
+				}
+				if err := d.DecodeElement(&value, &tok); err != nil {
+					return "", err
+				}
+			}
+
+			m.Set(key, value)
+			seen[key] = true
+		}
+	}
+}
+
+// setXMLText sets the text of the element for the key given by opts.
+func (m *OrderedMap[K, V]) setXMLText(text string, opts XMLOptions) error {
+	key, err := decodeXMLKey[K](opts.textKey())
+	if err != nil {
+		return err
+	}
+
+	var value V
+	if err := decodeXMLAttr(xml.Attr{Value: text}, reflect.ValueOf(&value).Elem()); err != nil {
+		return fmt.Errorf("orderedmap: text: %w", err)
+	}
+	m.Set(key, value)
+
+	return nil
+}
+
+// encodeXMLText writes value as the text of the current element. A nil value
+// is omitted.
+func encodeXMLText(e *xml.Encoder, value any) error {
+	attr, err := encodeXMLAttr(xml.Name{Local: "text"}, value)
+	if err != nil {
+		return fmt.Errorf("orderedmap: text: %w", err)
+	}
+	if attr.Name.Local == "" {
+		return nil
+	}
+
+	return e.EncodeToken(xml.CharData(attr.Value))
+}
+
+// decodeXMLAny decodes an element for a value of type any.
+func decodeXMLAny(d *xml.Decoder, start xml.StartElement, opts XMLOptions, depth int) (any, error) {
+	m := NewOrderedMap[string, any]()
+	text, err := m.decodeXML(d, start, opts, depth)
+	if err != nil {
+		return nil, err
+	}
+	if m.Len() == 0 {
+		return text, nil
+	}
+
+	return m, nil
+}
+
+// appendXMLAny combines the values of a repeated element into a []any. Only
+// the second and later occurrences are passed here, and decodeXMLAny never
+// returns a slice, so existing is only a []any if it was created here.
+func appendXMLAny(existing, value any) any {
+	if values, ok := existing.([]any); ok {
+		return append(values, value)
+	}
+
+	return []any{existing, value}
+}
+
+func xmlKey(key any) (string, error) {
+	v := reflect.ValueOf(key)
+	if v.Kind() == reflect.String {
+		return v.String(), nil
+	}
+
+	if tm, ok := key.(encoding.TextMarshaler); ok {
+		text, err := tm.MarshalText()
+		return string(text), err
+	}
+
+	return "", fmt.Errorf("orderedmap: cannot use key of type %T as an XML name", key)
+}
+
+func decodeXMLKey[K comparable](s string) (key K, err error) {
+	v := reflect.ValueOf(&key).Elem()
+	if tu, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		err = tu.UnmarshalText([]byte(s))
+		return
+	}
+
+	if v.Kind() != reflect.String {
+		return key, fmt.Errorf("orderedmap: cannot use XML name as key of type %s", v.Type())
+	}
+	v.SetString(s)
+
+	return
+}
+
+// encodeXMLAttr returns the attribute for value, or an attribute with an empty
+// name if it should be omitted.
+func encodeXMLAttr(name xml.Name, value any) (xml.Attr, error) {
+	if ma, ok := value.(xml.MarshalerAttr); ok {
+		return ma.MarshalXMLAttr(name)
+	}
+
+	if tm, ok := value.(encoding.TextMarshaler); ok {
+		text, err := tm.MarshalText()
+		return xml.Attr{Name: name, Value: string(text)}, err
+	}
+
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return xml.Attr{}, nil
+		}
+		v = v.Elem()
+	}
+
+	var s string
+	switch v.Kind() {
+	case reflect.Invalid:
+		return xml.Attr{}, nil
+
+	case reflect.String:
+		s = v.String()
+
+	case reflect.Bool:
+		s = strconv.FormatBool(v.Bool())
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		s = strconv.FormatInt(v.Int(), 10)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64, reflect.Uintptr:
+		s = strconv.FormatUint(v.Uint(), 10)
+
+	case reflect.Float32, reflect.Float64:
+		s = strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits())
+
+	default:
+		return xml.Attr{}, fmt.Errorf("cannot encode value of type %s", v.Type())
+	}
+
+	return xml.Attr{Name: name, Value: s}, nil
+}
+
+// decodeXMLAttr decodes the value of attr into v, which is a string for values
+// of type any.
+func decodeXMLAttr(attr xml.Attr, v reflect.Value) error {
+	if v.Kind() == reflect.Interface && v.NumMethod() == 0 {
+		v.Set(reflect.ValueOf(attr.Value))
+		return nil
+	}
+
+	if v.Kind() == reflect.Pointer {
+		v.Set(reflect.New(v.Type().Elem()))
+		return decodeXMLAttr(attr, v.Elem())
+	}
+
+	switch u := v.Addr().Interface().(type) {
+	case xml.UnmarshalerAttr:
+		return u.UnmarshalXMLAttr(attr)
+	case encoding.TextUnmarshaler:
+		return u.UnmarshalText([]byte(attr.Value))
+	}
+
+	s := strings.TrimSpace(attr.Value)
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(attr.Value)
+
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+
+	default:
+		return fmt.Errorf("cannot decode into value of type %s", v.Type())
+	}
+
+	return nil
+}
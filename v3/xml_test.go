@@ -0,0 +1,235 @@
+package orderedmap_test
+
+import (
+	"encoding/xml"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/elliotchance/orderedmap/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderedMap_MarshalXML(t *testing.T) {
+	t.Run("EmptyMap", func(t *testing.T) {
+		data, err := xml.Marshal(orderedmap.NewOrderedMap[string, int]())
+		require.NoError(t, err)
+		assert.Equal(t, `<map></map>`, string(data))
+	})
+
+	t.Run("RetainsOrder", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, any]()
+		m.Set("z", 1)
+		m.Set("a", "two & three")
+		m.Set("m", []int{4, 5})
+		m.Set("n", nil)
+		data, err := xml.Marshal(m)
+		require.NoError(t, err)
+		assert.Equal(t, `<map><z>1</z><a>two &amp; three</a><m>4</m><m>5</m></map>`, string(data))
+	})
+
+	t.Run("Name", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, int]()
+		m.Set("id", 7)
+		data, err := xml.Marshal(orderedmap.WithXMLOptions(m, orderedmap.XMLOptions{Name: "record"}))
+		require.NoError(t, err)
+		assert.Equal(t, `<record><id>7</id></record>`, string(data))
+	})
+
+	t.Run("StructField", func(t *testing.T) {
+		type envelope struct {
+			XMLName xml.Name `xml:"Envelope"`
+			Body    *orderedmap.OrderedMap[string, string]
+		}
+		body := orderedmap.NewOrderedMap[string, string]()
+		body.Set("Second", "b")
+		body.Set("First", "a")
+		data, err := xml.Marshal(envelope{Body: body})
+		require.NoError(t, err)
+		assert.Equal(t, `<Envelope><Body><Second>b</Second><First>a</First></Body></Envelope>`, string(data))
+	})
+
+	t.Run("Attributes", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, any]()
+		m.Set("name", "widget")
+		m.Set("id", 12)
+		m.Set("@version", 1.5)
+		m.Set("@missing", nil)
+		data, err := xml.Marshal(orderedmap.WithXMLOptions(m, orderedmap.XMLOptions{
+			Name:            "item",
+			Attributes:      []string{"id"},
+			AttributePrefix: "@",
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, `<item id="12" version="1.5"><name>widget</name></item>`, string(data))
+	})
+
+	t.Run("InvalidAttribute", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, any]()
+		m.Set("a", []int{1})
+		_, err := xml.Marshal(orderedmap.WithXMLOptions(m, orderedmap.XMLOptions{Attributes: []string{"a"}}))
+		assert.ErrorContains(t, err, "attribute a")
+	})
+
+	t.Run("OptionsStructField", func(t *testing.T) {
+		type envelope struct {
+			XMLName xml.Name `xml:"Envelope"`
+			Body    *orderedmap.XMLMap[string, string]
+		}
+		body := orderedmap.NewOrderedMap[string, string]()
+		body.Set("id", "1")
+		body.Set("name", "a")
+		data, err := xml.Marshal(envelope{Body: orderedmap.WithXMLOptions(body, orderedmap.XMLOptions{
+			Attributes: []string{"id"},
+		})})
+		require.NoError(t, err)
+		assert.Equal(t, `<Envelope><Body id="1"><name>a</name></Body></Envelope>`, string(data))
+
+		data, err = xml.Marshal(orderedmap.WithXMLOptions(body, orderedmap.XMLOptions{}))
+		require.NoError(t, err)
+		assert.Equal(t, `<map><id>1</id><name>a</name></map>`, string(data))
+	})
+
+	t.Run("Nested", func(t *testing.T) {
+		inner := orderedmap.NewOrderedMap[string, int]()
+		inner.Set("y", 1)
+		inner.Set("x", 2)
+		m := orderedmap.NewOrderedMap[string, any]()
+		m.Set("inner", inner)
+		data, err := xml.Marshal(m)
+		require.NoError(t, err)
+		assert.Equal(t, `<map><inner><y>1</y><x>2</x></inner></map>`, string(data))
+	})
+
+	t.Run("NonStringKey", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[int, int]()
+		m.Set(1, 1)
+		_, err := xml.Marshal(m)
+		assert.Error(t, err)
+	})
+}
+
+func TestOrderedMap_UnmarshalXML(t *testing.T) {
+	t.Run("RetainsOrder", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, int]()
+		require.NoError(t, xml.Unmarshal([]byte(`<map><z>1</z><a>2</a><m>3</m></map>`), m))
+		assert.Equal(t, []string{"z", "a", "m"}, slices.Collect(m.Keys()))
+		assert.Equal(t, []int{1, 2, 3}, slices.Collect(m.Values()))
+	})
+
+	t.Run("ZeroValue", func(t *testing.T) {
+		var m orderedmap.OrderedMap[string, string]
+		require.NoError(t, xml.Unmarshal([]byte(`<map><b>x</b><a>y</a></map>`), &m))
+		assert.Equal(t, []string{"b", "a"}, slices.Collect(m.Keys()))
+	})
+
+	t.Run("Any", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, any]()
+		opts := orderedmap.XMLOptions{AttributePrefix: "@"}
+		require.NoError(t, xml.Unmarshal([]byte(`
+			<order id="5">
+				<customer>Bob</customer>
+				<line sku="a"><qty>1</qty></line>
+				<note/>
+				<line sku="b"><qty>2</qty></line>
+			</order>`), orderedmap.WithXMLOptions(m, opts)))
+		assert.Equal(t, []string{"@id", "customer", "line", "note"}, slices.Collect(m.Keys()))
+		assert.Equal(t, "5", m.GetOrDefault("@id", nil))
+		assert.Equal(t, "Bob", m.GetOrDefault("customer", nil))
+		assert.Equal(t, "", m.GetOrDefault("note", nil))
+
+		lines := m.GetOrDefault("line", nil).([]any)
+		require.Len(t, lines, 2)
+		line := lines[1].(*orderedmap.OrderedMap[string, any])
+		assert.Equal(t, []string{"@sku", "qty"}, slices.Collect(line.Keys()))
+		assert.Equal(t, "2", line.GetOrDefault("qty", nil))
+	})
+
+	t.Run("RepeatedSlice", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, []int]()
+		require.NoError(t, xml.Unmarshal([]byte(`<map><n>1</n><s>0</s><n>2</n></map>`), m))
+		assert.Equal(t, []string{"n", "s"}, slices.Collect(m.Keys()))
+		assert.Equal(t, []int{1, 2}, m.GetOrDefault("n", nil))
+	})
+
+	t.Run("TypedAttributes", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, int]()
+		x := orderedmap.WithXMLOptions(m, orderedmap.XMLOptions{Attributes: []string{"id"}})
+		require.NoError(t, xml.Unmarshal([]byte(`<map xmlns="urn:x" id="3"><n>4</n></map>`), x))
+		assert.Equal(t, []string{"id", "n"}, slices.Collect(m.Keys()))
+		assert.Equal(t, 3, m.GetOrDefault("id", 0))
+
+		err := xml.Unmarshal([]byte(`<map id="x"></map>`), x)
+		assert.ErrorContains(t, err, "attribute id")
+	})
+
+	t.Run("TextWithAttributes", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, any]()
+		require.NoError(t, xml.Unmarshal([]byte(`
+			<order>
+				<price currency="USD">10</price>
+				<note>plain</note>
+				<item>widget<size>2</size></item>
+			</order>`), m))
+
+		price := m.GetOrDefault("price", nil).(*orderedmap.OrderedMap[string, any])
+		assert.Equal(t, []string{"currency", "#text"}, slices.Collect(price.Keys()))
+		assert.Equal(t, "10", price.GetOrDefault("#text", nil))
+		assert.Equal(t, "plain", m.GetOrDefault("note", nil))
+		item := m.GetOrDefault("item", nil).(*orderedmap.OrderedMap[string, any])
+		assert.Equal(t, []string{"size", "#text"}, slices.Collect(item.Keys()))
+
+		data, err := xml.Marshal(price)
+		require.NoError(t, err)
+		assert.Equal(t, `<map><currency>USD</currency>10</map>`, string(data))
+
+		typed := orderedmap.NewOrderedMap[string, int]()
+		x := orderedmap.WithXMLOptions(typed, orderedmap.XMLOptions{TextKey: "value"})
+		require.NoError(t, xml.Unmarshal([]byte(`<price cents="50">10</price>`), x))
+		assert.Equal(t, []string{"cents", "value"}, slices.Collect(typed.Keys()))
+		assert.Equal(t, 10, typed.GetOrDefault("value", 0))
+
+		data, err = xml.Marshal(orderedmap.WithXMLOptions(typed, orderedmap.XMLOptions{
+			Name:       "price",
+			Attributes: []string{"cents"},
+			TextKey:    "value",
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, `<price cents="50">10</price>`, string(data))
+	})
+
+	t.Run("MaxDepth", func(t *testing.T) {
+		nested := func(depth int) []byte {
+			return []byte("<map>" + strings.Repeat("<a>", depth) + strings.Repeat("</a>", depth) + "</map>")
+		}
+
+		m := orderedmap.NewOrderedMap[string, any]()
+		require.NoError(t, xml.Unmarshal(nested(100), m))
+		assert.ErrorContains(t, xml.Unmarshal(nested(3_000_000), m), "exceeded max depth")
+	})
+
+	t.Run("StructField", func(t *testing.T) {
+		type envelope struct {
+			Body *orderedmap.OrderedMap[string, string]
+		}
+		var v envelope
+		require.NoError(t, xml.Unmarshal([]byte(`<Envelope><Body><b>2</b><a>1</a></Body></Envelope>`), &v))
+		assert.Equal(t, []string{"b", "a"}, slices.Collect(v.Body.Keys()))
+	})
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, string]()
+		m.Set("-kind", "k")
+		m.Set("z", "1")
+		m.Set("a", "2")
+		data, err := xml.Marshal(orderedmap.WithXMLOptions(m, orderedmap.XMLOptions{Name: "r", AttributePrefix: "-"}))
+		require.NoError(t, err)
+
+		// A nil Map is created when decoding.
+		x := &orderedmap.XMLMap[string, string]{Options: orderedmap.XMLOptions{AttributePrefix: "-"}}
+		require.NoError(t, xml.Unmarshal(data, x))
+		assert.Equal(t, slices.Collect(m.Keys()), slices.Collect(x.Map.Keys()))
+		assert.Equal(t, slices.Collect(m.Values()), slices.Collect(x.Map.Values()))
+	})
+}
@@ -0,0 +1,540 @@
+package orderedmap
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"gopkg.in/yaml.v3"
+)
+
+// YAMLComment holds the comments attached to a single YAML node. Each comment
+// includes the leading "#".
+type YAMLComment struct {
+	// Head is the comment in the lines preceding the node.
+	Head string
+
+	// Line is the comment at the end of the line where the node is.
+	Line string
+
+	// Foot is the comment in the lines following the node.
+	Foot string
+}
+
+// YAMLComments holds the comments attached to the key and value of an element.
+type YAMLComments struct {
+	Key   YAMLComment
+	Value YAMLComment
+}
+
+// yamlState is only allocated for maps that have comments so that maps which
+// are never used with YAML do not pay for it.
+type yamlState[K comparable, V any] struct {
+	keep     bool
+	mapping  YAMLComment
+	elements map[*Element[K, V]]yamlElement
+}
+
+// yamlElement is what is kept for each element. Along with the comments, the
+// style of the nodes is kept so that a flow sequence is not turned into a block
+// sequence (which would lose its line comment), or a quoted string unquoted.
+type yamlElement struct {
+	comments   YAMLComments
+	key, value yamlStyle
+}
+
+type yamlStyle struct {
+	kind  yaml.Kind
+	tag   string
+	style yaml.Style
+}
+
+// KeepYAMLComments controls whether UnmarshalYAML records the comments attached
+// to each element, so that they are written back out by MarshalYAML. This also
+// applies to any ordered maps created while decoding the values.
+//
+// Comments recorded for the map are not affected by this setting, and can
+// always be inspected and changed with YAMLComments and SetYAMLComments.
+func (m *OrderedMap[K, V]) KeepYAMLComments(keep bool) {
+	m.yamlState().keep = keep
+}
+
+// YAMLComments returns the comments attached to the element for key. If the
+// key does not exist or has no comments, the returned value is empty.
+func (m *OrderedMap[K, V]) YAMLComments(key K) YAMLComments {
+	if m.yaml == nil {
+		return YAMLComments{}
+	}
+
+	return m.yaml.elements[m.kv[key]].comments
+}
+
+// SetYAMLComments replaces the comments attached to the element for key. It
+// returns false if the key does not exist.
+func (m *OrderedMap[K, V]) SetYAMLComments(key K, comments YAMLComments) bool {
+	element, ok := m.kv[key]
+	if !ok {
+		return false
+	}
+
+	state := m.yamlState()
+	yel := state.elements[element]
+	yel.comments = comments
+	state.elements[element] = yel
+
+	return true
+}
+
+func (m *OrderedMap[K, V]) yamlState() *yamlState[K, V] {
+	if m.yaml == nil {
+		m.yaml = &yamlState[K, V]{
+			elements: make(map[*Element[K, V]]yamlElement),
+		}
+	}
+
+	return m.yaml
+}
+
+// MarshalYAML implements yaml.Marshaler. The map is encoded as a YAML mapping
+// with the keys in the same order as the map (front to back), including any
+// comments attached to the elements.
+func (m *OrderedMap[K, V]) MarshalYAML() (any, error) {
+	if m == nil {
+		return nil, nil
+	}
+
+	node := &yaml.Node{
+		Kind: yaml.MappingNode,
+		Tag:  "!!map",
+	}
+	if m.yaml != nil {
+		m.yaml.mapping.apply(node)
+	}
+
+	for el := m.Front(); el != nil; el = el.Next() {
+		keyNode, err := encodeYAMLNode(el.Key)
+		if err != nil {
+			return nil, err
+		}
+
+		valueNode, err := encodeYAMLNode(el.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		if m.yaml != nil {
+			if yel, ok := m.yaml.elements[el]; ok {
+				yel.comments.Key.apply(keyNode)
+				yel.comments.Value.apply(valueNode)
+				yel.key.apply(keyNode)
+				yel.value.apply(valueNode)
+			}
+		}
+
+		node.Content = append(node.Content, keyNode, valueNode)
+	}
+
+	return node, nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler. The keys of the YAML mapping are
+// added to the map in the order they appear. Like yaml.v3 does for plain maps,
+// existing elements are kept, a null leaves the map unchanged, merge keys
+// ("<<") are supported and a key that appears more than once is an error
+// wrapping ErrDuplicateKey.
+//
+// If V is the empty interface, nested mappings are decoded into
+// *OrderedMap[string, any] (or *OrderedMap[any, any] if not all the keys are
+// strings) so that their order is also preserved.
+func (m *OrderedMap[K, V]) UnmarshalYAML(value *yaml.Node) error {
+	d := &yamlDecoder{stringKeys: make(map[*yaml.Node]bool)}
+	value, leave, err := d.enter(value)
+	if err != nil {
+		return err
+	}
+	defer leave()
+
+	return m.unmarshalYAML(value, d)
+}
+
+// unmarshalYAML decodes value, which has already been passed to d.enter.
+func (m *OrderedMap[K, V]) unmarshalYAML(value *yaml.Node, d *yamlDecoder) error {
+	if value.Kind == yaml.ScalarNode && value.ShortTag() == "!!null" {
+		return nil
+	}
+	if value.Kind != yaml.MappingNode {
+		return &yaml.TypeError{Errors: []string{fmt.Sprintf(
+			"line %d: cannot unmarshal %s into %T",
+			value.Line, value.ShortTag(), m)}}
+	}
+
+	m.lazyInit()
+	keep := m.yaml != nil && m.yaml.keep
+	if keep {
+		m.yaml.mapping = yamlCommentOf(value)
+	}
+
+	// Explicit keys always take precedence over merged keys, regardless of
+	// where they appear.
+	explicit := make(map[K]int)
+	for i := 0; i+1 < len(value.Content); i += 2 {
+		keyNode := value.Content[i]
+		if keyNode.ShortTag() == "!!merge" {
+			continue
+		}
+
+		var key K
+		if err := keyNode.Decode(&key); err != nil {
+			return err
+		}
+		if line, ok := explicit[key]; ok {
+			return fmt.Errorf("%w: line %d: mapping key %q already defined at line %d",
+				ErrDuplicateKey, keyNode.Line, keyNode.Value, line)
+		}
+		explicit[key] = keyNode.Line
+	}
+
+	merged := make(map[K]struct{})
+	for i := 0; i+1 < len(value.Content); i += 2 {
+		keyNode, valueNode := value.Content[i], value.Content[i+1]
+		if keyNode.ShortTag() == "!!merge" {
+			if err := m.mergeYAML(valueNode, explicit, merged, d); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var key K
+		if err := keyNode.Decode(&key); err != nil {
+			return err
+		}
+
+		v, err := m.decodeYAMLValue(valueNode, d)
+		if err != nil {
+			return err
+		}
+
+		m.Set(key, v)
+		if keep {
+			m.yaml.elements[m.kv[key]] = yamlElement{
+				comments: YAMLComments{
+					Key:   yamlCommentOf(keyNode),
+					Value: yamlCommentOf(valueNode),
+				},
+				key:   yamlStyleOf(keyNode),
+				value: yamlStyleOf(valueNode),
+			}
+		}
+	}
+
+	return nil
+}
+
+// mergeYAML sets the elements of the mapping (or sequence of mappings) in
+// value, skipping keys that are defined explicitly or have already been merged
+// from an earlier mapping.
+func (m *OrderedMap[K, V]) mergeYAML(value *yaml.Node, explicit map[K]int, merged map[K]struct{}, d *yamlDecoder) error {
+	value, leave, err := d.enter(value)
+	if err != nil {
+		return err
+	}
+	defer leave()
+
+	mappings := []*yaml.Node{value}
+	if value.Kind == yaml.SequenceNode {
+		mappings = value.Content
+	}
+
+	for _, mapping := range mappings {
+		if err := m.mergeYAMLMapping(mapping, explicit, merged, d); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// mergeYAMLMapping merges a single mapping, see mergeYAML.
+func (m *OrderedMap[K, V]) mergeYAMLMapping(mapping *yaml.Node, explicit map[K]int, merged map[K]struct{}, d *yamlDecoder) error {
+	mapping, leave, err := d.enter(mapping)
+	if err != nil {
+		return err
+	}
+	defer leave()
+
+	if mapping.Kind != yaml.MappingNode {
+		return &yaml.TypeError{Errors: []string{fmt.Sprintf(
+			"line %d: map merge requires map or sequence of maps as the value",
+			mapping.Line)}}
+	}
+
+	other := &OrderedMap[K, V]{}
+	if m.yaml != nil && m.yaml.keep {
+		other.KeepYAMLComments(true)
+	}
+	if err := other.unmarshalYAML(mapping, d); err != nil {
+		return err
+	}
+
+	for el := other.Front(); el != nil; el = el.Next() {
+		if _, ok := explicit[el.Key]; ok {
+			continue
+		}
+		if _, ok := merged[el.Key]; ok {
+			continue
+		}
+
+		merged[el.Key] = struct{}{}
+		m.Set(el.Key, el.Value)
+		if other.yaml != nil {
+			if yel, ok := other.yaml.elements[el]; ok {
+				m.yaml.elements[m.kv[el.Key]] = yel
+			}
+		}
+	}
+
+	return nil
+}
+
+func (m *OrderedMap[K, V]) decodeYAMLValue(node *yaml.Node, d *yamlDecoder) (value V, err error) {
+	keep := m.yaml != nil && m.yaml.keep
+
+	if v, ok := any(&value).(*any); ok {
+		*v, err = decodeYAMLAny(node, keep, d)
+		return
+	}
+
+	// Other values are decoded by yaml.v3, which limits aliases itself.
+	node, leave, err := d.enter(node)
+	if err != nil {
+		return
+	}
+	defer leave()
+
+	// Nested ordered maps need to be created up front so that they also keep
+	// their comments.
+	if keep {
+		rv := reflect.ValueOf(&value).Elem()
+		if rv.Kind() == reflect.Pointer {
+			if keeper, ok := reflect.New(rv.Type().Elem()).Interface().(yamlCommentKeeper); ok {
+				keeper.KeepYAMLComments(true)
+				rv.Set(reflect.ValueOf(keeper))
+			}
+		}
+	}
+
+	err = node.Decode(&value)
+
+	return
+}
+
+// yamlCommentKeeper is implemented by all *OrderedMap types.
+type yamlCommentKeeper interface {
+	KeepYAMLComments(keep bool)
+}
+
+// decodeYAMLAny decodes a node in the same way yaml.v3 would decode it into an
+// interface value, except that mappings become ordered maps.
+func decodeYAMLAny(node *yaml.Node, keep bool, d *yamlDecoder) (any, error) {
+	node, leave, err := d.enter(node)
+	if err != nil {
+		return nil, err
+	}
+	defer leave()
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil, nil
+		}
+
+		return decodeYAMLAny(node.Content[0], keep, d)
+
+	case yaml.MappingNode:
+		var m interface {
+			unmarshalYAML(value *yaml.Node, d *yamlDecoder) error
+			yamlCommentKeeper
+		}
+		if d.yamlStringKeys(node) {
+			m = NewOrderedMap[string, any]()
+		} else {
+			m = NewOrderedMap[any, any]()
+		}
+		if keep {
+			m.KeepYAMLComments(true)
+		}
+
+		if err := m.unmarshalYAML(node, d); err != nil {
+			return nil, err
+		}
+
+		return m, nil
+
+	case yaml.SequenceNode:
+		values := make([]any, 0, len(node.Content))
+		for _, child := range node.Content {
+			value, err := decodeYAMLAny(child, keep, d)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+
+		return values, nil
+	}
+
+	var value any
+	err = node.Decode(&value)
+
+	return value, err
+}
+
+// yamlStringKeys returns true if all the keys of the mapping (including merged
+// mappings) are strings. The result is kept for each mapping, so that a mapping
+// that is merged through many aliases is only checked once.
+func (d *yamlDecoder) yamlStringKeys(node *yaml.Node) bool {
+	if stringKeys, ok := d.stringKeys[node]; ok {
+		return stringKeys
+	}
+
+	stringKeys := true
+	for i := 0; stringKeys && i+1 < len(node.Content); i += 2 {
+		keyNode, valueNode := node.Content[i], resolveYAMLAlias(node.Content[i+1])
+		switch {
+		case keyNode.ShortTag() == "!!merge":
+			if valueNode.Kind == yaml.MappingNode && !d.yamlStringKeys(valueNode) {
+				stringKeys = false
+			}
+			for _, child := range valueNode.Content {
+				child = resolveYAMLAlias(child)
+				if valueNode.Kind == yaml.SequenceNode &&
+					child.Kind == yaml.MappingNode && !d.yamlStringKeys(child) {
+					stringKeys = false
+				}
+			}
+
+		case keyNode.ShortTag() != "!!str":
+			stringKeys = false
+		}
+	}
+	d.stringKeys[node] = stringKeys
+
+	return stringKeys
+}
+
+// encodeYAMLNode returns the node for a key or value. Values that already
+// produce a node (such as nested ordered maps) are used directly so that their
+// comments are not lost.
+func encodeYAMLNode(v any) (*yaml.Node, error) {
+	if marshaler, ok := v.(yaml.Marshaler); ok {
+		if rv := reflect.ValueOf(v); rv.Kind() != reflect.Pointer || !rv.IsNil() {
+			result, err := marshaler.MarshalYAML()
+			if err != nil {
+				return nil, err
+			}
+			if node, ok := result.(*yaml.Node); ok {
+				return node, nil
+			}
+			v = result
+		}
+	}
+
+	node := &yaml.Node{}
+	if err := node.Encode(v); err != nil {
+		return nil, err
+	}
+
+	return node, nil
+}
+
+// errYAMLExcessiveAliasing is the same error that yaml.v3 returns.
+var errYAMLExcessiveAliasing = errors.New("yaml: document contains excessive aliasing")
+
+// yamlDecoder counts the nodes that are decoded by UnmarshalYAML, including
+// nested ordered maps. Each alias is decoded again every time it is used, so
+// like yaml.v3 the decoding fails when most of the nodes come from aliases.
+// Otherwise a small document that nests aliases (a "billion laughs" attack)
+// would take an exponential amount of time and memory to decode.
+type yamlDecoder struct {
+	decodeCount int
+	aliasCount  int
+	aliasDepth  int
+
+	// stringKeys is the result of yamlStringKeys for each mapping.
+	stringKeys map[*yaml.Node]bool
+}
+
+// enter is called for each node that is decoded. It returns the node with any
+// alias resolved, and a function to call once the node has been decoded.
+func (d *yamlDecoder) enter(node *yaml.Node) (_ *yaml.Node, leave func(), _ error) {
+	d.decodeCount++
+	if d.aliasDepth > 0 {
+		d.aliasCount++
+	}
+	if d.aliasCount > 100 && d.decodeCount > 1000 &&
+		float64(d.aliasCount)/float64(d.decodeCount) > allowedYAMLAliasRatio(d.decodeCount) {
+		return nil, nil, errYAMLExcessiveAliasing
+	}
+
+	if node.Kind != yaml.AliasNode {
+		return node, func() {}, nil
+	}
+
+	d.aliasDepth++
+	return resolveYAMLAlias(node), func() { d.aliasDepth-- }, nil
+}
+
+// allowedYAMLAliasRatio is the highest ratio of aliased nodes to all nodes that
+// is allowed, with the same limits as yaml.v3. Small documents can use aliases
+// freely, but the ratio falls to 10% for documents of four million nodes.
+func allowedYAMLAliasRatio(decodeCount int) float64 {
+	const low, high = 400000, 4000000
+	switch {
+	case decodeCount <= low:
+		return 0.99
+	case decodeCount >= high:
+		return 0.10
+	}
+
+	return 0.99 - 0.89*float64(decodeCount-low)/(high-low)
+}
+
+func resolveYAMLAlias(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+
+	return node
+}
+
+func yamlCommentOf(node *yaml.Node) YAMLComment {
+	return YAMLComment{
+		Head: node.HeadComment,
+		Line: node.LineComment,
+		Foot: node.FootComment,
+	}
+}
+
+func (c YAMLComment) apply(node *yaml.Node) {
+	node.HeadComment = c.Head
+	node.LineComment = c.Line
+	node.FootComment = c.Foot
+}
+
+func yamlStyleOf(node *yaml.Node) yamlStyle {
+	node = resolveYAMLAlias(node)
+
+	return yamlStyle{
+		kind:  node.Kind,
+		tag:   node.ShortTag(),
+		style: node.Style,
+	}
+}
+
+// apply only restores the style if the node is still the same kind of value,
+// otherwise a new value could end up with a style that changes its meaning.
+func (s yamlStyle) apply(node *yaml.Node) {
+	if node.Kind == s.kind && node.ShortTag() == s.tag {
+		node.Style = s.style
+	}
+}
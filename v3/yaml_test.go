@@ -0,0 +1,229 @@
+package orderedmap_test
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/elliotchance/orderedmap/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestOrderedMap_MarshalYAML(t *testing.T) {
+	t.Run("RetainsOrder", func(t *testing.T) {
+		inner := orderedmap.NewOrderedMap[string, int]()
+		inner.Set("y", 1)
+		inner.Set("x", 2)
+
+		m := orderedmap.NewOrderedMap[string, any]()
+		m.Set("z", "foo")
+		m.Set("inner", inner)
+		m.Set("list", []int{1, 2})
+
+		data, err := yaml.Marshal(m)
+		require.NoError(t, err)
+		assert.Equal(t, "z: foo\ninner:\n    \"y\": 1\n    x: 2\nlist:\n    - 1\n    - 2\n",
+			string(data))
+	})
+
+	t.Run("EmptyMap", func(t *testing.T) {
+		data, err := yaml.Marshal(orderedmap.NewOrderedMap[string, int]())
+		require.NoError(t, err)
+		assert.Equal(t, "{}\n", string(data))
+	})
+
+	t.Run("IntegerKeys", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[int, string]()
+		m.Set(3, "c")
+		m.Set(1, "a")
+		data, err := yaml.Marshal(m)
+		require.NoError(t, err)
+		assert.Equal(t, "3: c\n1: a\n", string(data))
+	})
+
+	t.Run("SetYAMLComments", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, int]()
+		m.Set("a", 1)
+		assert.True(t, m.SetYAMLComments("a", orderedmap.YAMLComments{
+			Key:   orderedmap.YAMLComment{Head: "# about a"},
+			Value: orderedmap.YAMLComment{Line: "# one"},
+		}))
+		assert.False(t, m.SetYAMLComments("b", orderedmap.YAMLComments{}))
+
+		data, err := yaml.Marshal(m)
+		require.NoError(t, err)
+		assert.Equal(t, "# about a\na: 1 # one\n", string(data))
+	})
+}
+
+func TestOrderedMap_UnmarshalYAML(t *testing.T) {
+	t.Run("RetainsOrder", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, int]()
+		require.NoError(t, yaml.Unmarshal([]byte("z: 1\na: 2\nm: 3\n"), m))
+		assert.Equal(t, []string{"z", "a", "m"}, slices.Collect(m.Keys()))
+		assert.Equal(t, []int{1, 2, 3}, slices.Collect(m.Values()))
+	})
+
+	t.Run("NestedAny", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, any]()
+		err := yaml.Unmarshal([]byte("b:\n  y: 1\n  x: [{d: 1, c: 2}]\na:\n  2: two\n  1: one\n"), m)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"b", "a"}, slices.Collect(m.Keys()))
+
+		b := m.GetOrDefault("b", nil).(*orderedmap.OrderedMap[string, any])
+		assert.Equal(t, []string{"y", "x"}, slices.Collect(b.Keys()))
+
+		x := b.GetOrDefault("x", nil).([]any)
+		d := x[0].(*orderedmap.OrderedMap[string, any])
+		assert.Equal(t, []string{"d", "c"}, slices.Collect(d.Keys()))
+
+		a := m.GetOrDefault("a", nil).(*orderedmap.OrderedMap[any, any])
+		assert.Equal(t, []any{2, 1}, slices.Collect(a.Keys()))
+	})
+
+	t.Run("InStruct", func(t *testing.T) {
+		var v struct {
+			Fields *orderedmap.OrderedMap[string, string] `yaml:"fields"`
+		}
+		require.NoError(t, yaml.Unmarshal([]byte("fields:\n  b: x\n  a: y\n"), &v))
+		assert.Equal(t, []string{"b", "a"}, slices.Collect(v.Fields.Keys()))
+	})
+
+	t.Run("DuplicateKey", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, int]()
+		err := yaml.Unmarshal([]byte("a: 1\nb: 2\na: 3\n"), m)
+		assert.ErrorIs(t, err, orderedmap.ErrDuplicateKey)
+	})
+
+	t.Run("NotAMapping", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, int]()
+		err := yaml.Unmarshal([]byte("- 1\n- 2\n"), m)
+		assert.Error(t, err)
+	})
+
+	t.Run("MergeKeys", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, any]()
+		err := yaml.Unmarshal([]byte(`
+base: &base
+  x: 1
+  y: 2
+other: &other
+  y: 3
+  z: 4
+merged:
+  a: 0
+  <<: [*base, *other]
+  x: 5
+`), m)
+		require.NoError(t, err)
+
+		merged := m.GetOrDefault("merged", nil).(*orderedmap.OrderedMap[string, any])
+		assert.Equal(t, []string{"a", "y", "z", "x"}, slices.Collect(merged.Keys()))
+		assert.Equal(t, []any{0, 2, 4, 5}, slices.Collect(merged.Values()))
+	})
+
+	t.Run("Aliases", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, any]()
+		err := yaml.Unmarshal([]byte("a: &x {q: 1, p: 2}\nb: *x\n"), m)
+		require.NoError(t, err)
+		b := m.GetOrDefault("b", nil).(*orderedmap.OrderedMap[string, any])
+		assert.Equal(t, []string{"q", "p"}, slices.Collect(b.Keys()))
+	})
+
+	t.Run("ExcessiveAliasing", func(t *testing.T) {
+		// Each level uses the previous level nine times, so the last level
+		// expands to 9^9 nodes.
+		laughs := "a: &a [\"lol\",\"lol\",\"lol\",\"lol\",\"lol\",\"lol\",\"lol\",\"lol\",\"lol\"]\n"
+		merges := "a: &a {lol: 1}\n"
+		for _, level := range "bcdefghi" {
+			prev := "*" + string(level-1)
+			uses := strings.TrimSuffix(strings.Repeat(prev+",", 9), ",")
+			laughs += fmt.Sprintf("%c: &%c [%s]\n", level, level, uses)
+			merges += fmt.Sprintf("%c: &%c {<<: [%s]}\n", level, level, uses)
+		}
+
+		for _, doc := range []string{laughs, merges} {
+			m := orderedmap.NewOrderedMap[string, any]()
+			err := yaml.Unmarshal([]byte(doc), m)
+			assert.ErrorContains(t, err, "excessive aliasing")
+		}
+	})
+}
+
+func TestOrderedMap_KeepYAMLComments(t *testing.T) {
+	src := `# head a
+a: 1 # line a
+# foot a
+
+# head b
+b: # line b
+    # head c
+    c: 2 # line c
+    # foot c
+d: [1] # line d
+`
+
+	t.Run("RoundTripAny", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, any]()
+		m.KeepYAMLComments(true)
+		require.NoError(t, yaml.Unmarshal([]byte(src), m))
+
+		assert.Equal(t, orderedmap.YAMLComments{
+			Key:   orderedmap.YAMLComment{Head: "# head a", Foot: "# foot a"},
+			Value: orderedmap.YAMLComment{Line: "# line a"},
+		}, m.YAMLComments("a"))
+
+		data, err := yaml.Marshal(m)
+		require.NoError(t, err)
+		assert.Equal(t, src, string(data))
+	})
+
+	t.Run("RoundTripTyped", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, *orderedmap.OrderedMap[string, int]]()
+		m.KeepYAMLComments(true)
+		src := "# head a\na:\n    # head b\n    b: 1 # line b\n"
+		require.NoError(t, yaml.Unmarshal([]byte(src), m))
+
+		data, err := yaml.Marshal(m)
+		require.NoError(t, err)
+		assert.Equal(t, src, string(data))
+	})
+
+	t.Run("ModifyThenEncode", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, any]()
+		m.KeepYAMLComments(true)
+		require.NoError(t, yaml.Unmarshal([]byte(src), m))
+
+		m.Set("a", 10)
+		m.Delete("d")
+		m.Set("e", true)
+		assert.Empty(t, m.YAMLComments("e"))
+
+		data, err := yaml.Marshal(m)
+		require.NoError(t, err)
+		assert.Equal(t, `# head a
+a: 10 # line a
+# foot a
+
+# head b
+b: # line b
+    # head c
+    c: 2 # line c
+    # foot c
+e: true
+`, string(data))
+	})
+
+	t.Run("DisabledByDefault", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap[string, any]()
+		require.NoError(t, yaml.Unmarshal([]byte(src), m))
+		assert.Empty(t, m.YAMLComments("a"))
+
+		data, err := yaml.Marshal(m)
+		require.NoError(t, err)
+		assert.Equal(t, "a: 1\nb:\n    c: 2\nd:\n    - 1\n", string(data))
+	})
+}